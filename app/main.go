@@ -1,11 +1,12 @@
 package main
 
 import (
-	"bytes"
-	"encoding/hex"
+	"flag"
 	"fmt"
 	"log"
 	"math/big"
+	"net"
+	"net/http"
 	"sync"
 	"time"
 
@@ -14,13 +15,28 @@ import (
 	signer "github.com/ignition-pillar/go-zdk/wallet"
 	"github.com/ignition-pillar/go-zdk/zdk"
 	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/store"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/kinggorrin/ptlc/transport"
+	"github.com/kinggorrin/ptlc/wire"
 	"github.com/tyler-smith/go-bip39"
-	commoncrypto "github.com/zenon-network/go-zenon/common/crypto"
 	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/constants"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
 	"github.com/zenon-network/go-zenon/wallet"
 )
 
-func party_alice(sender chan<- string, receiver <-chan string, wg *sync.WaitGroup) {
+// chainIdentifier identifies the Zenon network the swap runs on.
+const chainIdentifier = 321
+
+// The terms of the swap: Alice locks 10 ZNN in PTLC1 for 100 QSR that Bob
+// locks in PTLC2.
+var (
+	ptlc1Terms = swap.Terms{Token: types.ZnnTokenStandard, Amount: big.NewInt(1000000000)}
+	ptlc2Terms = swap.Terms{Token: types.QsrTokenStandard, Amount: big.NewInt(10000000000)}
+)
+
+func party_alice(tr transport.Transport, st *store.Store, wg *sync.WaitGroup) {
 	fmt.Printf("Alice: Start\n")
 
 	// Setup wallet
@@ -28,151 +44,180 @@ func party_alice(sender chan<- string, receiver <-chan string, wg *sync.WaitGrou
 	ks, _ := keyStoreFromMnemonic(mnemonic)
 	_, kp, _ := ks.DeriveForIndexPath(0)
 	ksigner := signer.NewSigner(kp)
-
-	// Say hello
-	sender <- "Hello from Alice"
-	fmt.Printf("Alice: %v\n", <-receiver)
-
-	// Send address
-	fmt.Printf("Alice: Send wallet addressA\n")
 	addressA := kp.Address
-	sender <- addressA.String()
 
-	// Receive address
-	fmt.Printf("Alice: Receive wallet addressB\n")
-	addressB := types.ParseAddressPanic(<-receiver)
-
-	rpc, err := client.NewClient(client.DefaultUrl, client.ChainIdentifier(321))
+	rpc, err := client.NewClient(client.DefaultUrl, client.ChainIdentifier(chainIdentifier))
 	if err != nil {
 		log.Fatal(err)
 	}
 	z := zdk.NewZdk(rpc)
 
-	currentFrontierMomentum, err := z.Ledger.GetFrontierMomentum()
+	// Resume unfinished swaps, as far as the chain allows
+	resumed := resumeSwaps("Alice", st, z, ksigner, addressA, true)
+
+	// Secure channel
+	fmt.Printf("Alice: Authenticate and encrypt the channel with the wallet key\n")
+	secure, err := transport.Handshake(tr, kp.Private, false)
 	if err != nil {
 		log.Fatal(err)
 	}
-	currentTime := currentFrontierMomentum.TimestampUnix
-	expirationTime := currentTime + (10 * 60 * 60) // convert to seconds
-
-	// Generate keys
-	fmt.Printf("Alice: Generate key pair (a1, A1), (a2, A2), (ra, Ra) and (t, T)\n")
-	a1, _, A1, _, _ := ed25519.GenerateKey2(nil)
-	a2, _, A2, _, _ := ed25519.GenerateKey2(nil)
-	ra, _, Ra, _, _ := ed25519.GenerateKey2(nil)
-	t, _, T, _, _ := ed25519.GenerateKey2(nil)
-
-	// Send public keys
-	fmt.Printf("Alice: Send public key (A1, A2, Ra, T)\n")
-	sender <- hex.EncodeToString(A1)
-	sender <- hex.EncodeToString(A2)
-	sender <- hex.EncodeToString(Ra)
-	sender <- hex.EncodeToString(T)
-
-	// Receive public keys
-	fmt.Printf("Alice: Receive public key (B1, B2, Rb)\n")
-	B1Bytes, _ := hex.DecodeString(<-receiver)
-	B1 := ed25519.PublicKey(B1Bytes)
-	B2Bytes, _ := hex.DecodeString(<-receiver)
-	B2 := ed25519.PublicKey(B2Bytes)
-	RbBytes, _ := hex.DecodeString(<-receiver)
-	Rb := ed25519.PublicKey(RbBytes)
-
-	// Key aggregation
-	fmt.Printf("Alice: Create joint public key (A1 + B1), (A2 + B2), (Ra + T) and (Rb + T)\n")
-	AB1 := ed25519.PublicKey(ed25519.CurvePoint(A1).Add(ed25519.CurvePoint(B1)))
-	AB2 := ed25519.PublicKey(ed25519.CurvePoint(A2).Add(ed25519.CurvePoint(B2)))
-	RaT := ed25519.PublicKey(ed25519.CurvePoint(Ra).Add(ed25519.CurvePoint(T)))
-	RbT := ed25519.PublicKey(ed25519.CurvePoint(Rb).Add(ed25519.CurvePoint(T)))
-
-	// Create ptlc
-	fmt.Printf("Alice: Create PTLC1: send funds, expiration and public key (A2 + B2) as Ed25519 point lock\n")
-	ptlc1AB, _ := z.Embedded.Ptlc.Create(
-		types.ZnnTokenStandard,
-		big.NewInt(1000000000),
-		int64(expirationTime),
-		0,
-		AB2)
-	pltc1, err := utils.Send(z,
-		ptlc1AB,
-		ksigner,
-		true)
+	tr = secure
+
+	// Say hello
+	fmt.Printf("Alice: Send hello with wallet addressA\n")
+	send(tr, &wire.Hello{Address: addressA})
+
+	fmt.Printf("Alice: Receive hello with wallet addressB\n")
+	addressB := receiveHello(secure)
+
+	// Continue the resumed swaps with Bob that have not been signed yet
+	for _, party := range resumed {
+		if party.Counterparty() != addressB {
+			continue
+		}
+		initiator := party.(*swap.Initiator)
+		keyExchangeA, err := initiator.RefreshNonces()
+		if err == swap.ErrInvalidState {
+			continue
+		} else if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Alice: Send public key (A1, A2, T) and refreshed public nonce (Ra1, Ra2) to continue the swap\n")
+		send(tr, &wire.KeyExchange{KeyExchange: keyExchangeA})
+
+		fmt.Printf("Alice: Receive public key (B1, B2) and refreshed public nonce (Rb1, Rb2)\n")
+		keyExchangeB := receive[*wire.KeyExchange](tr, initiator)
+		if err := initiator.ReceiveNonces(keyExchangeB.KeyExchange); err != nil {
+			abort(tr, initiator, err)
+		}
+		runInitiator(tr, z, ksigner, initiator)
+	}
+
+	// Start swap
+	index, err := st.NextIndex(addressA, 0)
 	if err != nil {
 		log.Fatal(err)
 	}
-	ptlc1Id := pltc1.Hash
-
-	// Wait 2 momentums
-	fmt.Printf("Alice: Wait 2 momentums\n")
-	time.Sleep(time.Second * 10 * 2)
-
-	// Send ptlc id
-	fmt.Printf("Alice: Send ptlc1 id\n")
-	sender <- hex.EncodeToString(ptlc1Id.Bytes())
+	fmt.Printf("Alice: Derive key pair (a1, A1), (a2, A2) and (t, T) for swap %d and generate nonce pair (ra1, Ra1) and (ra2, Ra2)\n", index)
+	keys, err := swap.DeriveKeys(ks, 0, index)
+	if err != nil {
+		log.Fatal(err)
+	}
+	initiator, err := swap.NewInitiator(nil, keys, addressA, chainIdentifier)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := initiator.SetJournal(newJournal(st, addressA, keys)); err != nil {
+		log.Fatal(err)
+	}
 
-	// Receive ptlc id
-	fmt.Printf("Alice: Receive ptlc2 id\n")
-	ptlc2Id := types.HexToHashPanic(<-receiver)
+	// Exchange keys
+	fmt.Printf("Alice: Send public key (A1, A2, T) and public nonce (Ra1, Ra2) with proofs of possession\n")
+	send(tr, &wire.KeyExchange{KeyExchange: initiator.KeyExchange()})
 
-	// Create messages
-	fmt.Printf("Alice: Create message msgA: SHA3(PTLC2 id + addressA)\n")
-	msgA := commoncrypto.Hash(append(ptlc2Id.Bytes()[:], addressA.Bytes()...))
+	fmt.Printf("Alice: Receive public key (B1, B2) and public nonce (Rb1, Rb2) and verify proofs of possession\n")
+	keyExchangeB := receive[*wire.KeyExchange](tr, initiator)
+	if err := initiator.ReceiveKeyExchange(addressB, keyExchangeB.KeyExchange); err != nil {
+		abort(tr, initiator, err)
+	}
 
-	fmt.Printf("Alice: Create message msgB: SHA3(PTLC1 id + addressB)\n")
-	msgB := commoncrypto.Hash(append(ptlc1Id.Bytes()[:], addressB.Bytes()...))
+	runInitiator(tr, z, ksigner, initiator)
 
-	// Generates challenges
-	fmt.Printf("Alice: Generate challenge (c1 = SHA512((Rb + T) || (A1 + B1) || msgA))\n")
-	c1 := ed25519.Challenge(AB1, RbT, msgA)
-	fmt.Printf("Alice: Generate challenge (c2 = SHA512((Ra + T) || (A2 + B2) || msgB))\n")
-	c2 := ed25519.Challenge(AB2, RaT, msgB)
+	fmt.Printf("Alice: End (%v)\n", initiator.State())
+	wg.Done()
+}
 
-	c1a1 := c1.Multiply(ed25519.Scalar(a1[:32]))
-	c2a2 := c2.Multiply(ed25519.Scalar(a2[:32]))
+// runInitiator runs the swap of initiator from StateKeysExchanged or, for a
+// resumed swap, StatePtlcFunded on. PTLC1 is created only if it does not
+// exist yet.
+func runInitiator(tr transport.Transport, z *zdk.Zdk, ksigner signer.Signer, initiator *swap.Initiator) {
+	ptlc1Id, _ := initiator.Ptlcs()
+	var expirationTime int64
+	if ptlc1Id.IsZero() {
+		currentFrontierMomentum, err := z.Ledger.GetFrontierMomentum()
+		if err != nil {
+			log.Fatal(err)
+		}
+		currentTime := currentFrontierMomentum.TimestampUnix
+		expirationTime = int64(currentTime + (10 * 60 * 60)) // convert to seconds
+
+		// Create ptlc
+		fmt.Printf("Alice: Create PTLC1: send funds, expiration and public key (A2 + B2) as Ed25519 point lock\n")
+		ptlc1AB, _ := z.Embedded.Ptlc.Create(
+			ptlc1Terms.Token,
+			ptlc1Terms.Amount,
+			expirationTime,
+			0,
+			initiator.LockKey())
+		pltc1, err := utils.Send(z,
+			ptlc1AB,
+			ksigner,
+			true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ptlc1Id = pltc1.Hash
+		if err := initiator.Created(ptlc1Id); err != nil {
+			log.Fatal(err)
+		}
+
+		// Wait 2 momentums
+		fmt.Printf("Alice: Wait 2 momentums\n")
+		time.Sleep(time.Second * 10 * 2)
+	} else {
+		ptlc1 := getPtlc(z, ptlc1Id)
+		if ptlc1 == nil {
+			abort(tr, initiator, fmt.Errorf("PTLC1 %v no longer exists", ptlc1Id))
+		}
+		expirationTime = ptlc1.ExpirationTime
+	}
 
-	// Sends challenges
-	fmt.Printf("Alice: Send challenge (c1 * a1) and (c2 * a2)\n")
-	sender <- hex.EncodeToString(c1a1)
-	sender <- hex.EncodeToString(c2a2)
+	// Send ptlc id
+	fmt.Printf("Alice: Send ptlc1 id\n")
+	send(tr, &wire.PtlcFunded{Id: ptlc1Id})
 
-	// Receive challenges
-	fmt.Printf("Alice: Receive challenge ((c2a2 + c2) * b2)\n")
-	c2a2b2Bytes, _ := hex.DecodeString(<-receiver)
-	c2a2b2 := ed25519.Scalar(c2a2b2Bytes)
+	// Receive ptlc id
+	fmt.Printf("Alice: Receive ptlc2 id\n")
+	ptlc2Id := receive[*wire.PtlcFunded](tr, initiator).Id
+
+	if initiator.State() == swap.StateKeysExchanged {
+		// Verify ptlc
+		fmt.Printf("Alice: Verify PTLC2 owner and public key (A1 + B1)\n")
+		ptlc2, err := z.Embedded.Ptlc.GetById(ptlc2Id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := initiator.CheckCounterpartyPtlc(ptlc2, ptlc2Terms, expirationTime); err != nil {
+			abort(tr, initiator, err)
+		}
+		if err := initiator.Funded(ptlc1Id, ptlc2Id); err != nil {
+			log.Fatal(err)
+		}
+	} else if _, funded := initiator.Ptlcs(); funded != ptlc2Id {
+		abort(tr, initiator, swap.ErrPtlcMismatch)
+	}
 
-	// Create signatures
+	// Create partial signatures
+	fmt.Printf("Alice: Create partial signature (sa1 = ra1 + c1 * a1) and (sa2 = ra2 + c2 * a2) over msgA: SHA3(PTLC2 id + addressA) and msgB: SHA3(PTLC1 id + addressB)\n")
+	sa1, sa2, err := initiator.PartialSignatures()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Sends adaptor signature to Bob
-	fmt.Printf("Alice: Send adaptor signature (s_adapt_b = (ra + c2a2b2))\n")
-	s_adapt_b := c2a2b2.Add(ed25519.Scalar(ra[:32]))
-	sender <- hex.EncodeToString(s_adapt_b)
+	// Send partial signatures
+	fmt.Printf("Alice: Send partial signature (sa1) and (sa2)\n")
+	send(tr, &wire.PartialChallenge{Partial1: sa1, Partial2: sa2})
 
-	// Receive adaptor signature
-	fmt.Printf("Alice: Receive adaptor signature (s_adapt_a = (rb + c1a1b1))\n")
-	s_adapt_a_bytes, _ := hex.DecodeString(<-receiver)
-	s_adapt_a := ed25519.Scalar(s_adapt_a_bytes)
+	// Receive partial signature
+	fmt.Printf("Alice: Receive partial signature (sb1)\n")
+	sb1 := receive[*wire.AdaptorSig](tr, initiator).Partial
 
 	// Alice is now able to publish her full signature
-	fmt.Printf("Alice: Create signature (sa = s_adapt_a + t)\n")
-	sa := s_adapt_a.Add(t)
-
-	// Verify signature
-	fmt.Printf("Alice: Verify signature (sa * G == c1 * (A1 + B1) + Rb + T)\n")
-	c1AB1 := ed25519.GeScalarMult(c1, AB1)
-	c1AB1RbT := ed25519.CurvePoint(c1AB1[:]).Add(ed25519.CurvePoint(RbT))
-
-	if !bytes.Equal(ed25519.GenerateCurvePoint(sa), c1AB1RbT) {
-		panic("Alice: signature is invalid")
-	}
-
-	// Create ed25519 signature
-	fmt.Printf("Alice: Create ed25519 signature (sa64 = bytes64(Rb + T, sa))\n")
-	sa64 := make([]byte, 64)
-	copy(sa64[:32], RbT[:32])
-	copy(sa64[32:], sa[:32])
-
-	if !ed25519.Verify(AB1, msgA, sa64) {
-		panic("Alice: signature is invalid")
+	fmt.Printf("Alice: Verify adaptor signature (s_adapt_a = sa1 + sb1) and create ed25519 signature (sa64 = bytes64(R1 + T, s_adapt_a + t))\n")
+	sa64, err := initiator.ReceivePartialSignature(sb1)
+	if err != nil {
+		abort(tr, initiator, err)
 	}
 
 	// Unlock PTLC
@@ -189,16 +234,16 @@ func party_alice(sender chan<- string, receiver <-chan string, wg *sync.WaitGrou
 	// Wait 2 momentums
 	fmt.Printf("Alice: Wait 2 momentums\n")
 	time.Sleep(time.Second * 10 * 2)
+	if err := initiator.Claimed(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Sends signature to Bob
 	// Bob should actually retrieve this onchain, but this is easier
-	sender <- hex.EncodeToString(sa)
-
-	fmt.Printf("Alice: End\n")
-	wg.Done()
+	send(tr, &wire.Claimed{Id: ptlc2Id, Signature: sa64})
 }
 
-func party_bob(sender chan<- string, receiver <-chan string, wg *sync.WaitGroup) {
+func party_bob(tr transport.Transport, st *store.Store, wg *sync.WaitGroup) {
 	fmt.Printf("Bob: Start\n")
 
 	// Setup wallet
@@ -206,163 +251,179 @@ func party_bob(sender chan<- string, receiver <-chan string, wg *sync.WaitGroup)
 	ks, _ := keyStoreFromMnemonic(mnemonic)
 	_, kp, _ := ks.DeriveForIndexPath(0)
 	ksigner := signer.NewSigner(kp)
-
-	// Say hello
-	fmt.Printf("Bob: %v\n", <-receiver)
-	sender <- "Hello from Bob"
-
-	// Receive address
-	fmt.Printf("Bob: Receive wallet addressA\n")
-	addressA := types.ParseAddressPanic(<-receiver)
-
-	// Send address
-	fmt.Printf("Bob: Send wallet addressB\n")
 	addressB := kp.Address
-	sender <- addressB.String()
 
 	// Connect client
-	rpc, err := client.NewClient(client.DefaultUrl, client.ChainIdentifier(321))
+	rpc, err := client.NewClient(client.DefaultUrl, client.ChainIdentifier(chainIdentifier))
 	if err != nil {
 		log.Fatal(err)
 	}
 	z := zdk.NewZdk(rpc)
 
-	currentFrontierMomentum, err := z.Ledger.GetFrontierMomentum()
+	// Resume unfinished swaps, as far as the chain allows
+	resumed := resumeSwaps("Bob", st, z, ksigner, addressB, false)
+
+	// Secure channel
+	fmt.Printf("Bob: Authenticate and encrypt the channel with the wallet key\n")
+	secure, err := transport.Handshake(tr, kp.Private, true)
 	if err != nil {
 		log.Fatal(err)
 	}
-	currentTime := currentFrontierMomentum.TimestampUnix
-	expirationTime := currentTime + (10 * 60 * 60) // convert to seconds
-
-	// Generate keys
-	fmt.Printf("Bob: Generate key pair (b1, B1), (b2, B2) and (rb, Rb)\n")
-	b1, _, B1, _, _ := ed25519.GenerateKey2(nil)
-	b2, _, B2, _, _ := ed25519.GenerateKey2(nil)
-	rb, _, Rb, _, _ := ed25519.GenerateKey2(nil)
-
-	// Receive public keys
-	fmt.Printf("Bob: Receive public key (A1, A2, Ra, T)\n")
-	A1Bytes, _ := hex.DecodeString(<-receiver)
-	A1 := ed25519.PublicKey(A1Bytes)
-	A2Bytes, _ := hex.DecodeString(<-receiver)
-	A2 := ed25519.PublicKey(A2Bytes)
-	RaBytes, _ := hex.DecodeString(<-receiver)
-	Ra := ed25519.PublicKey(RaBytes)
-	TBytes, _ := hex.DecodeString(<-receiver)
-	T := ed25519.PublicKey(TBytes)
-
-	// Send public key
-	fmt.Printf("Bob: Send public key (B1, B2, Rb)\n")
-	sender <- hex.EncodeToString(B1)
-	sender <- hex.EncodeToString(B2)
-	sender <- hex.EncodeToString(Rb)
-
-	// Key aggregation
-	fmt.Printf("Bob: Create joint public key (A1 + B1), (A2 + B2), (Ra + T) and (Rb + T)\n")
-	AB1 := ed25519.PublicKey(ed25519.CurvePoint(A1).Add(ed25519.CurvePoint(B1)))
-	AB2 := ed25519.PublicKey(ed25519.CurvePoint(A2).Add(ed25519.CurvePoint(B2)))
-	RaT := ed25519.PublicKey(ed25519.CurvePoint(Ra).Add(ed25519.CurvePoint(T)))
-	RbT := ed25519.PublicKey(ed25519.CurvePoint(Rb).Add(ed25519.CurvePoint(T)))
+	tr = secure
 
-	// Receive ptlc
-	fmt.Printf("Bob: Receive PTLC1 id\n")
-	ptlc1Id := types.HexToHashPanic(<-receiver)
-
-	// Verify funds
-	fmt.Printf("Bob: Verify PTLC1 funds, expiration and public key\n")
-	// TODO: Bob should verify the ptlc
+	// Say hello
+	fmt.Printf("Bob: Receive hello with wallet addressA\n")
+	addressA := receiveHello(secure)
+
+	fmt.Printf("Bob: Send hello with wallet addressB\n")
+	send(tr, &wire.Hello{Address: addressB})
+
+	// Exchange keys. A key exchange message with the keys of a resumed swap
+	// continues that swap; any other one starts a new swap.
+	fmt.Printf("Bob: Receive public key (A1, A2, T) and public nonce (Ra1, Ra2) and verify proofs of possession\n")
+	keyExchangeA := receive[*wire.KeyExchange](tr, nil)
+	for responder := resumedResponder(resumed, addressA, keyExchangeA.KeyExchange); responder != nil; responder = resumedResponder(resumed, addressA, keyExchangeA.KeyExchange) {
+		fmt.Printf("Bob: Refresh nonce pair (rb1, Rb1) and (rb2, Rb2) to continue the swap\n")
+		keyExchangeB, err := responder.RefreshNonces()
+		if err != nil {
+			abort(tr, responder, err)
+		}
+		if err := responder.ReceiveNonces(keyExchangeA.KeyExchange); err != nil {
+			abort(tr, responder, err)
+		}
+
+		fmt.Printf("Bob: Send public key (B1, B2) and refreshed public nonce (Rb1, Rb2)\n")
+		send(tr, &wire.KeyExchange{KeyExchange: keyExchangeB})
+		runResponder(tr, z, ksigner, responder)
+
+		fmt.Printf("Bob: Receive public key (A1, A2, T) and public nonce (Ra1, Ra2) and verify proofs of possession\n")
+		keyExchangeA = receive[*wire.KeyExchange](tr, nil)
+	}
 
-	// Create ptlc
-	fmt.Printf("Bob: Create PTLC2: send funds, expiration and public key (A1 + B1) as Ed25519 point lock\n")
-	ptlc2AB, _ := z.Embedded.Ptlc.Create(types.QsrTokenStandard, big.NewInt(10000000000), int64(expirationTime), 0, AB1)
-	pltc2, err := utils.Send(z,
-		ptlc2AB,
-		ksigner,
-		true)
+	// Start swap
+	index, err := st.NextIndex(addressB, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Bob: Derive key pair (b1, B1) and (b2, B2) for swap %d\n", index)
+	keys, err := swap.DeriveKeys(ks, 0, index)
 	if err != nil {
 		log.Fatal(err)
 	}
-	ptlc2Id := pltc2.Hash
+	responder := swap.NewResponder(nil, keys, addressB, chainIdentifier)
+	if err := responder.SetJournal(newJournal(st, addressB, keys)); err != nil {
+		log.Fatal(err)
+	}
 
-	// Wait 2 momentums
-	fmt.Printf("Bob: Wait 2 momentums\n")
-	time.Sleep(time.Second * 10 * 2)
+	fmt.Printf("Bob: Generate nonce pair (rb1, Rb1) and (rb2, Rb2)\n")
+	keyExchangeB, err := responder.ReceiveKeyExchange(addressA, keyExchangeA.KeyExchange)
+	if err != nil {
+		abort(tr, responder, err)
+	}
+
+	fmt.Printf("Bob: Send public key (B1, B2) and public nonce (Rb1, Rb2) with proofs of possession\n")
+	send(tr, &wire.KeyExchange{KeyExchange: keyExchangeB})
+
+	runResponder(tr, z, ksigner, responder)
+
+	fmt.Printf("Bob: End (%v)\n", responder.State())
+	wg.Done()
+}
+
+// runResponder runs the swap of responder from StateKeysExchanged or, for a
+// resumed swap, StatePtlcFunded on. PTLC2 is created only if it does not
+// exist yet.
+func runResponder(tr transport.Transport, z *zdk.Zdk, ksigner signer.Signer, responder *swap.Responder) {
+	// Receive ptlc
+	fmt.Printf("Bob: Receive PTLC1 id\n")
+	ptlc1Id := receive[*wire.PtlcFunded](tr, responder).Id
+
+	ptlc2Id, _ := responder.Ptlcs()
+	if responder.State() == swap.StateKeysExchanged {
+		var expirationTime int64
+		if ptlc2Id.IsZero() {
+			currentFrontierMomentum, err := z.Ledger.GetFrontierMomentum()
+			if err != nil {
+				log.Fatal(err)
+			}
+			currentTime := currentFrontierMomentum.TimestampUnix
+			expirationTime = int64(currentTime + (5 * 60 * 60)) // PTLC2 expires well before PTLC1
+		} else {
+			ptlc2 := getPtlc(z, ptlc2Id)
+			if ptlc2 == nil {
+				abort(tr, responder, fmt.Errorf("PTLC2 %v no longer exists", ptlc2Id))
+			}
+			expirationTime = ptlc2.ExpirationTime
+		}
+
+		// Verify ptlc
+		fmt.Printf("Bob: Verify PTLC1 owner and public key (A2 + B2)\n")
+		ptlc1, err := z.Embedded.Ptlc.GetById(ptlc1Id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := responder.CheckCounterpartyPtlc(ptlc1, ptlc1Terms, expirationTime); err != nil {
+			abort(tr, responder, err)
+		}
+
+		if ptlc2Id.IsZero() {
+			// Create ptlc
+			fmt.Printf("Bob: Create PTLC2: send funds, expiration and public key (A1 + B1) as Ed25519 point lock\n")
+			ptlc2AB, _ := z.Embedded.Ptlc.Create(ptlc2Terms.Token, ptlc2Terms.Amount, expirationTime, 0, responder.LockKey())
+			pltc2, err := utils.Send(z,
+				ptlc2AB,
+				ksigner,
+				true)
+			if err != nil {
+				log.Fatal(err)
+			}
+			ptlc2Id = pltc2.Hash
+			if err := responder.Created(ptlc2Id); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if err := responder.Funded(ptlc2Id, ptlc1Id); err != nil {
+			log.Fatal(err)
+		}
+
+		// Wait 2 momentums
+		fmt.Printf("Bob: Wait 2 momentums\n")
+		time.Sleep(time.Second * 10 * 2)
+	} else if _, funded := responder.Ptlcs(); funded != ptlc1Id {
+		abort(tr, responder, swap.ErrPtlcMismatch)
+	}
 
 	// Send ptlc id
 	fmt.Printf("Bob: Send PTLC2 id\n")
-	sender <- hex.EncodeToString(pltc2.Hash.Bytes())
-
-	// Create messages
-	fmt.Printf("Bob: Create message msgA: SHA3(PTLC2 id + addressA)\n")
-	msgA := commoncrypto.Hash(append(ptlc2Id.Bytes()[:], addressA.Bytes()...))
-	fmt.Printf("Bob: Create message msgB: SHA3(PTLC1 id + addressB)\n")
-	msgB := commoncrypto.Hash(append(ptlc1Id.Bytes()[:], addressB.Bytes()...))
-
-	// Receive challenges
-	fmt.Printf("Bob: Receive challenge (c1 * a1) and (c2 * a2)\n")
-	c1a1Bytes, _ := hex.DecodeString(<-receiver)
-	c1a1 := ed25519.Scalar(c1a1Bytes)
-	fmt.Printf("Bob: Receive challenge (c1 * a1) and (c2 * a2)\n")
-	c2a2Bytes, _ := hex.DecodeString(<-receiver)
-	c2a2 := ed25519.Scalar(c2a2Bytes)
-
-	// Generate challenges
-	fmt.Printf("Bob: Generate challenge (c1 = SHA512((Rb + T) || (A1 + B1) || msgA))\n")
-	c1 := ed25519.Challenge(AB1, RbT, msgA)
-	fmt.Printf("Bob: Generate challenge (c2 = SHA512((Ra + T) || (A2 + B2) || msgB))\n")
-	c2 := ed25519.Challenge(AB2, RaT, msgB)
-
-	c1a1b1 := c1.Multiply(ed25519.Scalar(b1[:32])).Add(c1a1)
-	c2a2b2 := c2.Multiply(ed25519.Scalar(b2[:32])).Add(c2a2)
-
-	// Bob sends c2*(a2 + b2) to Alice but keeps c1*(a1 + b1) for now
-	fmt.Printf("Bob: Send challenge ((c2a2 + c2) * b2)\n")
-	sender <- hex.EncodeToString(c2a2b2)
-
-	// Receive adapter signature
-	fmt.Printf("Bob: Receive adapter signature (s_adapt_b = (ra + c2a2b2))\n")
-	s_adapt_b_bytes, _ := hex.DecodeString(<-receiver)
-	s_adapt_b := ed25519.Scalar(s_adapt_b_bytes)
-
-	// Verify signature
-	fmt.Printf("Bob: Verify adapter signature (s_adapt_b * G == (c2 * (A2 + B2) + Ra))\n")
-	c2AB2 := ed25519.GeScalarMult(c2, AB2)
-	c2AB2Ra := ed25519.CurvePoint(c2AB2[:]).Add(ed25519.CurvePoint(Ra))
-
-	if !bytes.Equal(ed25519.GenerateCurvePoint(s_adapt_b), c2AB2Ra) {
-		panic("Bob: adapter signature is invalid")
-	}
-
-	// Verification is OK so Bob is safe to send his signature Alice
-	fmt.Printf("Bob: Send adaptor signature (s_adapt_a = (rb + c1a1b1))\n")
-	s_adapt_a := c1a1b1.Add(rb)
-	sender <- hex.EncodeToString(s_adapt_a)
-
-	// Receive signature
-	fmt.Printf("Bob: Receive signature (sa)\n")
-	sa_bytes, _ := hex.DecodeString(<-receiver)
-	sa := ed25519.Scalar(sa_bytes)
+	send(tr, &wire.PtlcFunded{Id: ptlc2Id})
 
-	// Bob can now infer `t` and build his signature
-	fmt.Printf("Bob: Extract (t = sa - s_adapt_a)\n")
-	t := sa.Subtract(s_adapt_a)
-	fmt.Printf("Bob: Create signature (sb = s_adapt_b + t)\n")
-	sb := s_adapt_b.Add(t)
-	c2AB2RaT := ed25519.CurvePoint(c2AB2[:]).Add(ed25519.CurvePoint(RaT))
+	// Receive partial signatures
+	fmt.Printf("Bob: Receive partial signature (sa1) and (sa2)\n")
+	challenge := receive[*wire.PartialChallenge](tr, responder)
 
-	fmt.Printf("Bob: Verify signature (sb * G == c2 * (A2 + B2) + Ra + T)\n")
-	if !bytes.Equal(ed25519.GenerateCurvePoint(sb), c2AB2RaT) {
-		panic("Bob: signature is invalid")
+	// Create partial signatures
+	fmt.Printf("Bob: Create partial signature (sb1 = rb1 + c1 * b1) and (sb2 = rb2 + c2 * b2) and verify adaptor signature (s_adapt_b = sa2 + sb2)\n")
+	sb1, err := responder.ReceivePartialSignatures(challenge.Partial1, challenge.Partial2)
+	if err != nil {
+		abort(tr, responder, err)
 	}
 
-	// Create ed25519 signature
-	fmt.Printf("Bob: Create ed25519 signature (sb64 = bytes64(Ra + T, sb))\n")
-	sb64 := make([]byte, 64)
-	copy(sb64[:32], RaT[:32])
-	copy(sb64[32:], sb[:32])
+	// Verification is OK so Bob is safe to send his partial signature to Alice
+	fmt.Printf("Bob: Send partial signature (sb1)\n")
+	send(tr, &wire.AdaptorSig{Partial: sb1})
 
-	if !ed25519.Verify(AB2, msgB, sb64) {
-		panic("Bob: signature is invalid")
+	// Receive signature
+	fmt.Printf("Bob: Receive signature (sa64)\n")
+	claimed := receive[*wire.Claimed](tr, responder)
+	if claimed.Id != ptlc2Id {
+		log.Fatal("claimed PTLC is not PTLC2")
+	}
+
+	// Bob can now infer `t` and build his signature
+	fmt.Printf("Bob: Extract (t = sa - s_adapt_a) and create ed25519 signature (sb64 = bytes64(R2 + T, s_adapt_b + t))\n")
+	sb64, err := responder.ReceiveSignature(claimed.Signature)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	// Unlock PTLC
@@ -379,30 +440,330 @@ func party_bob(sender chan<- string, receiver <-chan string, wg *sync.WaitGroup)
 	// Wait 2 momentums
 	fmt.Printf("Bob: Wait 2 momentums\n")
 	time.Sleep(time.Second * 10 * 2)
+	if err := responder.Claimed(); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	fmt.Printf("Bob: End\n")
-	wg.Done()
+// resumedResponder returns the resumed, unfinished swap with the
+// counterparty address whose keys ke carries, or nil if there is none.
+func resumedResponder(resumed []swapParty, address types.Address, ke *swap.KeyExchange) *swap.Responder {
+	for _, party := range resumed {
+		if party.Counterparty() == address && !party.State().Final() && party.Resumes(ke) {
+			return party.(*swap.Responder)
+		}
+	}
+	return nil
 }
 
 func main() {
+	party := flag.String("party", "both", "party to run: alice, bob or both in one process")
+	address := flag.String("address", "127.0.0.1:9735", "address alice listens on and bob connects to")
+	useWebSocket := flag.Bool("websocket", false, "connect the parties over WebSocket instead of TCP")
+	storePath := flag.String("store", "swaps", "directory of the swap journal database")
+	flag.Parse()
+
 	fmt.Println("App: Start")
 
+	// Open swap journal
+	st, err := store.Open(*storePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer st.Close()
+
 	// Create a WaitGroup
 	var wg sync.WaitGroup
-	wg.Add(2)
-
-	// Create channels
-	c1 := make(chan string)
-	c2 := make(chan string)
 
-	go party_alice(c1, c2, &wg)
-	go party_bob(c2, c1, &wg)
+	switch *party {
+	case "both":
+		// Create transport
+		trA, trB := transport.Pipe()
+
+		wg.Add(2)
+		go party_alice(trA, st, &wg)
+		go party_bob(trB, st, &wg)
+	case "alice":
+		tr, err := acceptCounterparty(*address, *useWebSocket)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer tr.Close()
+
+		wg.Add(1)
+		go party_alice(tr, st, &wg)
+	case "bob":
+		tr, err := dialCounterparty(*address, *useWebSocket)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer tr.Close()
+
+		wg.Add(1)
+		go party_bob(tr, st, &wg)
+	default:
+		log.Fatalf("unknown party %q", *party)
+	}
 
 	wg.Wait()
 
 	fmt.Println("App: End")
 }
 
+// swapParty is the part of swap.Initiator and swap.Responder used to finish
+// a resumed swap.
+type swapParty interface {
+	State() swap.State
+	LockKey() ed25519.PublicKey
+	CounterpartyLockKey() ed25519.PublicKey
+	Counterparty() types.Address
+	Resumes(ke *swap.KeyExchange) bool
+	Ptlcs() (own, counterparty types.Hash)
+	ClaimSignature() []byte
+	SetJournal(j swap.Journal) error
+	Claimed() error
+	Refunded() error
+	Abort() error
+}
+
+// newJournal returns the journal of a new swap. Keys must never be used for
+// a second swap, because the adaptor secret of the first one is public.
+func newJournal(st *store.Store, address types.Address, keys *swap.Keys) swap.Journal {
+	if _, err := st.Last(address, keys.Account, keys.Index); err != store.ErrNotFound {
+		log.Fatalf("swap %d already exists", keys.Index)
+	}
+	journal, err := st.Journal(address, keys.Account, keys.Index)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return journal
+}
+
+// resumeSwaps resumes the unfinished swaps of the party from the store and
+// finishes them as far as possible without the counterparty. It runs before
+// the connection to the counterparty, and every step looks at the chain
+// first, so that running it again after a crash records what already
+// happened instead of repeating it. It returns the swaps that are still
+// unfinished; those that have not been signed yet are continued with the
+// counterparty once the channel is open.
+func resumeSwaps(name string, st *store.Store, z *zdk.Zdk, ksigner signer.Signer, address types.Address, initiator bool) []swapParty {
+	entries, err := st.Unfinished(address)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var resumed []swapParty
+	for _, entry := range entries {
+		var party swapParty
+		if initiator {
+			party, err = swap.ResumeInitiator(nil, entry.Snapshot)
+		} else {
+			party, err = swap.ResumeResponder(nil, entry.Snapshot)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		journal, err := st.Journal(address, entry.Account, entry.Index)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := party.SetJournal(journal); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s: Resume swap %d (%v)\n", name, entry.Index, party.State())
+		resumeSwap(name, z, ksigner, address, party)
+		if !party.State().Final() {
+			resumed = append(resumed, party)
+		}
+	}
+	return resumed
+}
+
+// resumeSwap settles a resumed swap on chain: the counterparty's PTLC is
+// unlocked with the claim signature, which the responder reads from the
+// counterparty's unlock of its own PTLC, and otherwise the own PTLC is
+// reclaimed once it expired. A swap without keys of the counterparty is
+// aborted, while one whose keys were exchanged is left to be continued.
+func resumeSwap(name string, z *zdk.Zdk, ksigner signer.Signer, address types.Address, party swapParty) {
+	own, counterparty := party.Ptlcs()
+	if own.IsZero() {
+		if party.State() == swap.StateNew {
+			if err := party.Abort(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	// A PTLC that no longer exists was unlocked by the counterparty or
+	// reclaimed by the party.
+	ownPtlc := getPtlc(z, own)
+	if ownPtlc == nil {
+		_, signature := findUnlock(z, own, party.LockKey())
+		if signature == nil {
+			fmt.Printf("%s: PTLC %v was reclaimed\n", name, own)
+			if err := party.Refunded(); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if responder, ok := party.(*swap.Responder); ok && party.ClaimSignature() == nil {
+			fmt.Printf("%s: Read the claim signature of PTLC %v from the chain\n", name, own)
+			if _, err := responder.ReceiveSignature(signature); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+
+	if party.ClaimSignature() != nil {
+		if claimed(z, counterparty, party.CounterpartyLockKey(), address) {
+			fmt.Printf("%s: PTLC %v was unlocked\n", name, counterparty)
+		} else if getPtlc(z, counterparty) != nil {
+			fmt.Printf("%s: Unlock PTLC %v with the claim signature\n", name, counterparty)
+			unlock, _ := z.Embedded.Ptlc.Unlock(counterparty, party.ClaimSignature())
+			if _, err := utils.Send(z, unlock, ksigner, true); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			fmt.Printf("%s: PTLC %v was reclaimed by the counterparty\n", name, counterparty)
+			return
+		}
+		if err := party.Claimed(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if ownPtlc == nil {
+		fmt.Printf("%s: PTLC %v was unlocked, but the claim signature of PTLC %v is unknown\n", name, own, counterparty)
+		return
+	}
+	frontierMomentum, err := z.Ledger.GetFrontierMomentum()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if frontierMomentum.TimestampUnix < uint64(ownPtlc.ExpirationTime) {
+		fmt.Printf("%s: PTLC %v can be reclaimed after %v\n", name, own, time.Unix(ownPtlc.ExpirationTime, 0))
+		return
+	}
+	fmt.Printf("%s: Reclaim expired PTLC %v\n", name, own)
+	reclaim, _ := z.Embedded.Ptlc.Reclaim(own)
+	if _, err := utils.Send(z, reclaim, ksigner, true); err != nil {
+		log.Fatal(err)
+	}
+	if err := party.Refunded(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// getPtlc returns the PTLC with the given id, or nil if it no longer exists
+// because it was unlocked or reclaimed.
+func getPtlc(z *zdk.Zdk, id types.Hash) *definition.PtlcInfo {
+	ptlc, err := z.Embedded.Ptlc.GetById(id)
+	if err != nil {
+		if err.Error() == constants.ErrDataNonExistent.Error() {
+			return nil
+		}
+		log.Fatal(err)
+	}
+	return ptlc
+}
+
+// claimed reports whether the PTLC with the given id was unlocked to
+// address.
+func claimed(z *zdk.Zdk, id types.Hash, lock ed25519.PublicKey, address types.Address) bool {
+	destination, signature := findUnlock(z, id, lock)
+	return signature != nil && destination == address
+}
+
+// findUnlock searches the blocks received by the PTLC contract, newest first
+// and back to the creation of the PTLC with the given id, for an unlock of
+// it whose signature is valid for lock. It returns the destination and
+// signature of the unlock, or a nil signature if there is none. The contract
+// may have rejected the unlock, for example after expiry, but its signature
+// is public all the same.
+func findUnlock(z *zdk.Zdk, id types.Hash, lock ed25519.PublicKey) (types.Address, []byte) {
+	for page := uint32(0); ; page++ {
+		blocks, err := z.Ledger.GetAccountBlocksByPage(types.PtlcContract, page, 50)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(blocks.List) == 0 {
+			return types.Address{}, nil
+		}
+		for _, block := range blocks.List {
+			sendBlock := block.PairedAccountBlock
+			if !block.IsReceiveBlock() || sendBlock == nil {
+				continue
+			}
+			if sendBlock.Hash == id {
+				return types.Address{}, nil
+			}
+
+			unlock := new(definition.UnlockPtlcParam)
+			proxyUnlock := new(definition.ProxyUnlockPtlcParam)
+			var destination types.Address
+			var signature []byte
+			if definition.ABIPtlc.UnpackMethod(unlock, definition.UnlockPtlcMethodName, sendBlock.Data) == nil && unlock.Id == id {
+				destination, signature = sendBlock.Address, unlock.Signature
+			} else if definition.ABIPtlc.UnpackMethod(proxyUnlock, definition.ProxyUnlockPtlcMethodName, sendBlock.Data) == nil && proxyUnlock.Id == id {
+				destination, signature = proxyUnlock.Destination, proxyUnlock.Signature
+			} else {
+				continue
+			}
+			if ed25519.Verify(lock, swap.UnlockMessage(swap.MessageContract, chainIdentifier, id, destination), signature) {
+				return destination, signature
+			}
+		}
+	}
+}
+
+// acceptCounterparty waits for the counterparty to connect to address.
+func acceptCounterparty(address string, useWebSocket bool) (transport.Transport, error) {
+	if !useWebSocket {
+		listener, err := transport.Listen(address)
+		if err != nil {
+			return nil, err
+		}
+		defer listener.Close()
+		return listener.Accept()
+	}
+
+	// Only the first counterparty takes part in the swap; later connections
+	// are closed instead of blocking their handlers.
+	accepted := make(chan transport.Transport, 1)
+	server := &http.Server{Handler: transport.WebSocketHandler(func(tr transport.Transport) {
+		select {
+		case accepted <- tr:
+		default:
+			tr.Close()
+		}
+	})}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	select {
+	case tr := <-accepted:
+		listener.Close()
+		return tr, nil
+	case err := <-served:
+		return nil, err
+	}
+}
+
+// dialCounterparty connects to the counterparty listening on address.
+func dialCounterparty(address string, useWebSocket bool) (transport.Transport, error) {
+	if useWebSocket {
+		return transport.DialWebSocket("ws://" + address + "/")
+	}
+	return transport.Dial(address)
+}
+
 func keyStoreFromMnemonic(mnemonic string) (*wallet.KeyStore, error) {
 	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
 	if err != nil {
@@ -424,3 +785,68 @@ func keyStoreFromMnemonic(mnemonic string) (*wallet.KeyStore, error) {
 
 	return ks, nil
 }
+
+// send sends a message to the counterparty.
+func send(tr transport.Transport, message wire.Message) {
+	b, err := wire.MarshalBinary(message)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := tr.Send(b); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// receive waits for the next message of the counterparty, which must be of
+// type M. If the counterparty aborted the swap, the abort of party is
+// journaled before the process exits; party is nil while no swap runs.
+func receive[M wire.Message](tr transport.Transport, party swapParty) M {
+	b, err := tr.Receive()
+	if err != nil {
+		log.Fatal(err)
+	}
+	message, err := wire.UnmarshalBinary(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if abort, ok := message.(*wire.Abort); ok {
+		if party != nil {
+			abortParty(party)
+		}
+		log.Fatalf("counterparty aborted the swap: %s", abort.Reason)
+	}
+	m, ok := message.(M)
+	if !ok {
+		log.Fatalf("unexpected %v message", message.Type())
+	}
+	return m
+}
+
+// receiveHello waits for the hello of the counterparty and returns its
+// address, which must be the address of the key it authenticated with.
+func receiveHello(tr *transport.Secure) types.Address {
+	hello := receive[*wire.Hello](tr, nil)
+	if hello.Address != tr.PeerAddress() {
+		log.Fatalf("counterparty %v authenticated as %v", hello.Address, tr.PeerAddress())
+	}
+	return hello.Address
+}
+
+// abort tells the counterparty why the swap ends and aborts it.
+func abort(tr transport.Transport, party swapParty, err error) {
+	abortParty(party)
+	send(tr, &wire.Abort{Reason: err.Error()})
+	log.Fatal(err)
+}
+
+// abortParty journals the abort of party, so that resumeSwaps reclaims its
+// PTLC instead of resuming the swap. A party that holds pre-signatures can
+// still claim the counterparty's PTLC and is left to resumeSwaps as it is.
+func abortParty(party swapParty) {
+	if party.State() >= swap.StatePreSignaturesExchanged {
+		return
+	}
+	if err := party.Abort(); err != nil {
+		log.Print(err)
+	}
+}
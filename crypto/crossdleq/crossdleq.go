@@ -0,0 +1,543 @@
+// Package crossdleq implements a cross-group discrete logarithm equality proof
+// between ed25519 and secp256k1.
+//
+// The prover shows that an ed25519 point X = x*G and a secp256k1 point
+// Y = x*G' share the same secret x, so that an adaptor secret revealed on one
+// chain can be used to complete a signature on the other. Because the two
+// groups have different orders, x is decomposed into Bits bits and every bit
+// is committed to in both groups:
+//
+//	C_i = b_i*G  + r_i*H    (ed25519)
+//	D_i = b_i*G' + s_i*H'   (secp256k1)
+//
+// where H and H' are generators with unknown discrete logarithms and the
+// blinding factors are chosen so that sum(2^i * r_i) = sum(2^i * s_i) = 0.
+// A ring signature per bit proves that both commitments open to the same bit,
+// and the weighted sums of the commitments equal X and Y.
+//
+// This follows the construction used by the Monero/Bitcoin atomic swap
+// proofs (MRL-0010). A proof takes ProofSize = 56,635 bytes, nearly all of
+// it the commitments and ring signatures of the 252 bits. It is sent once
+// per swap, so the encoding favours simplicity over size.
+//
+// The prover handles x, its bits and the blinding factors in constant time;
+// the secp256k1 multiplications with secrets use the constant-time functions
+// of the secp256k1 package instead of btcec's NonConst ones.
+package crossdleq
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/crypto/secp256k1"
+)
+
+const (
+	// Bits is the number of bits of the shared secret. Secrets must be
+	// smaller than 2^Bits, which fits both group orders.
+	Bits = 252
+
+	edPointSize   = ed25519.CurvePointSize
+	secpPointSize = btcec.PubKeyBytesLenCompressed
+	scalarSize    = 32
+
+	// bitProofSize is the size of the ring signature of a single bit.
+	bitProofSize = 5 * scalarSize
+	// commitmentSize is the size of the commitments to a single bit.
+	commitmentSize = edPointSize + secpPointSize
+
+	// ProofSize is the size, in bytes, of an encoded proof: 56,635 bytes.
+	// The commitments to the lowest bit are not encoded since the verifier
+	// derives them from X and Y.
+	ProofSize = (Bits-1)*commitmentSize + Bits*bitProofSize
+)
+
+var (
+	generatorTag = []byte("PTLC/crossdleq/generator")
+	contextTag   = []byte("PTLC/crossdleq/context")
+	challengeTag = []byte("PTLC/crossdleq/challenge")
+)
+
+var (
+	// ErrSecretTooLarge is returned when the secret does not fit in Bits
+	// bits.
+	ErrSecretTooLarge = errors.New("crossdleq: secret does not fit in 252 bits")
+	// ErrInvalidProof is returned when a proof encoding is malformed.
+	ErrInvalidProof = errors.New("crossdleq: invalid proof encoding")
+)
+
+// bitProof is the ring signature proving that the commitments C and D open
+// to the same bit. e0 is the challenge of the first branch, z are the
+// ed25519 responses and w the secp256k1 responses of both branches.
+type bitProof struct {
+	e0     [scalarSize]byte
+	z0, z1 ed25519.Scalar
+	w0, w1 btcec.ModNScalar
+}
+
+// Proof is a cross-group DLEQ proof between an ed25519 and a secp256k1 point.
+type Proof struct {
+	c    [Bits]ed25519.CurvePoint
+	d    [Bits]btcec.JacobianPoint
+	bits [Bits]bitProof
+}
+
+// Prove proves that X = x*G on ed25519 and Y = x*G' on secp256k1 share the
+// secret x and returns the proof with X and Y. x is reduced modulo the
+// ed25519 group order and must be smaller than 2^Bits. If rand is nil,
+// crypto/rand.Reader will be used.
+func Prove(rand io.Reader, x ed25519.Scalar) (*Proof, ed25519.CurvePoint, *btcec.PublicKey, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if len(x) != ed25519.ScalarSize {
+		return nil, nil, nil, ed25519.ErrInvalidScalar
+	}
+
+	x = edZero().Add(x)
+	if x[31]&0xf0 != 0 {
+		return nil, nil, nil, ErrSecretTooLarge
+	}
+
+	H, HPrime := generators()
+
+	X := x.ToCurvePoint()
+	var xPrime btcec.ModNScalar
+	xPrime.SetBytes(reversed(x))
+	var Y btcec.JacobianPoint
+	secp256k1.ScalarBaseMult(&xPrime, &Y)
+
+	// Pick blinding factors whose weighted sums are zero by deriving the
+	// factor of the lowest bit from the others.
+	var r [Bits]ed25519.Scalar
+	var s [Bits]btcec.ModNScalar
+	rSum := edZero()
+	var sSum btcec.ModNScalar
+	edPower := edOne().Add(edOne())
+	var secpPower btcec.ModNScalar
+	secpPower.SetInt(2)
+	for i := 1; i < Bits; i++ {
+		var err error
+		if r[i], err = ed25519.RandomScalar(rand); err != nil {
+			return nil, nil, nil, err
+		}
+		if err = secpRandom(rand, &s[i]); err != nil {
+			return nil, nil, nil, err
+		}
+
+		rSum = rSum.Add(edPower.Multiply(r[i]))
+		var weighted btcec.ModNScalar
+		weighted.Mul2(&secpPower, &s[i])
+		sSum.Add(&weighted)
+
+		edPower = edPower.Add(edPower)
+		secpPower.Add(&secpPower)
+	}
+	r[0] = rSum.Negate()
+	s[0].NegateVal(&sSum)
+
+	// Commit to the bits as b_i*G + r_i*H and b_i*G' + s_i*H' without
+	// branching on b_i.
+	proof := new(Proof)
+	for i := 0; i < Bits; i++ {
+		edBit := edZero()
+		edBit[0] = byte(bit(x, i))
+		proof.c[i] = edMul(r[i], H).Add(edBit.ToCurvePoint())
+
+		var secpBit btcec.ModNScalar
+		secpBit.SetInt(uint32(bit(x, i)))
+		var bG btcec.JacobianPoint
+		secp256k1.ScalarBaseMult(&secpBit, &bG)
+		secp256k1.ScalarMult(&s[i], &HPrime, &proof.d[i])
+		secp256k1.Add(&proof.d[i], &bG, &proof.d[i])
+	}
+
+	ctx := proof.context(X, &Y)
+	for i := 0; i < Bits; i++ {
+		if err := proof.proveBit(rand, ctx, i, bit(x, i), r[i], &s[i], H, &HPrime); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	Y.ToAffine()
+	return proof, X, btcec.NewPublicKey(&Y.X, &Y.Y), nil
+}
+
+// proveBit creates the ring signature for bit i with value b, where the
+// commitments open with the blinding factors r and s. The branches are
+// placed in constant time, so that the timing does not reveal b.
+func (proof *Proof) proveBit(rand io.Reader, ctx []byte, i int, b int, r ed25519.Scalar, s *btcec.ModNScalar, H ed25519.CurvePoint, HPrime *btcec.JacobianPoint) error {
+	alpha, err := ed25519.RandomScalar(rand)
+	if err != nil {
+		return err
+	}
+	var beta btcec.ModNScalar
+	if err := secpRandom(rand, &beta); err != nil {
+		return err
+	}
+
+	// Commit on the real branch b and derive the challenge of the other.
+	var B btcec.JacobianPoint
+	secp256k1.ScalarMult(&beta, HPrime, &B)
+	eOther := challenge(ctx, i, b, edMul(alpha, H), &B)
+
+	// Simulate the other branch with random responses.
+	zOther, err := ed25519.RandomScalar(rand)
+	if err != nil {
+		return err
+	}
+	var wOther btcec.ModNScalar
+	if err := secpRandom(rand, &wOther); err != nil {
+		return err
+	}
+	AOther, BOther := proof.branchCommitments(i, 1-b, eOther, zOther, &wOther, H, HPrime)
+	e := challenge(ctx, i, 1-b, AOther, &BOther)
+
+	// Answer the challenge of the real branch.
+	z := edScalar(e).Multiply(r).Add(alpha)
+	var w btcec.ModNScalar
+	w.Mul2(secpScalar(e), s).Add(&beta)
+
+	// Store the real branch as branch b and the simulated one as 1-b.
+	bp := &proof.bits[i]
+	bp.e0 = e
+	subtle.ConstantTimeCopy(b, bp.e0[:], eOther[:])
+	bp.z0, bp.z1 = edSelect(b, z, zOther), edSelect(b, zOther, z)
+	bp.w0, bp.w1 = secpSelect(b, &w, &wOther), secpSelect(b, &wOther, &w)
+	return nil
+}
+
+// Verify reports whether proof shows that the ed25519 point X and the
+// secp256k1 point Y share the same secret. It returns false for a nil proof
+// or Y and for an X that is not a canonical point of the prime-order group.
+func Verify(proof *Proof, X ed25519.CurvePoint, Y *btcec.PublicKey) bool {
+	if proof == nil || Y == nil || !Y.IsOnCurve() {
+		return false
+	}
+	if _, err := ed25519.ParsePoint(X); err != nil {
+		return false
+	}
+	for i := 0; i < Bits; i++ {
+		if _, err := ed25519.ParseScalar(proof.bits[i].z0); err != nil {
+			return false
+		}
+		if _, err := ed25519.ParseScalar(proof.bits[i].z1); err != nil {
+			return false
+		}
+	}
+
+	var YJacobian btcec.JacobianPoint
+	Y.AsJacobian(&YJacobian)
+
+	// The commitments to the lowest bit are determined by X and Y.
+	p := *proof
+	p.c[0], p.d[0] = p.lowestCommitments(X, &YJacobian)
+
+	H, HPrime := generators()
+	ctx := p.context(X, &YJacobian)
+	for i := 0; i < Bits; i++ {
+		bp := &p.bits[i]
+		A0, B0 := p.branchCommitments(i, 0, bp.e0, bp.z0, &bp.w0, H, &HPrime)
+		e1 := challenge(ctx, i, 0, A0, &B0)
+		A1, B1 := p.branchCommitments(i, 1, e1, bp.z1, &bp.w1, H, &HPrime)
+		e0 := challenge(ctx, i, 1, A1, &B1)
+		if e0 != bp.e0 {
+			return false
+		}
+	}
+	return true
+}
+
+// branchCommitments recomputes the ring commitments A = z*H - e*(C_i - k*G)
+// and B = w*H' - e*(D_i - k*G') of branch k of bit i. All inputs but k are
+// public, so only the choice of k is made in constant time.
+func (proof *Proof) branchCommitments(i int, k int, e [scalarSize]byte, z ed25519.Scalar, w *btcec.ModNScalar, H ed25519.CurvePoint, HPrime *btcec.JacobianPoint) (ed25519.CurvePoint, btcec.JacobianPoint) {
+	negE := edScalar(e).Negate()
+	A := edMul(z, H).Add(edMul(negE, proof.c[i]))
+	AOne := A.Add(edScalar(e).ToCurvePoint())
+	subtle.ConstantTimeCopy(k, A, AOne)
+
+	var negEPrime btcec.ModNScalar
+	negEPrime.NegateVal(secpScalar(e))
+	var B, eD, eG, BOne btcec.JacobianPoint
+	secpMul(w, HPrime, &B)
+	secpMul(&negEPrime, &proof.d[i], &eD)
+	secpAdd(&B, &eD, &B)
+	btcec.ScalarBaseMultNonConst(secpScalar(e), &eG)
+	secpAdd(&B, &eG, &BOne)
+
+	B.ToAffine()
+	BOne.ToAffine()
+	secpPointSelect(k, &B, &BOne)
+	return A, B
+}
+
+// lowestCommitments returns C_0 = X - sum(2^i * C_i) and
+// D_0 = Y - sum(2^i * D_i) over i >= 1.
+func (proof *Proof) lowestCommitments(X ed25519.CurvePoint, Y *btcec.JacobianPoint) (ed25519.CurvePoint, btcec.JacobianPoint) {
+	C := proof.c[Bits-1]
+	D := proof.d[Bits-1]
+	for i := Bits - 2; i >= 0; i-- {
+		C = C.Add(C)
+		secpDouble(&D, &D)
+		if i > 0 {
+			C = C.Add(proof.c[i])
+			secpAdd(&D, &proof.d[i], &D)
+		}
+	}
+
+	C0 := X.Add(edMul(edOne().Negate(), C))
+
+	var negD, D0 btcec.JacobianPoint
+	D.ToAffine()
+	negD.Set(&D)
+	negD.Y.Negate(1).Normalize()
+	secpAdd(Y, &negD, &D0)
+	return C0, D0
+}
+
+// context returns the hash of the statement and all bit commitments.
+func (proof *Proof) context(X ed25519.CurvePoint, Y *btcec.JacobianPoint) []byte {
+	h := sha256.New()
+	h.Write(contextTag)
+	h.Write(X)
+	h.Write(btcec.JacobianToByteSlice(*Y))
+	for i := 0; i < Bits; i++ {
+		h.Write(proof.c[i])
+		h.Write(btcec.JacobianToByteSlice(proof.d[i]))
+	}
+	return h.Sum(nil)
+}
+
+// Bytes returns the encoding of the proof: the commitments (C_i || D_i) of
+// bits 1 to Bits-1 followed by the ring signatures (e0 || z0 || z1 || w0 ||
+// w1) of all bits.
+func (proof *Proof) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Grow(ProofSize)
+	for i := 1; i < Bits; i++ {
+		buf.Write(proof.c[i])
+		buf.Write(btcec.JacobianToByteSlice(proof.d[i]))
+	}
+	for i := 0; i < Bits; i++ {
+		bp := &proof.bits[i]
+		w0 := bp.w0.Bytes()
+		w1 := bp.w1.Bytes()
+		buf.Write(bp.e0[:])
+		buf.Write(bp.z0)
+		buf.Write(bp.z1)
+		buf.Write(w0[:])
+		buf.Write(w1[:])
+	}
+	return buf.Bytes()
+}
+
+// ParseProof parses a proof encoded with Bytes. The commitments to the lowest
+// bit are derived by Verify.
+func ParseProof(b []byte) (*Proof, error) {
+	if len(b) != ProofSize {
+		return nil, ErrInvalidProof
+	}
+
+	proof := new(Proof)
+	for i := 1; i < Bits; i++ {
+		C, err := ed25519.ParsePoint(b[:edPointSize])
+		if err != nil {
+			return nil, err
+		}
+		D, err := btcec.ParsePubKey(b[edPointSize:commitmentSize])
+		if err != nil {
+			return nil, err
+		}
+		proof.c[i] = C
+		D.AsJacobian(&proof.d[i])
+		b = b[commitmentSize:]
+	}
+	for i := 0; i < Bits; i++ {
+		bp := &proof.bits[i]
+		copy(bp.e0[:], b[:scalarSize])
+		bp.z0 = ed25519.Scalar(append([]byte(nil), b[scalarSize:2*scalarSize]...))
+		bp.z1 = ed25519.Scalar(append([]byte(nil), b[2*scalarSize:3*scalarSize]...))
+		if bp.w0.SetByteSlice(b[3*scalarSize:4*scalarSize]) || bp.w1.SetByteSlice(b[4*scalarSize:5*scalarSize]) {
+			return nil, ErrInvalidProof
+		}
+		b = b[bitProofSize:]
+	}
+	return proof, nil
+}
+
+// challenge returns the ring challenge derived from branch k of bit i,
+// truncated to Bits bits so that it is a valid scalar in both groups. It is
+// encoded in little-endian order.
+func challenge(ctx []byte, i int, k int, A ed25519.CurvePoint, B *btcec.JacobianPoint) [scalarSize]byte {
+	var index [3]byte
+	binary.BigEndian.PutUint16(index[:2], uint16(i))
+	index[2] = byte(k)
+
+	h := sha256.New()
+	h.Write(challengeTag)
+	h.Write(ctx)
+	h.Write(index[:])
+	h.Write(A)
+	h.Write(btcec.JacobianToByteSlice(*B))
+
+	var e [scalarSize]byte
+	h.Sum(e[:0])
+	e[31] &= 0x0f
+	return e
+}
+
+var (
+	generatorsOnce sync.Once
+	edGenerator    ed25519.CurvePoint
+	secpGenerator  btcec.JacobianPoint
+)
+
+// generators returns the second generators H on ed25519 and H' on
+// secp256k1. Both are derived by hashing to the curve, so nobody knows their
+// discrete logarithms relative to the base points.
+func generators() (ed25519.CurvePoint, btcec.JacobianPoint) {
+	generatorsOnce.Do(func() {
+		for counter := uint32(0); ; counter++ {
+			candidate := hashCounter("ed25519", counter)
+			if H, err := ed25519.ParsePoint(candidate[:]); err == nil {
+				edGenerator = H
+				break
+			}
+		}
+		for counter := uint32(0); ; counter++ {
+			candidate := hashCounter("secp256k1", counter)
+			if H, err := btcec.ParsePubKey(append([]byte{0x02}, candidate[:]...)); err == nil {
+				H.AsJacobian(&secpGenerator)
+				break
+			}
+		}
+	})
+	return edGenerator, secpGenerator
+}
+
+// hashCounter returns SHA256(tag || curve || counter).
+func hashCounter(curve string, counter uint32) [32]byte {
+	var c [4]byte
+	binary.BigEndian.PutUint32(c[:], counter)
+
+	h := sha256.New()
+	h.Write(generatorTag)
+	h.Write([]byte(curve))
+	h.Write(c[:])
+
+	var digest [32]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// bit returns bit i of the little-endian scalar x.
+func bit(x ed25519.Scalar, i int) int {
+	return int(x[i/8]>>(i%8)) & 1
+}
+
+// reversed returns the big-endian form of a little-endian scalar.
+func reversed(x []byte) *[32]byte {
+	var out [32]byte
+	for i := range out {
+		out[i] = x[31-i]
+	}
+	return &out
+}
+
+func edZero() ed25519.Scalar {
+	return ed25519.Scalar(make([]byte, ed25519.ScalarSize))
+}
+
+func edOne() ed25519.Scalar {
+	one := edZero()
+	one[0] = 1
+	return one
+}
+
+// edScalar converts a little-endian challenge into an ed25519 scalar.
+func edScalar(e [scalarSize]byte) ed25519.Scalar {
+	return ed25519.Scalar(append([]byte(nil), e[:]...))
+}
+
+// secpScalar converts a little-endian challenge into a secp256k1 scalar.
+func secpScalar(e [scalarSize]byte) *btcec.ModNScalar {
+	var s btcec.ModNScalar
+	s.SetBytes(reversed(e[:]))
+	return &s
+}
+
+// secpRandom sets s to a uniformly random secp256k1 scalar.
+func secpRandom(rand io.Reader, s *btcec.ModNScalar) error {
+	var b [32]byte
+	for {
+		if _, err := io.ReadFull(rand, b[:]); err != nil {
+			return err
+		}
+		if !s.SetByteSlice(b[:]) {
+			return nil
+		}
+	}
+}
+
+// edMul returns k*P on ed25519.
+func edMul(k ed25519.Scalar, P ed25519.CurvePoint) ed25519.CurvePoint {
+	return P.ScalarMult(k)
+}
+
+// edSelect returns b if choose is 1 and a if it is 0, in constant time.
+func edSelect(choose int, a, b ed25519.Scalar) ed25519.Scalar {
+	result := append(ed25519.Scalar(nil), a...)
+	subtle.ConstantTimeCopy(choose, result, b)
+	return result
+}
+
+// secpSelect returns b if choose is 1 and a if it is 0, in constant time.
+func secpSelect(choose int, a, b *btcec.ModNScalar) btcec.ModNScalar {
+	result, other := a.Bytes(), b.Bytes()
+	subtle.ConstantTimeCopy(choose, result[:], other[:])
+	var s btcec.ModNScalar
+	s.SetBytes(&result)
+	return s
+}
+
+// secpPointSelect sets P to Q if choose is 1 and leaves it unchanged if it
+// is 0, in constant time. Both points must be in affine coordinates.
+func secpPointSelect(choose int, P, Q *btcec.JacobianPoint) {
+	for _, coordinates := range [][2]*btcec.FieldVal{{&P.X, &Q.X}, {&P.Y, &Q.Y}, {&P.Z, &Q.Z}} {
+		p, q := coordinates[0].Bytes(), coordinates[1].Bytes()
+		subtle.ConstantTimeCopy(choose, p[:], q[:])
+		coordinates[0].SetBytes(p)
+	}
+}
+
+// secpMul sets result to k*P on secp256k1. It is not constant time and only
+// used with public scalars.
+func secpMul(k *btcec.ModNScalar, P, result *btcec.JacobianPoint) {
+	var affine btcec.JacobianPoint
+	affine.Set(P)
+	affine.ToAffine()
+	btcec.ScalarMultNonConst(k, &affine, result)
+}
+
+// secpAdd sets result to P + Q on secp256k1. result may alias P or Q.
+func secpAdd(P, Q, result *btcec.JacobianPoint) {
+	var sum btcec.JacobianPoint
+	btcec.AddNonConst(P, Q, &sum)
+	result.Set(&sum)
+}
+
+// secpDouble sets result to 2*P on secp256k1. result may alias P.
+func secpDouble(P, result *btcec.JacobianPoint) {
+	var double btcec.JacobianPoint
+	btcec.DoubleNonConst(P, &double)
+	result.Set(&double)
+}
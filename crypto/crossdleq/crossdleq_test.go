@@ -0,0 +1,128 @@
+package crossdleq
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+)
+
+// testSecret returns a random secret smaller than 2^Bits.
+func testSecret(t *testing.T) ed25519.Scalar {
+	t.Helper()
+	x, err := ed25519.RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x[31] &= 0x0f
+	return x
+}
+
+func TestProveVerify(t *testing.T) {
+	x := testSecret(t)
+	proof, X, Y, err := Prove(nil, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(X, x.ToCurvePoint()) {
+		t.Fatal("X is not x*G")
+	}
+	var xPrime btcec.ModNScalar
+	xPrime.SetBytes(reversed(x))
+	if !Y.IsEqual(btcec.PrivKeyFromScalar(&xPrime).PubKey()) {
+		t.Fatal("Y is not x*G'")
+	}
+	if !Verify(proof, X, Y) {
+		t.Fatal("valid proof rejected")
+	}
+
+	encoded := proof.Bytes()
+	if len(encoded) != ProofSize {
+		t.Fatalf("proof is %d bytes, want %d", len(encoded), ProofSize)
+	}
+	parsed, err := ParseProof(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(parsed, X, Y) {
+		t.Fatal("parsed proof rejected")
+	}
+	if !bytes.Equal(parsed.Bytes(), encoded) {
+		t.Fatal("encoding does not round-trip")
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	x := testSecret(t)
+	proof, X, Y, err := Prove(nil, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A secp256k1 point with a different secret.
+	other := testSecret(t)
+	var otherPrime btcec.ModNScalar
+	otherPrime.SetBytes(reversed(other))
+	if Verify(proof, X, btcec.PrivKeyFromScalar(&otherPrime).PubKey()) {
+		t.Fatal("proof accepted for the wrong Y")
+	}
+	if Verify(proof, other.ToCurvePoint(), Y) {
+		t.Fatal("proof accepted for the wrong X")
+	}
+
+	// A modified ring signature.
+	encoded := proof.Bytes()
+	encoded[len(encoded)-1] ^= 1
+	tampered, err := ParseProof(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(tampered, X, Y) {
+		t.Fatal("tampered proof accepted")
+	}
+
+	if _, err := ParseProof(encoded[1:]); err != ErrInvalidProof {
+		t.Fatalf("ParseProof of a short proof: got %v, want %v", err, ErrInvalidProof)
+	}
+}
+
+func TestVerifyRejectsInvalidInputs(t *testing.T) {
+	proof, X, Y, err := Prove(nil, testSecret(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// smallOrder encodes a point of order 4.
+	smallOrder := make(ed25519.CurvePoint, ed25519.CurvePointSize)
+
+	tests := []struct {
+		name  string
+		proof *Proof
+		X     ed25519.CurvePoint
+		Y     *btcec.PublicKey
+	}{
+		{"nil proof", nil, X, Y},
+		{"empty proof", new(Proof), X, Y},
+		{"nil X", proof, nil, Y},
+		{"short X", proof, X[1:], Y},
+		{"small-order X", proof, smallOrder, Y},
+		{"nil Y", proof, X, nil},
+		{"Y off the curve", proof, X, new(btcec.PublicKey)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if Verify(test.proof, test.X, test.Y) {
+				t.Fatal("Verify accepted invalid inputs")
+			}
+		})
+	}
+}
+
+func TestProveSecretTooLarge(t *testing.T) {
+	// 2^252 is smaller than the ed25519 group order but does not fit.
+	x := make(ed25519.Scalar, ed25519.ScalarSize)
+	x[31] = 0x10
+	if _, _, _, err := Prove(nil, x); err != ErrSecretTooLarge {
+		t.Fatalf("got %v, want %v", err, ErrSecretTooLarge)
+	}
+}
@@ -0,0 +1,119 @@
+package ed25519
+
+import "errors"
+
+// AdaptorSignatureSize is the size, in bytes, of an encoded adaptor signature.
+const AdaptorSignatureSize = CurvePointSize + ScalarSize
+
+// ErrInvalidSignature is returned when a signature encoding is malformed or
+// does not belong to the adaptor signature.
+var ErrInvalidSignature = errors.New("ed25519: invalid signature")
+
+// AdaptorSignature is an Ed25519 pre-signature encrypted under an adaptor
+// point T. It holds the nonce point R and the scalar s' = r + c*x, where the
+// challenge c = SHA512((R + T) || A || m) already commits to T.
+//
+// Adding the secret adaptor t to s' yields the regular Ed25519 signature
+// (R + T, s' + t); subtracting s' from that signature reveals t again.
+type AdaptorSignature struct {
+	R CurvePoint
+	S Scalar
+}
+
+// Bytes returns the 64-byte encoding R || s' of the adaptor signature.
+func (as *AdaptorSignature) Bytes() []byte {
+	encoded := make([]byte, AdaptorSignatureSize)
+	copy(encoded[:CurvePointSize], as.R)
+	copy(encoded[CurvePointSize:], as.S)
+	return encoded
+}
+
+// ParseAdaptorSignature parses the 64-byte encoding R || s' of an adaptor
+// signature, checking R as ParsePoint and s' as ParseScalar do.
+func ParseAdaptorSignature(b []byte) (*AdaptorSignature, error) {
+	if len(b) != AdaptorSignatureSize {
+		return nil, errors.New("ed25519: bad adaptor signature length")
+	}
+
+	R, err := ParsePoint(b[:CurvePointSize])
+	if err != nil {
+		return nil, err
+	}
+	S, err := ParseScalar(b[CurvePointSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &AdaptorSignature{R: R, S: S}, nil
+}
+
+// PreSign creates an adaptor signature of message under publicKey, encrypted
+// under the adaptor point T. key is the private scalar of publicKey and nonce
+// the secret scalar r of the nonce point R = r*G.
+func PreSign(key, nonce Scalar, publicKey PublicKey, message []byte, T CurvePoint) *AdaptorSignature {
+	R := nonce.ToCurvePoint()
+	c := Challenge(publicKey, PublicKey(R.Add(T)), message)
+	return &AdaptorSignature{
+		R: R,
+		S: c.Multiply(key).Add(nonce),
+	}
+}
+
+// PreVerify reports whether as is a valid adaptor signature of message under
+// publicKey and the adaptor point T, that is s'*G == R + c*A with
+// c = SHA512((R + T) || A || m).
+func PreVerify(publicKey PublicKey, message []byte, T CurvePoint, as *AdaptorSignature) bool {
+	if _, err := ParsePublicKey(publicKey); err != nil {
+		return false
+	}
+	if _, err := ParsePoint(T); err != nil {
+		return false
+	}
+	if _, err := ParsePoint(as.R); err != nil {
+		return false
+	}
+	if _, err := ParseScalar(as.S); err != nil {
+		return false
+	}
+
+	c := Challenge(publicKey, PublicKey(as.R.Add(T)), message)
+	expected := as.R.Add(CurvePoint(publicKey).ScalarMult(c))
+	return as.S.ToCurvePoint().Equal(expected)
+}
+
+// Adapt completes the adaptor signature with the secret adaptor t and returns
+// the 64-byte Ed25519 signature (R + T || s' + t) accepted by Verify.
+func Adapt(as *AdaptorSignature, t Adaptor) []byte {
+	T := Scalar(t).ToCurvePoint()
+	s := as.S.Add(Scalar(t))
+
+	signature := make([]byte, SignatureSize)
+	copy(signature[:32], as.R.Add(T))
+	copy(signature[32:], s)
+	return signature
+}
+
+// Extract recovers the secret adaptor t = s - s' from a completed signature
+// and the adaptor signature it was adapted from. It returns
+// ErrInvalidSignature if signature is not SignatureSize bytes, s is not
+// reduced or the nonce point of signature is not R + t*G.
+func Extract(signature []byte, as *AdaptorSignature) (Adaptor, error) {
+	if len(signature) != SignatureSize {
+		return nil, ErrInvalidSignature
+	}
+	s, err := ParseScalar(signature[32:])
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if _, err := ParsePoint(as.R); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if _, err := ParseScalar(as.S); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	t := s.Subtract(as.S)
+	if string(as.R.Add(t.ToCurvePoint())) != string(signature[:32]) {
+		return nil, ErrInvalidSignature
+	}
+	return Adaptor(t), nil
+}
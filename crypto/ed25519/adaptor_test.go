@@ -0,0 +1,144 @@
+package ed25519
+
+import (
+	stded25519 "crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+// newTestScalar returns a random scalar.
+func newTestScalar(t *testing.T) Scalar {
+	t.Helper()
+	s, err := RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestPreSignature returns an adaptor signature of message under a new
+// key, encrypted under the point of a new adaptor t.
+func newTestPreSignature(t *testing.T, message []byte) (PublicKey, Adaptor, *AdaptorSignature) {
+	t.Helper()
+	key := newTestScalar(t)
+	publicKey := PublicKey(key.ToCurvePoint())
+	adaptor := newTestScalar(t)
+	as := PreSign(key, newTestScalar(t), publicKey, message, adaptor.ToCurvePoint())
+	return publicKey, Adaptor(adaptor), as
+}
+
+// addOrder returns the unreduced encoding of s + l, which is the same scalar
+// modulo l.
+func addOrder(s Scalar) Scalar {
+	sum := make(Scalar, ScalarSize)
+	carry := 0
+	for i := range sum {
+		v := int(s[i]) + int(orderBytes[i]) + carry
+		sum[i], carry = byte(v), v>>8
+	}
+	return sum
+}
+
+func TestAdaptorSignature(t *testing.T) {
+	message := []byte("PTLC/ed25519/adaptor")
+	publicKey, adaptor, as := newTestPreSignature(t, message)
+	T := Scalar(adaptor).ToCurvePoint()
+
+	if !PreVerify(publicKey, message, T, as) {
+		t.Fatal("PreVerify rejected a valid adaptor signature")
+	}
+	parsed, err := ParseAdaptorSignature(as.Bytes())
+	if err != nil || !PreVerify(publicKey, message, T, parsed) {
+		t.Fatal("encoded adaptor signature does not round-trip")
+	}
+
+	signature := Adapt(as, adaptor)
+	if !Verify(publicKey, message, signature) {
+		t.Fatal("Verify rejected the adapted signature")
+	}
+	if !stded25519.Verify(stded25519.PublicKey(publicKey), message, signature) {
+		t.Fatal("crypto/ed25519 rejected the adapted signature")
+	}
+	// The PTLC contract checks unlock signatures with wallet.VerifySignature.
+	if valid, err := wallet.VerifySignature(stded25519.PublicKey(publicKey), message, signature); err != nil || !valid {
+		t.Fatal("the contract's check rejected the adapted signature")
+	}
+
+	if extracted, err := Extract(signature, as); err != nil || !Scalar(extracted).Equal(Scalar(adaptor)) {
+		t.Fatal("Extract did not recover the adaptor")
+	}
+}
+
+func TestExtractRejects(t *testing.T) {
+	message := []byte("PTLC/ed25519/adaptor")
+	_, adaptor, as := newTestPreSignature(t, message)
+	signature := Adapt(as, adaptor)
+	_, _, other := newTestPreSignature(t, message)
+
+	one := make(Scalar, ScalarSize)
+	one[0] = 1
+	tamperedS := append([]byte(nil), signature...)
+	copy(tamperedS[32:], Scalar(signature[32:]).Add(one))
+	unreducedS := append([]byte(nil), signature...)
+	copy(unreducedS[32:], addOrder(Scalar(signature[32:])))
+
+	tests := []struct {
+		name      string
+		signature []byte
+		as        *AdaptorSignature
+	}{
+		{"short", signature[:SignatureSize-1], as},
+		{"long", append(append([]byte(nil), signature...), 0), as},
+		{"tampered s", tamperedS, as},
+		{"unreduced s", unreducedS, as},
+		{"other adaptor signature", signature, other},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Extract(test.signature, test.as); err != ErrInvalidSignature {
+				t.Fatalf("Extract returned %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestPreVerifyRejects(t *testing.T) {
+	message := []byte("PTLC/ed25519/adaptor")
+	publicKey, adaptor, as := newTestPreSignature(t, message)
+	T := Scalar(adaptor).ToCurvePoint()
+
+	one := make(Scalar, ScalarSize)
+	one[0] = 1
+	identity := make(CurvePoint, CurvePointSize)
+	identity[0] = 1
+	smallOrder, err := hex.DecodeString(smallOrderPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey PublicKey
+		message   []byte
+		T         CurvePoint
+		as        *AdaptorSignature
+	}{
+		{"tampered S", publicKey, message, T, &AdaptorSignature{R: as.R, S: as.S.Add(one)}},
+		{"unreduced S", publicKey, message, T, &AdaptorSignature{R: as.R, S: addOrder(as.S)}},
+		{"tampered R", publicKey, message, T, &AdaptorSignature{R: as.R.Add(T), S: as.S}},
+		{"wrong T", publicKey, message, newTestScalar(t).ToCurvePoint(), as},
+		{"identity T", publicKey, message, identity, as},
+		{"small-order T", publicKey, message, CurvePoint(smallOrder), as},
+		{"wrong message", publicKey, []byte("PTLC/ed25519/other"), T, as},
+		{"wrong key", PublicKey(newTestScalar(t).ToCurvePoint()), message, T, as},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if PreVerify(test.publicKey, test.message, test.T, test.as) {
+				t.Fatal("PreVerify accepted an invalid adaptor signature")
+			}
+		})
+	}
+}
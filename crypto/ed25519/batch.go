@@ -0,0 +1,197 @@
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/sha512"
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
+)
+
+// BatchVerifier checks many signatures at once. Instead of one verification
+// equation s_i*G == R_i + h_i*A_i per signature, it checks a single random
+// linear combination
+//
+//	8 * (sum(z_i*R_i) + sum(z_i*h_i*A_i) - sum(z_i*s_i)*G) == 0
+//
+// with 128-bit random weights z_i, evaluated with one multi-scalar
+// multiplication. A batch containing an invalid signature passes with
+// probability at most 2^-128.
+//
+// The batch equation is the cofactored one of ModeCofactored, so R_i and A_i
+// must be in the prime-order subgroup: a signature whose R or A carries a
+// small-order component is reported invalid. On such points the cofactored
+// and the cofactorless equations agree, so a signature accepted by the batch
+// verifier is also accepted by Verify and by the go-zenon PTLC contract.
+type BatchVerifier struct {
+	entries []batchEntry
+}
+
+type batchEntry struct {
+	publicKey PublicKey
+	message   []byte
+	signature []byte
+}
+
+// NewBatchVerifier returns an empty batch verifier.
+func NewBatchVerifier() *BatchVerifier {
+	return &BatchVerifier{}
+}
+
+// Add queues the signature sig of message by publicKey for verification.
+func (bv *BatchVerifier) Add(publicKey PublicKey, message, sig []byte) {
+	bv.entries = append(bv.entries, batchEntry{publicKey: publicKey, message: message, signature: sig})
+}
+
+// Len returns the number of queued signatures.
+func (bv *BatchVerifier) Len() int {
+	return len(bv.entries)
+}
+
+// Verify reports whether all queued signatures are valid, drawing the random
+// weights from rand. If the batch fails, or rand fails to provide the
+// weights, every signature is verified on its own with ModeZenon and valid
+// reports which of them are valid; otherwise valid is true for all of
+// them. If rand is nil, crypto/rand.Reader will be used.
+func (bv *BatchVerifier) Verify(rand io.Reader) (ok bool, valid []bool) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	valid = make([]bool, len(bv.entries))
+	if len(bv.entries) == 0 {
+		return true, valid
+	}
+
+	decoded := make([]decodedSignature, len(bv.entries))
+	ok = true
+	for i, entry := range bv.entries {
+		decoded[i].R, decoded[i].A, decoded[i].s, decoded[i].h, valid[i] = decodeSignature(entry.publicKey, entry.message, entry.signature)
+		ok = ok && valid[i]
+	}
+	if !ok {
+		return bv.verifyEach(valid)
+	}
+
+	// Every signature contributes R_i and A_i, and the base point takes the
+	// combined s coefficient.
+	scalars := make([][32]byte, 0, 2*len(bv.entries)+1)
+	points := make([]edwards25519.ExtendedGroupElement, 0, 2*len(bv.entries)+1)
+
+	var sSum, zero [32]byte
+	for _, d := range decoded {
+		var wide [16]byte
+		if _, err := io.ReadFull(rand, wide[:]); err != nil {
+			return bv.verifyEach(valid)
+		}
+		var z, zh, zs [32]byte
+		copy(z[:], wide[:])
+		edwards25519.ScMul(&zh, &z, &d.h)
+		edwards25519.ScMul(&zs, &z, &d.s)
+		edwards25519.ScAdd(&sSum, &sSum, &zs)
+
+		scalars = append(scalars, z, zh)
+		points = append(points, d.R, d.A)
+	}
+
+	var negSSum [32]byte
+	edwards25519.ScSub(&negSSum, &zero, &sSum)
+	scalars = append(scalars, negSSum)
+	points = append(points, BasePoint().toElement())
+
+	var sum edwards25519.ExtendedGroupElement
+	edwards25519.GeMultiScalarMultVartime(&sum, scalars, points)
+	if fromElement(&sum).value().IsSmallOrder() {
+		return true, valid
+	}
+	return bv.verifyEach(valid)
+}
+
+// verifyEach verifies the entries one by one with ModeZenon, skipping those
+// that failed to decode, and reports whether all of them are valid.
+func (bv *BatchVerifier) verifyEach(decoded []bool) (bool, []bool) {
+	ok := true
+	valid := make([]bool, len(bv.entries))
+	for i, entry := range bv.entries {
+		valid[i] = decoded[i] && VerifyWithMode(entry.publicKey, entry.message, entry.signature, ModeZenon)
+		ok = ok && valid[i]
+	}
+	return ok, valid
+}
+
+// decodedSignature holds the decoded points and scalars of a queued
+// signature.
+type decodedSignature struct {
+	R, A edwards25519.ExtendedGroupElement
+	s, h [32]byte
+}
+
+// decodeSignature decodes the points R and A of a signature, checks that R is
+// canonical, that R and A are torsion-free and that s is reduced, and computes the challenge
+// h = SHA512(R || A || m) mod l.
+func decodeSignature(publicKey PublicKey, message, sig []byte) (R, A edwards25519.ExtendedGroupElement, s, h [32]byte, ok bool) {
+	if len(publicKey) != PublicKeySize || len(sig) != SignatureSize {
+		return R, A, s, h, false
+	}
+	if _, err := ParseScalar(sig[32:]); err != nil {
+		return R, A, s, h, false
+	}
+
+	var encoded [32]byte
+	copy(encoded[:], sig[:32])
+	if !R.FromBytes(&encoded) {
+		return R, A, s, h, false
+	}
+	// Verify compares the encoding of R, so a non-canonical R never passes.
+	var canonical [32]byte
+	R.ToBytes(&canonical)
+	if canonical != encoded {
+		return R, A, s, h, false
+	}
+	copy(encoded[:], publicKey)
+	if !A.FromBytes(&encoded) {
+		return R, A, s, h, false
+	}
+	// The cofactored equation only implies the cofactorless one of Verify
+	// when neither point has a small-order component.
+	if !CurvePoint(sig[:32]).IsTorsionFree() || !CurvePoint(publicKey).IsTorsionFree() {
+		return R, A, s, h, false
+	}
+	copy(s[:], sig[32:])
+
+	var digest [64]byte
+	hash := sha512.New()
+	hash.Write(sig[:32])
+	hash.Write(publicKey)
+	hash.Write(message)
+	hash.Sum(digest[:0])
+	edwards25519.ScReduce(&h, &digest)
+
+	return R, A, s, h, true
+}
+
+// MultiScalarMult returns scalars[0]*points[0] + ... + scalars[n-1]*points[n-1]
+// using a single multi-scalar multiplication, which is much faster than n
+// calls to GeScalarMult. It runs in variable time, so it must only be used
+// with public scalars. It panics if the slices differ in length or a point is
+// invalid.
+func MultiScalarMult(scalars []Scalar, points []CurvePoint) CurvePoint {
+	if len(scalars) != len(points) {
+		panic("ed25519: mismatched scalars and points")
+	}
+
+	a := make([][32]byte, len(scalars))
+	A := make([]edwards25519.ExtendedGroupElement, len(points))
+	for i := range scalars {
+		// Reduce the scalar so that the top bit is clear.
+		var wide [64]byte
+		copy(wide[:], scalars[i])
+		edwards25519.ScReduce(&a[i], &wide)
+		A[i] = points[i].toElement()
+	}
+
+	var sum edwards25519.ExtendedGroupElement
+	edwards25519.GeMultiScalarMultVartime(&sum, a, A)
+
+	return fromElement(&sum)
+}
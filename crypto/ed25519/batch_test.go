@@ -0,0 +1,176 @@
+package ed25519
+
+import (
+	stded25519 "crypto/ed25519"
+	"io"
+	"strconv"
+	"testing"
+	"testing/iotest"
+)
+
+// smallOrderPoint is a point of order 8.
+const smallOrderPoint = "c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a"
+
+type testSignature struct {
+	publicKey PublicKey
+	message   []byte
+	signature []byte
+}
+
+func newTestSignatures(t *testing.T, n int) []testSignature {
+	t.Helper()
+	signatures := make([]testSignature, n)
+	for i := range signatures {
+		publicKey, privateKey, err := stded25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte("PTLC/ed25519/batch/" + strconv.Itoa(i))
+		signatures[i] = testSignature{PublicKey(publicKey), message, stded25519.Sign(privateKey, message)}
+	}
+	return signatures
+}
+
+// newMixedOrderSignature returns a signature under a public key with a
+// small-order component, which passes the cofactored equation but not the
+// cofactorless one.
+func newMixedOrderSignature(t *testing.T) testSignature {
+	t.Helper()
+	T := decodeHex(t, smallOrderPoint)
+	a := newTestScalar(t)
+	A := a.ToCurvePoint().Add(CurvePoint(T))
+
+	for i := 0; ; i++ {
+		r := newTestScalar(t)
+		R := r.ToCurvePoint()
+		message := []byte("PTLC/ed25519/batch/mixed/" + strconv.Itoa(i))
+		h := hashToScalar(nil, R, A, message)
+		if h[0]&7 == 0 {
+			// h*T is the identity, so the cofactorless equation holds too.
+			continue
+		}
+		s := r.Add(h.Multiply(a))
+		return testSignature{PublicKey(A), message, append(R, s...)}
+	}
+}
+
+func verifyBatch(signatures []testSignature, rand io.Reader) (bool, []bool) {
+	bv := NewBatchVerifier()
+	for _, s := range signatures {
+		bv.Add(s.publicKey, s.message, s.signature)
+	}
+	return bv.Verify(rand)
+}
+
+func checkValid(t *testing.T, name string, ok bool, valid []bool, want []bool) {
+	t.Helper()
+	wantOK := true
+	for i := range want {
+		wantOK = wantOK && want[i]
+		if valid[i] != want[i] {
+			t.Errorf("%s: valid[%d] = %v, want %v", name, i, valid[i], want[i])
+		}
+	}
+	if ok != wantOK {
+		t.Errorf("%s: ok = %v, want %v", name, ok, wantOK)
+	}
+}
+
+func TestBatchVerifier(t *testing.T) {
+	signatures := newTestSignatures(t, 8)
+	for name, rand := range map[string]io.Reader{"rand": nil, "failing rand": iotest.ErrReader(iotest.ErrTimeout)} {
+		ok, valid := verifyBatch(signatures, rand)
+		checkValid(t, name, ok, valid, []bool{true, true, true, true, true, true, true, true})
+
+		invalid := append([]testSignature(nil), signatures...)
+		invalid[3].message = []byte("PTLC/ed25519/batch/other")
+		invalid[5].signature = invalid[5].signature[:SignatureSize-1]
+		ok, valid = verifyBatch(invalid, rand)
+		checkValid(t, name, ok, valid, []bool{true, true, true, false, true, false, true, true})
+	}
+
+	if ok, valid := NewBatchVerifier().Verify(nil); !ok || len(valid) != 0 {
+		t.Error("empty batch rejected")
+	}
+}
+
+// TestBatchVerifierCofactored checks that a signature that passes only the
+// cofactored equation is rejected by the batch equation and by the checks of
+// the signatures on their own, as it is by Verify.
+func TestBatchVerifierCofactored(t *testing.T) {
+	mixed := newMixedOrderSignature(t)
+	if Verify(mixed.publicKey, mixed.message, mixed.signature) {
+		t.Fatal("mixed-order signature passes the cofactorless equation")
+	}
+	if !VerifyWithMode(mixed.publicKey, mixed.message, mixed.signature, ModeCofactored) {
+		t.Fatal("mixed-order signature fails the cofactored equation")
+	}
+
+	signatures := append(newTestSignatures(t, 3), mixed)
+	ok, valid := verifyBatch(signatures, nil)
+	checkValid(t, "batch", ok, valid, []bool{true, true, true, false})
+	ok, valid = verifyBatch(signatures, iotest.ErrReader(iotest.ErrTimeout))
+	checkValid(t, "failing rand", ok, valid, []bool{true, true, true, false})
+
+	signatures[0].message = []byte("PTLC/ed25519/batch/other")
+	ok, valid = verifyBatch(signatures, nil)
+	checkValid(t, "fallback", ok, valid, []bool{false, true, true, false})
+
+	// A torsion component in R is rejected as well, even though the
+	// cofactored equation holds.
+	T := decodeHex(t, smallOrderPoint)
+	a := newTestScalar(t)
+	r := newTestScalar(t)
+	A := a.ToCurvePoint()
+	R := r.ToCurvePoint().Add(CurvePoint(T))
+	message := []byte("PTLC/ed25519/batch/mixed-nonce")
+	sig := append(R, r.Add(hashToScalar(nil, R, A, message).Multiply(a))...)
+	if !VerifyWithMode(PublicKey(A), message, sig, ModeCofactored) {
+		t.Fatal("mixed-order nonce fails the cofactored equation")
+	}
+	ok, valid = verifyBatch([]testSignature{{PublicKey(A), message, sig}}, nil)
+	checkValid(t, "mixed-order nonce", ok, valid, []bool{false})
+}
+
+// TestBatchVerifierLarge checks a batch large enough for the multi-scalar
+// multiplication to use Pippenger's method.
+func TestBatchVerifierLarge(t *testing.T) {
+	signatures := newTestSignatures(t, 100)
+	want := make([]bool, len(signatures))
+	for i := range want {
+		want[i] = true
+	}
+	ok, valid := verifyBatch(signatures, nil)
+	checkValid(t, "batch", ok, valid, want)
+
+	signatures[42].message = []byte("PTLC/ed25519/batch/other")
+	want[42] = false
+	ok, valid = verifyBatch(signatures, nil)
+	checkValid(t, "invalid", ok, valid, want)
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	T := decodeHex(t, smallOrderPoint)
+
+	// The sizes around 190 cover both the Straus and the Pippenger method.
+	for _, n := range []int{1, 189, 190, 300} {
+		scalars := make([]Scalar, n)
+		points := make([]CurvePoint, n)
+		want := Identity()
+		for i := range scalars {
+			s := newTestScalar(t)
+			r := newTestScalar(t)
+			scalars[i] = s
+			points[i] = r.ToCurvePoint()
+			if i%7 == 0 {
+				points[i] = points[i].Add(CurvePoint(T))
+			}
+			sP := GeScalarMult(s, points[i])
+			want = want.Add(CurvePoint(sP[:]))
+		}
+
+		if got := MultiScalarMult(scalars, points); !got.Equal(want) {
+			t.Errorf("n = %d: MultiScalarMult = %x, want %x", n, got, want)
+		}
+	}
+}
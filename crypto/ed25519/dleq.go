@@ -0,0 +1,97 @@
+package ed25519
+
+import (
+	"errors"
+	"io"
+)
+
+// DLEQProofSize is the size, in bytes, of an encoded DLEQ proof.
+const DLEQProofSize = 2 * ScalarSize
+
+var (
+	dleqNonceTag     = []byte("PTLC/ed25519/dleq/nonce")
+	dleqChallengeTag = []byte("PTLC/ed25519/dleq/challenge")
+)
+
+// DLEQProof is a non-interactive Chaum-Pedersen proof that two points X1 and
+// X2 have the same discrete logarithm x relative to the bases G1 and G2, that
+// is X1 = x*G1 and X2 = x*G2, without revealing x.
+//
+// It is the sigma protocol of sigma.go over the bases G1 and G2; the proof
+// is the pair (c, s).
+type DLEQProof struct {
+	C Scalar
+	S Scalar
+}
+
+// BasePoint returns the encoding of the Ed25519 base point G.
+func BasePoint() CurvePoint {
+	one := make([]byte, ScalarSize)
+	one[0] = 1
+	return Scalar(one).ToCurvePoint()
+}
+
+// ProveDLEQ proves that X1 = x*G1 and X2 = x*G2 share the discrete logarithm
+// x and returns the proof together with X1 and X2. The statement is
+// G1 || X1 || G2 || X2. If rand is nil, crypto/rand.Reader will be used.
+func ProveDLEQ(rand io.Reader, x Scalar, G1, G2 CurvePoint) (proof *DLEQProof, X1, X2 CurvePoint, err error) {
+	if len(x) != ScalarSize {
+		return nil, nil, nil, ErrInvalidScalar
+	}
+	if _, err := ParsePoint(G1); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := ParsePoint(G2); err != nil {
+		return nil, nil, nil, err
+	}
+
+	x = Scalar(make([]byte, ScalarSize)).Add(x)
+	X1 = G1.ScalarMult(x)
+	X2 = G2.ScalarMult(x)
+
+	c, s, err := proveSigma(rand, dleqNonceTag, dleqChallengeTag, x, []CurvePoint{G1, G2}, [][]byte{G1, X1, G2, X2})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &DLEQProof{C: c, S: s}, X1, X2, nil
+}
+
+// VerifyDLEQ reports whether proof shows that X1 and X2 have the same discrete
+// logarithm relative to the bases G1 and G2. It returns false for a nil
+// proof.
+func VerifyDLEQ(proof *DLEQProof, G1, X1, G2, X2 CurvePoint) bool {
+	if proof == nil {
+		return false
+	}
+	for _, point := range []CurvePoint{G1, X1, G2, X2} {
+		if _, err := ParsePoint(point); err != nil {
+			return false
+		}
+	}
+	return verifySigma(dleqChallengeTag, proof.C, proof.S, []CurvePoint{G1, G2}, []CurvePoint{X1, X2}, [][]byte{G1, X1, G2, X2})
+}
+
+// Bytes returns the 64-byte encoding c || s of the proof.
+func (proof *DLEQProof) Bytes() []byte {
+	encoded := make([]byte, DLEQProofSize)
+	copy(encoded[:ScalarSize], proof.C)
+	copy(encoded[ScalarSize:], proof.S)
+	return encoded
+}
+
+// ParseDLEQProof parses the 64-byte encoding c || s of a DLEQ proof.
+func ParseDLEQProof(b []byte) (*DLEQProof, error) {
+	if len(b) != DLEQProofSize {
+		return nil, errors.New("ed25519: bad DLEQ proof length")
+	}
+
+	c, err := ParseScalar(b[:ScalarSize])
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseScalar(b[ScalarSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &DLEQProof{C: c, S: s}, nil
+}
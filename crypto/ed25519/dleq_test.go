@@ -0,0 +1,71 @@
+package ed25519
+
+import "testing"
+
+func TestDLEQ(t *testing.T) {
+	x := newTestScalar(t)
+	G1, G2 := BasePoint(), newTestScalar(t).ToCurvePoint()
+	proof, X1, X2, err := ProveDLEQ(nil, x, G1, G2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !X1.Equal(x.ToCurvePoint()) || !X2.Equal(G2.ScalarMult(x)) {
+		t.Fatal("ProveDLEQ returned the wrong points")
+	}
+	if !VerifyDLEQ(proof, G1, X1, G2, X2) {
+		t.Fatal("VerifyDLEQ rejected a valid proof")
+	}
+
+	parsed, err := ParseDLEQProof(proof.Bytes())
+	if err != nil || !VerifyDLEQ(parsed, G1, X1, G2, X2) {
+		t.Fatal("encoded proof does not round-trip")
+	}
+	if _, err := ParseDLEQProof(proof.Bytes()[:DLEQProofSize-1]); err == nil {
+		t.Fatal("ParseDLEQProof accepted a short proof")
+	}
+	if _, err := ParseDLEQProof(append(proof.Bytes()[:ScalarSize], addOrder(proof.S)...)); err == nil {
+		t.Fatal("ParseDLEQProof accepted an unreduced s")
+	}
+}
+
+func TestVerifyDLEQRejects(t *testing.T) {
+	x := newTestScalar(t)
+	G1, G2 := BasePoint(), newTestScalar(t).ToCurvePoint()
+	proof, X1, X2, err := ProveDLEQ(nil, x, G1, G2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one := make(Scalar, ScalarSize)
+	one[0] = 1
+	smallOrder := CurvePoint(decodeHex(t, smallOrderPoint))
+	other := newTestScalar(t)
+
+	tests := []struct {
+		name           string
+		proof          *DLEQProof
+		G1, X1, G2, X2 CurvePoint
+	}{
+		{"nil proof", nil, G1, X1, G2, X2},
+		{"tampered c", &DLEQProof{C: proof.C.Add(one), S: proof.S}, G1, X1, G2, X2},
+		{"tampered s", &DLEQProof{C: proof.C, S: proof.S.Add(one)}, G1, X1, G2, X2},
+		{"unreduced s", &DLEQProof{C: proof.C, S: addOrder(proof.S)}, G1, X1, G2, X2},
+		{"different logarithms", proof, G1, X1, G2, G2.ScalarMult(other)},
+		{"wrong X1", proof, G1, other.ToCurvePoint(), G2, X2},
+		{"wrong base", proof, G1, X1, other.ToCurvePoint(), X2},
+		{"swapped statements", proof, G2, X2, G1, X1},
+		{"mixed-order X2", proof, G1, X1, G2, X2.Add(smallOrder)},
+		{"small-order base", proof, G1, X1, smallOrder, X2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if VerifyDLEQ(test.proof, test.G1, test.X1, test.G2, test.X2) {
+				t.Fatal("VerifyDLEQ accepted an invalid proof")
+			}
+		})
+	}
+
+	if _, _, _, err := ProveDLEQ(nil, x, G1, smallOrder); err != ErrSmallOrderPoint {
+		t.Fatalf("ProveDLEQ with a small-order base returned %v, want %v", err, ErrSmallOrderPoint)
+	}
+}
@@ -15,13 +15,13 @@ package ed25519
 // from SUPERCOP.
 
 import (
-	"bytes"
 	"crypto"
 	cryptorand "crypto/rand"
 	"crypto/sha512"
 	"io"
 	"strconv"
 
+	"github.com/kinggorrin/ptlc/crypto/ed25519/group"
 	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
 )
 
@@ -62,73 +62,43 @@ func (priv PrivateKey) Public() crypto.PublicKey {
 	return PublicKey(publicKey)
 }
 
+// toElement decodes cp. It panics if cp is not a valid point; input from
+// untrusted sources must be validated with ParsePoint first.
 func (cp CurvePoint) toElement() edwards25519.ExtendedGroupElement {
 	var element edwards25519.ExtendedGroupElement
 	var pointBytes [32]byte
 	copy(pointBytes[:], cp[:])
-	if !element.FromBytes(&pointBytes) {
-		panic("ed25519: unable to parse nonce point")
+	if len(cp) != CurvePointSize || !element.FromBytes(&pointBytes) {
+		panic("ed25519: unable to parse point")
 	}
 	return element
 }
 
+// Add returns cp + point. It panics if either is not a valid point.
 func (cp CurvePoint) Add(point CurvePoint) CurvePoint {
-	var newPointElement edwards25519.ExtendedGroupElement
-	var newPoint CurvePoint
-	cpElem1 := cp.toElement()
-	cpElem2 := point.toElement()
-	edwards25519.GeAdd(&newPointElement, &cpElem1, &cpElem2)
-	newPoint = make([]byte, CurvePointSize)
-	var newPointBuffer [CurvePointSize]byte
-	newPointElement.ToBytes(&newPointBuffer)
-	copy(newPoint[:], newPointBuffer[:])
-	return newPoint
+	return fromPoint(cp.value().Add(point.value()))
 }
 
+// Add returns sc + scalar mod l. It panics if either has the wrong length.
 func (sc Scalar) Add(scalar Scalar) Scalar {
-	var newScalar Scalar
-	var s, s1, s2 [ScalarSize]byte
-	copy(s1[:], sc[:])
-	copy(s2[:], scalar[:])
-	edwards25519.ScAdd(&s, &s1, &s2)
-	newScalar = make([]byte, ScalarSize)
-	copy(newScalar[:], s[:])
-	return newScalar
+	return fromScalar(sc.value().Add(scalar.value()))
 }
 
+// Subtract returns sc - scalar mod l. It panics if either has the wrong
+// length.
 func (sc Scalar) Subtract(scalar Scalar) Scalar {
-	var newScalar Scalar
-	var s, s1, s2 [ScalarSize]byte
-	copy(s1[:], sc[:])
-	copy(s2[:], scalar[:])
-	edwards25519.ScSub(&s, &s1, &s2)
-	newScalar = make([]byte, ScalarSize)
-	copy(newScalar[:], s[:])
-	return newScalar
+	return fromScalar(sc.value().Sub(scalar.value()))
 }
 
+// Multiply returns sc * scalar mod l. It panics if either has the wrong
+// length.
 func (sc Scalar) Multiply(scalar Scalar) Scalar {
-	var newScalar Scalar
-	var s, s1, s2 [ScalarSize]byte
-	copy(s1[:], sc[:])
-	copy(s2[:], scalar[:])
-	edwards25519.ScMul(&s, &s1, &s2)
-	newScalar = make([]byte, ScalarSize)
-	copy(newScalar[:], s[:])
-	return newScalar
+	return fromScalar(sc.value().Mul(scalar.value()))
 }
 
+// ToCurvePoint returns sc*G. It panics if sc has the wrong length.
 func (sc Scalar) ToCurvePoint() CurvePoint {
-	var newElem edwards25519.ExtendedGroupElement
-	var newCurvePoint CurvePoint
-	var scBuffer [ScalarSize]byte
-	copy(scBuffer[:], sc[:])
-	edwards25519.GeScalarMultBase(&newElem, &scBuffer)
-	var newCurvePointBuffer [CurvePointSize]byte
-	newElem.ToBytes(&newCurvePointBuffer)
-	newCurvePoint = make([]byte, CurvePointSize)
-	copy(newCurvePoint[:], newCurvePointBuffer[:])
-	return newCurvePoint
+	return fromPoint(group.ScalarBaseMult(sc.value()))
 }
 
 // GenerateKey generates a public/private key pair using entropy from rand.
@@ -187,6 +157,24 @@ func Challenge(ephemeral_pk PublicKey, pk PublicKey, message []byte) Scalar {
 	return Scalar(scalar)
 }
 
+// hashToScalar returns SHA512(tag || data...) mod l.
+func hashToScalar(tag []byte, data ...[]byte) Scalar {
+	var digest [64]byte
+	var reduced [32]byte
+
+	h := sha512.New()
+	h.Write(tag)
+	for _, d := range data {
+		h.Write(d)
+	}
+	h.Sum(digest[:0])
+
+	edwards25519.ScReduce(&reduced, &digest)
+	scalar := make([]byte, ScalarSize)
+	copy(scalar, reduced[:])
+	return Scalar(scalar)
+}
+
 func GenerateCurvePoint(scalar []byte) CurvePoint {
 	var scalarBytes [32]byte
 	var P edwards25519.ExtendedGroupElement
@@ -199,54 +187,11 @@ func GenerateCurvePoint(scalar []byte) CurvePoint {
 	return CurvePoint(curve)
 }
 
-// Verify reports whether sig is a valid signature of message by publicKey. It
-// will panic if len(publicKey) is not PublicKeySize.
-
-// Verification requires sB = R + H(R,A,m)A = S
-// So R = S - H(R,A,m)A
+// Verify reports whether sig is a valid signature of message by publicKey
+// under the rules of the go-zenon PTLC contract, see ModeZenon. It will panic
+// if len(publicKey) is not PublicKeySize.
 func Verify(publicKey PublicKey, message, sig []byte) bool {
-	if l := len(publicKey); l != PublicKeySize {
-		panic("ed25519: bad public key length: " + strconv.Itoa(l))
-	}
-
-	if len(sig) != SignatureSize || sig[63]&224 != 0 {
-		return false
-	}
-
-	var A edwards25519.ExtendedGroupElement
-	var publicKeyBytes [32]byte
-	copy(publicKeyBytes[:], publicKey)
-	if !A.FromBytes(&publicKeyBytes) {
-		return false
-	}
-	edwards25519.FeNeg(&A.X, &A.X)
-	edwards25519.FeNeg(&A.T, &A.T)
-
-	// H(R,A,m)
-	h := sha512.New()
-	h.Write(sig[:32])
-	h.Write(publicKey[:])
-	h.Write(message)
-	var digest [64]byte
-	h.Sum(digest[:0])
-
-	var hReduced [32]byte
-	edwards25519.ScReduce(&hReduced, &digest)
-	var hramA edwards25519.ExtendedGroupElement
-	edwards25519.GeScalarMult(&hramA, &hReduced, &A)
-	var hramABuffer [32]byte
-	hramA.ToBytes(&hramABuffer)
-
-	var R edwards25519.ProjectiveGroupElement
-	// b is little s
-	var b [32]byte
-	copy(b[:], sig[32:])
-	// R = - H(R,A,m)A + sB
-	edwards25519.GeDoubleScalarMultVartime(&R, &hReduced, &A, &b)
-
-	var checkR [32]byte
-	R.ToBytes(&checkR)
-	return bytes.Equal(sig[:32], checkR[:])
+	return VerifyWithMode(publicKey, message, sig, ModeZenon)
 }
 
 func ScSub(c, a, b *[32]byte) {
@@ -271,12 +216,15 @@ func GeAdd(A *edwards25519.ExtendedGroupElement, B *edwards25519.ExtendedGroupEl
 	return C
 }
 
+// GeScalarMult returns the encoding of a*B. It panics if a is not 32 bytes
+// or b is not a valid point encoding.
 func GeScalarMult(a []byte, b []byte) [32]byte {
-	var p edwards25519.ExtendedGroupElement
-	var aBytes, bBytes [32]byte
+	if l := len(a); l != ScalarSize {
+		panic("ed25519: bad scalar length: " + strconv.Itoa(l))
+	}
+	var aBytes [32]byte
 	copy(aBytes[:], a)
-	copy(bBytes[:], b)
-	p.FromBytes(&bBytes)
+	p := CurvePoint(b).toElement()
 
 	var h edwards25519.ExtendedGroupElement
 	edwards25519.GeScalarMult(&h, &aBytes, &p)
@@ -0,0 +1,314 @@
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var dkgProofTag = []byte("PTLC/ed25519/frost/dkg-proof")
+
+var (
+	// ErrInvalidParticipant is returned for participant indices outside 1..n
+	// and for invalid threshold parameters.
+	ErrInvalidParticipant = errors.New("ed25519: invalid FROST participant")
+	// ErrInvalidShare is returned when a secret share does not match the
+	// commitments of its dealer.
+	ErrInvalidShare = errors.New("ed25519: secret share does not match commitment")
+	// ErrInvalidDKGProof is returned when a dealer's proof of knowledge of its
+	// secret does not verify.
+	ErrInvalidDKGProof = errors.New("ed25519: invalid DKG proof of knowledge")
+	// ErrDKGFinished is returned when a DKG is used after Finish, which
+	// wipes its polynomial.
+	ErrDKGFinished = errors.New("ed25519: DKG has already finished")
+)
+
+// DKG is the state of a single participant in the FROST distributed key
+// generation of a threshold-of-participants group key.
+//
+// Every participant i acts as a dealer: it samples a random polynomial f_i of
+// degree threshold-1, broadcasts the commitments A_i,k = a_i,k*G to its
+// coefficients with a proof of knowledge of a_i,0, and privately sends the
+// share f_i(j) to every participant j. The group key is Y = sum(A_i,0) and
+// the secret share of participant j is s_j = sum(f_i(j)). No single party
+// ever learns the group secret.
+type DKG struct {
+	context      []byte
+	index        uint16
+	threshold    int
+	participants int
+	coefficients []Scalar
+	commitment   *DKGCommitment
+	finished     bool
+}
+
+// DKGCommitment is the message a participant broadcasts in the first round
+// of the DKG: the commitments to its polynomial coefficients and a Schnorr
+// proof (R, mu) of knowledge of the constant term.
+type DKGCommitment struct {
+	Index       uint16
+	Commitments []CurvePoint
+	R           CurvePoint
+	Mu          Scalar
+}
+
+// KeyShare is the outcome of the DKG for one participant: its secret share,
+// the group public key and the verification shares Y_j = s_j*G of all
+// participants, which are used to check partial signatures.
+type KeyShare struct {
+	Index              uint16
+	Threshold          int
+	Secret             Scalar
+	PublicKey          PublicKey
+	VerificationShares map[uint16]CurvePoint
+}
+
+// NewDKG starts the DKG for participant index of a threshold-of-participants
+// group. context binds the proofs of knowledge to this particular key
+// generation, for example a swap identifier. If rand is nil,
+// crypto/rand.Reader will be used.
+func NewDKG(rand io.Reader, context []byte, index uint16, threshold, participants int) (*DKG, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if threshold < 1 || threshold > participants || participants > 0xffff {
+		return nil, ErrInvalidParticipant
+	}
+	if index < 1 || int(index) > participants {
+		return nil, ErrInvalidParticipant
+	}
+
+	coefficients := make([]Scalar, threshold)
+	commitments := make([]CurvePoint, threshold)
+	for k := range coefficients {
+		coefficient, err := RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[k] = coefficient
+		commitments[k] = coefficient.ToCurvePoint()
+	}
+
+	// Prove knowledge of a_i,0 so that no participant can pick its
+	// contribution as a function of the others (rogue-key attack).
+	k, err := RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	R := k.ToCurvePoint()
+	c := dkgChallenge(context, index, commitments[0], R)
+
+	return &DKG{
+		context:      append([]byte(nil), context...),
+		index:        index,
+		threshold:    threshold,
+		participants: participants,
+		coefficients: coefficients,
+		commitment: &DKGCommitment{
+			Index:       index,
+			Commitments: commitments,
+			R:           R,
+			Mu:          c.Multiply(coefficients[0]).Add(k),
+		},
+	}, nil
+}
+
+// Commitment returns the message to broadcast to all participants in the
+// first round.
+func (d *DKG) Commitment() *DKGCommitment {
+	return d.commitment
+}
+
+// Share returns the secret share f_i(j) for participant j, which must be
+// sent to j over a private channel in the second round. All shares must be
+// sent before Finish.
+func (d *DKG) Share(j uint16) (Scalar, error) {
+	if d.finished {
+		return nil, ErrDKGFinished
+	}
+	if j < 1 || int(j) > d.participants {
+		return nil, ErrInvalidParticipant
+	}
+	return evaluatePolynomial(d.coefficients, indexScalar(j)), nil
+}
+
+// Finish verifies the commitments of all participants, including its own,
+// and the shares received from them, keyed by dealer index, and returns the
+// key share of the local participant. The polynomial is wiped afterwards, and
+// a second call returns ErrDKGFinished.
+func (d *DKG) Finish(commitments []*DKGCommitment, shares map[uint16]Scalar) (*KeyShare, error) {
+	if d.finished {
+		return nil, ErrDKGFinished
+	}
+	if len(commitments) != d.participants || len(shares) != d.participants {
+		return nil, ErrInvalidParticipant
+	}
+
+	seen := make(map[uint16]bool, d.participants)
+	for _, commitment := range commitments {
+		if commitment.Index < 1 || int(commitment.Index) > d.participants || seen[commitment.Index] {
+			return nil, ErrInvalidParticipant
+		}
+		seen[commitment.Index] = true
+		if err := verifyDKGCommitment(d.context, d.threshold, commitment); err != nil {
+			return nil, err
+		}
+	}
+
+	x := indexScalar(d.index)
+	secret := Scalar(make([]byte, ScalarSize))
+	var groupKey CurvePoint
+	for _, commitment := range commitments {
+		share, ok := shares[commitment.Index]
+		if !ok {
+			return nil, ErrInvalidParticipant
+		}
+		if _, err := ParseScalar(share); err != nil {
+			return nil, err
+		}
+		if !share.ToCurvePoint().Equal(evaluateCommitments(commitment.Commitments, x)) {
+			return nil, ErrInvalidShare
+		}
+
+		secret = secret.Add(share)
+		if groupKey == nil {
+			groupKey = commitment.Commitments[0]
+		} else {
+			groupKey = groupKey.Add(commitment.Commitments[0])
+		}
+	}
+
+	verificationShares := make(map[uint16]CurvePoint, d.participants)
+	for j := 1; j <= d.participants; j++ {
+		xj := indexScalar(uint16(j))
+		var Yj CurvePoint
+		for _, commitment := range commitments {
+			term := evaluateCommitments(commitment.Commitments, xj)
+			if Yj == nil {
+				Yj = term
+			} else {
+				Yj = Yj.Add(term)
+			}
+		}
+		verificationShares[uint16(j)] = Yj
+	}
+
+	if _, err := ParsePublicKey(groupKey); err != nil {
+		return nil, err
+	}
+
+	d.finished = true
+	for _, coefficient := range d.coefficients {
+		for i := range coefficient {
+			coefficient[i] = 0
+		}
+	}
+
+	return &KeyShare{
+		Index:              d.index,
+		Threshold:          d.threshold,
+		Secret:             secret,
+		PublicKey:          PublicKey(groupKey),
+		VerificationShares: verificationShares,
+	}, nil
+}
+
+// Bytes returns the encoding index || A_0 || ... || A_t-1 || R || mu of the
+// commitment, with the index in big-endian order.
+func (dc *DKGCommitment) Bytes() []byte {
+	encoded := make([]byte, 2, 2+(len(dc.Commitments)+1)*CurvePointSize+ScalarSize)
+	binary.BigEndian.PutUint16(encoded, dc.Index)
+	for _, commitment := range dc.Commitments {
+		encoded = append(encoded, commitment...)
+	}
+	encoded = append(encoded, dc.R...)
+	return append(encoded, dc.Mu...)
+}
+
+// ParseDKGCommitment parses a commitment of a DKG with the given threshold,
+// checking all points as ParsePoint and mu as ParseScalar do.
+func ParseDKGCommitment(b []byte, threshold int) (*DKGCommitment, error) {
+	if threshold < 1 || len(b) != 2+(threshold+1)*CurvePointSize+ScalarSize {
+		return nil, errors.New("ed25519: bad DKG commitment length")
+	}
+
+	dc := &DKGCommitment{Index: binary.BigEndian.Uint16(b)}
+	b = b[2:]
+	for k := 0; k < threshold; k++ {
+		commitment, err := ParsePoint(b[:CurvePointSize])
+		if err != nil {
+			return nil, err
+		}
+		dc.Commitments = append(dc.Commitments, commitment)
+		b = b[CurvePointSize:]
+	}
+
+	R, err := ParsePoint(b[:CurvePointSize])
+	if err != nil {
+		return nil, err
+	}
+	mu, err := ParseScalar(b[CurvePointSize:])
+	if err != nil {
+		return nil, err
+	}
+	dc.R, dc.Mu = R, mu
+	return dc, nil
+}
+
+// verifyDKGCommitment checks the points of a commitment and its proof of
+// knowledge mu*G == R + c*A_0.
+func verifyDKGCommitment(context []byte, threshold int, commitment *DKGCommitment) error {
+	if len(commitment.Commitments) != threshold {
+		return ErrInvalidParticipant
+	}
+	for _, point := range commitment.Commitments {
+		if _, err := ParsePoint(point); err != nil {
+			return err
+		}
+	}
+	if _, err := ParsePoint(commitment.R); err != nil {
+		return err
+	}
+	if _, err := ParseScalar(commitment.Mu); err != nil {
+		return err
+	}
+
+	c := dkgChallenge(context, commitment.Index, commitment.Commitments[0], commitment.R)
+	expected := commitment.R.Add(commitment.Commitments[0].ScalarMult(c))
+	if !commitment.Mu.ToCurvePoint().Equal(expected) {
+		return ErrInvalidDKGProof
+	}
+	return nil
+}
+
+// dkgChallenge returns c = SHA512(tag || context || i || A_i,0 || R) mod l.
+func dkgChallenge(context []byte, index uint16, A0, R CurvePoint) Scalar {
+	return hashToScalar(dkgProofTag, context, indexBytes(index), A0, R)
+}
+
+// evaluatePolynomial returns f(x) = a_0 + a_1*x + ... + a_t-1*x^(t-1).
+func evaluatePolynomial(coefficients []Scalar, x Scalar) Scalar {
+	result := Scalar(make([]byte, ScalarSize))
+	for k := len(coefficients) - 1; k >= 0; k-- {
+		result = result.Multiply(x).Add(coefficients[k])
+	}
+	return result
+}
+
+// evaluateCommitments returns f(x)*G = A_0 + x*A_1 + ... + x^(t-1)*A_t-1
+// from the commitments to the coefficients of f.
+func evaluateCommitments(commitments []CurvePoint, x Scalar) CurvePoint {
+	result := commitments[len(commitments)-1]
+	for k := len(commitments) - 2; k >= 0; k-- {
+		result = result.ScalarMult(x).Add(commitments[k])
+	}
+	return result
+}
+
+// indexScalar returns the participant index i as a scalar.
+func indexScalar(index uint16) Scalar {
+	scalar := make([]byte, ScalarSize)
+	binary.LittleEndian.PutUint16(scalar, index)
+	return Scalar(scalar)
+}
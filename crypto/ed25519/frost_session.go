@@ -0,0 +1,172 @@
+package ed25519
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var frostBindingTag = []byte("PTLC/ed25519/frost/binding")
+
+// ThresholdSession is a single FROST signing session of a subset of at least
+// threshold participants of a group key, producing an adaptor signature of
+// one message under the group key and an optional adaptor point T.
+//
+// Every signer contributes a nonce pair (D_i, E_i), generated with
+// GenerateNonce. The binding factors rho_i = H(Y || i || T || B || m), where
+// B is the list of all nonce pairs, tie each nonce to the session, and the
+// group nonce is R = sum(D_i + rho_i*E_i). Signer i answers the challenge
+// c = H((R + T) || Y || m) with z_i = d_i + rho_i*e_i + lambda_i*s_i*c, where
+// lambda_i is its Lagrange coefficient in the signing set.
+//
+// Without an adaptor point the aggregated signature is already the standard
+// 64-byte Ed25519 signature R || s.
+type ThresholdSession struct {
+	share     *KeyShare
+	secret    *SecretNonce
+	nonces    map[uint16]PublicNonce
+	signers   []uint16
+	bindings  map[uint16]Scalar
+	challenge Scalar
+	nonce     CurvePoint
+}
+
+// NewThresholdSession starts a FROST signing session for message under the
+// group key of share. nonces holds the public nonces of all signers in the
+// session keyed by participant index, including the local one, whose secret
+// nonce is secretNonce. T is the adaptor point, or nil for a plain signature,
+// which is treated as the identity.
+func NewThresholdSession(share *KeyShare, secretNonce *SecretNonce, nonces map[uint16]PublicNonce, message []byte, T CurvePoint) (*ThresholdSession, error) {
+	if secretNonce == nil || secretNonce.used {
+		return nil, ErrNonceReused
+	}
+	if len(nonces) < share.Threshold {
+		return nil, errors.New("ed25519: not enough signers for the threshold")
+	}
+	if _, ok := nonces[share.Index]; !ok {
+		return nil, ErrInvalidParticipant
+	}
+	if T == nil {
+		T = Identity()
+	} else if _, err := ParsePoint(T); err != nil {
+		return nil, err
+	}
+
+	signers := make([]uint16, 0, len(nonces))
+	for index, nonce := range nonces {
+		if _, ok := share.VerificationShares[index]; !ok {
+			return nil, ErrInvalidParticipant
+		}
+		if _, err := ParsePublicNonce(nonce); err != nil {
+			return nil, err
+		}
+		signers = append(signers, index)
+	}
+	sort.Slice(signers, func(i, j int) bool { return signers[i] < signers[j] })
+
+	var encodedNonces bytes.Buffer
+	for _, index := range signers {
+		encodedNonces.Write(indexBytes(index))
+		encodedNonces.Write(nonces[index])
+	}
+
+	bindings := make(map[uint16]Scalar, len(signers))
+	var R CurvePoint
+	for _, index := range signers {
+		bindings[index] = hashToScalar(frostBindingTag, share.PublicKey, indexBytes(index), T, encodedNonces.Bytes(), message)
+		Ri := nonces[index].R1().Add(nonces[index].R2().ScalarMult(bindings[index]))
+		if R == nil {
+			R = Ri
+		} else {
+			R = R.Add(Ri)
+		}
+	}
+
+	return &ThresholdSession{
+		share:     share,
+		secret:    secretNonce,
+		nonces:    nonces,
+		signers:   signers,
+		bindings:  bindings,
+		challenge: Challenge(share.PublicKey, PublicKey(R.Add(T)), message),
+		nonce:     R,
+	}, nil
+}
+
+// Nonce returns the group nonce point R of the session.
+func (s *ThresholdSession) Nonce() CurvePoint {
+	return s.nonce
+}
+
+// PartialSign returns the signature share z_i = d_i + rho_i*e_i +
+// lambda_i*s_i*c of the local signer. The secret nonce is wiped afterwards,
+// so a second call returns ErrNonceReused.
+func (s *ThresholdSession) PartialSign() (Scalar, error) {
+	if s.secret.used {
+		return nil, ErrNonceReused
+	}
+
+	lambda := lagrangeCoefficient(s.share.Index, s.signers)
+	partial := s.bindings[s.share.Index].Multiply(s.secret.k2).Add(s.secret.k1).Add(s.challenge.Multiply(lambda).Multiply(s.share.Secret))
+
+	s.secret.used = true
+	for i := range s.secret.k1 {
+		s.secret.k1[i] = 0
+		s.secret.k2[i] = 0
+	}
+
+	return partial, nil
+}
+
+// PartialVerify reports whether partial is a valid signature share of
+// participant index, that is partial*G == D_i + rho_i*E_i + c*lambda_i*Y_i.
+func (s *ThresholdSession) PartialVerify(index uint16, partial Scalar) bool {
+	if _, err := ParseScalar(partial); err != nil {
+		return false
+	}
+	nonce, ok := s.nonces[index]
+	if !ok {
+		return false
+	}
+
+	lambda := lagrangeCoefficient(index, s.signers)
+	cY := s.share.VerificationShares[index].ScalarMult(s.challenge.Multiply(lambda))
+	expected := nonce.R1().Add(nonce.R2().ScalarMult(s.bindings[index])).Add(cY)
+	return partial.ToCurvePoint().Equal(expected)
+}
+
+// Aggregate sums the signature shares of all signers into the adaptor
+// signature (R, s') of the session. When the session has no adaptor point,
+// its Bytes are the final Ed25519 signature.
+func (s *ThresholdSession) Aggregate(partials ...Scalar) *AdaptorSignature {
+	S := Scalar(make([]byte, ScalarSize))
+	for _, partial := range partials {
+		S = S.Add(partial)
+	}
+	return &AdaptorSignature{R: s.nonce, S: S}
+}
+
+// lagrangeCoefficient returns lambda_i = prod(j / (j - i)) over all signers
+// j other than i, evaluating the interpolated polynomial at zero.
+func lagrangeCoefficient(index uint16, signers []uint16) Scalar {
+	numerator := indexScalar(1)
+	denominator := indexScalar(1)
+	xi := indexScalar(index)
+	for _, signer := range signers {
+		if signer == index {
+			continue
+		}
+		xj := indexScalar(signer)
+		numerator = numerator.Multiply(xj)
+		denominator = denominator.Multiply(xj.Subtract(xi))
+	}
+	return numerator.Multiply(denominator.Invert())
+}
+
+// indexBytes returns the big-endian encoding of a participant index.
+func indexBytes(index uint16) []byte {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], index)
+	return b[:]
+}
@@ -0,0 +1,245 @@
+package ed25519
+
+import "testing"
+
+var testDKGContext = []byte("PTLC/ed25519/frost/test")
+
+// newTestDKG runs the first round of a threshold-of-participants DKG and
+// returns the participants, their commitments and the shares sent to each
+// participant, keyed by recipient and dealer.
+func newTestDKG(t *testing.T, threshold, participants int) ([]*DKG, []*DKGCommitment, map[uint16]map[uint16]Scalar) {
+	t.Helper()
+	dkgs := make([]*DKG, participants)
+	commitments := make([]*DKGCommitment, participants)
+	for i := range dkgs {
+		dkg, err := NewDKG(nil, testDKGContext, uint16(i+1), threshold, participants)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dkgs[i], commitments[i] = dkg, dkg.Commitment()
+	}
+
+	shares := make(map[uint16]map[uint16]Scalar, participants)
+	for j := 1; j <= participants; j++ {
+		shares[uint16(j)] = make(map[uint16]Scalar, participants)
+		for _, dkg := range dkgs {
+			share, err := dkg.Share(uint16(j))
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares[uint16(j)][dkg.index] = share
+		}
+	}
+	return dkgs, commitments, shares
+}
+
+// newTestKeyShares runs a complete DKG and returns the key shares of all
+// participants.
+func newTestKeyShares(t *testing.T, threshold, participants int) []*KeyShare {
+	t.Helper()
+	dkgs, commitments, shares := newTestDKG(t, threshold, participants)
+	keyShares := make([]*KeyShare, participants)
+	for i, dkg := range dkgs {
+		keyShare, err := dkg.Finish(commitments, shares[dkg.index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyShares[i] = keyShare
+	}
+	for _, keyShare := range keyShares[1:] {
+		if !CurvePoint(keyShare.PublicKey).Equal(CurvePoint(keyShares[0].PublicKey)) {
+			t.Fatal("participants disagree on the group key")
+		}
+	}
+	return keyShares
+}
+
+// thresholdSign signs message under the adaptor point T with the given key
+// shares and returns the aggregated adaptor signature.
+func thresholdSign(t *testing.T, signers []*KeyShare, message []byte, T CurvePoint) *AdaptorSignature {
+	t.Helper()
+	secrets := make(map[uint16]*SecretNonce, len(signers))
+	nonces := make(map[uint16]PublicNonce, len(signers))
+	for _, signer := range signers {
+		secret, nonce, err := GenerateNonce(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets[signer.Index], nonces[signer.Index] = secret, nonce
+	}
+
+	sessions := make([]*ThresholdSession, len(signers))
+	partials := make([]Scalar, len(signers))
+	for i, signer := range signers {
+		session, err := NewThresholdSession(signer, secrets[signer.Index], nonces, message, T)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if partials[i], err = session.PartialSign(); err != nil {
+			t.Fatal(err)
+		}
+		sessions[i] = session
+	}
+	for i, signer := range signers {
+		for _, session := range sessions {
+			if !session.PartialVerify(signer.Index, partials[i]) {
+				t.Fatalf("signature share of participant %d rejected", signer.Index)
+			}
+		}
+	}
+	return sessions[0].Aggregate(partials...)
+}
+
+func TestFrost(t *testing.T) {
+	keyShares := newTestKeyShares(t, 2, 3)
+	message := []byte("PTLC/ed25519/frost")
+
+	for _, signers := range [][]*KeyShare{
+		{keyShares[0], keyShares[1]},
+		{keyShares[0], keyShares[2]},
+		{keyShares[1], keyShares[2]},
+		keyShares,
+	} {
+		signature := thresholdSign(t, signers, message, nil).Bytes()
+		if !Verify(keyShares[0].PublicKey, message, signature) {
+			t.Fatalf("Verify rejected the signature of %d signers", len(signers))
+		}
+	}
+}
+
+func TestFrostAdaptor(t *testing.T) {
+	keyShares := newTestKeyShares(t, 2, 3)
+	message := []byte("PTLC/ed25519/frost/adaptor")
+	adaptor := newTestScalar(t)
+	T := adaptor.ToCurvePoint()
+
+	as := thresholdSign(t, []*KeyShare{keyShares[2], keyShares[0]}, message, T)
+	publicKey := keyShares[0].PublicKey
+	if !PreVerify(publicKey, message, T, as) {
+		t.Fatal("PreVerify rejected the threshold adaptor signature")
+	}
+	signature := Adapt(as, Adaptor(adaptor))
+	if !Verify(publicKey, message, signature) {
+		t.Fatal("Verify rejected the adapted threshold signature")
+	}
+	if extracted, err := Extract(signature, as); err != nil || !Scalar(extracted).Equal(adaptor) {
+		t.Fatal("Extract did not recover the adaptor")
+	}
+}
+
+func TestFrostPartialVerifyRejects(t *testing.T) {
+	keyShares := newTestKeyShares(t, 2, 3)
+	signers := keyShares[:2]
+	secrets := make(map[uint16]*SecretNonce)
+	nonces := make(map[uint16]PublicNonce)
+	for _, signer := range signers {
+		secret, nonce, err := GenerateNonce(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets[signer.Index], nonces[signer.Index] = secret, nonce
+	}
+	session, err := NewThresholdSession(signers[0], secrets[signers[0].Index], nonces, []byte("PTLC/ed25519/frost"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := session.PartialSign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.PartialSign(); err != ErrNonceReused {
+		t.Fatalf("second PartialSign returned %v, want %v", err, ErrNonceReused)
+	}
+
+	one := indexScalar(1)
+	if session.PartialVerify(signers[0].Index, partial.Add(one)) {
+		t.Fatal("PartialVerify accepted a tampered share")
+	}
+	if session.PartialVerify(signers[1].Index, partial) {
+		t.Fatal("PartialVerify accepted the share of another participant")
+	}
+	if session.PartialVerify(keyShares[2].Index, partial) {
+		t.Fatal("PartialVerify accepted a participant outside the session")
+	}
+}
+
+func TestDKGRejects(t *testing.T) {
+	one := indexScalar(1)
+	tests := []struct {
+		name   string
+		tamper func(commitments []*DKGCommitment, shares map[uint16]Scalar)
+		err    error
+	}{
+		{
+			name: "share",
+			tamper: func(_ []*DKGCommitment, shares map[uint16]Scalar) {
+				shares[2] = shares[2].Add(one)
+			},
+			err: ErrInvalidShare,
+		},
+		{
+			name: "commitment",
+			tamper: func(commitments []*DKGCommitment, _ map[uint16]Scalar) {
+				commitments[1].Commitments[1] = commitments[1].Commitments[1].Add(indexScalar(1).ToCurvePoint())
+			},
+			err: ErrInvalidShare,
+		},
+		{
+			name: "proof of knowledge",
+			tamper: func(commitments []*DKGCommitment, _ map[uint16]Scalar) {
+				commitments[2].Mu = commitments[2].Mu.Add(one)
+			},
+			err: ErrInvalidDKGProof,
+		},
+		{
+			name: "constant term",
+			tamper: func(commitments []*DKGCommitment, _ map[uint16]Scalar) {
+				commitments[2].Commitments[0] = commitments[2].Commitments[0].Add(indexScalar(1).ToCurvePoint())
+			},
+			err: ErrInvalidDKGProof,
+		},
+		{
+			name: "duplicate dealer",
+			tamper: func(commitments []*DKGCommitment, _ map[uint16]Scalar) {
+				commitments[1] = commitments[0]
+			},
+			err: ErrInvalidParticipant,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dkgs, commitments, shares := newTestDKG(t, 2, 3)
+			test.tamper(commitments, shares[1])
+			if _, err := dkgs[0].Finish(commitments, shares[1]); err != test.err {
+				t.Fatalf("Finish returned %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestDKGFinishOnce(t *testing.T) {
+	dkgs, commitments, shares := newTestDKG(t, 2, 3)
+	if _, err := dkgs[0].Finish(commitments, shares[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dkgs[0].Finish(commitments, shares[1]); err != ErrDKGFinished {
+		t.Fatalf("second Finish returned %v, want %v", err, ErrDKGFinished)
+	}
+	if _, err := dkgs[0].Share(2); err != ErrDKGFinished {
+		t.Fatalf("Share after Finish returned %v, want %v", err, ErrDKGFinished)
+	}
+}
+
+func TestDKGCommitmentEncoding(t *testing.T) {
+	_, commitments, _ := newTestDKG(t, 3, 4)
+	parsed, err := ParseDKGCommitment(commitments[0].Bytes(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyDKGCommitment(testDKGContext, 3, parsed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseDKGCommitment(commitments[0].Bytes(), 2); err == nil {
+		t.Fatal("ParseDKGCommitment accepted a commitment of another threshold")
+	}
+}
@@ -0,0 +1,132 @@
+package group
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
+)
+
+// PointSize is the size, in bytes, of an encoded point.
+const PointSize = 32
+
+var (
+	// ErrInvalidPointLength is returned when a point encoding has the wrong
+	// length.
+	ErrInvalidPointLength = errors.New("group: invalid point length")
+	// ErrInvalidPoint is returned when an encoding does not decode to a point
+	// on the curve.
+	ErrInvalidPoint = errors.New("group: invalid point encoding")
+)
+
+// Point is a point on the Ed25519 curve. The zero value is not a valid
+// point; use Identity, Generator or PointFromBytes.
+type Point struct {
+	p edwards25519.ExtendedGroupElement
+}
+
+// Identity returns the neutral element of the group.
+func Identity() Point {
+	var P Point
+	P.p.Zero()
+	return P
+}
+
+// Generator returns the Ed25519 base point G.
+func Generator() Point {
+	var one Scalar
+	one.s[0] = 1
+	return ScalarBaseMult(one)
+}
+
+// PointFromBytes decodes a 32-byte point encoding. It accepts every encoding
+// of a point on the curve, including non-canonical ones and points outside
+// the prime-order subgroup; use ed25519.ParsePoint for input from untrusted
+// sources.
+func PointFromBytes(b []byte) (Point, error) {
+	if len(b) != PointSize {
+		return Point{}, ErrInvalidPointLength
+	}
+
+	var encoded [PointSize]byte
+	copy(encoded[:], b)
+	var P Point
+	if !P.p.FromBytes(&encoded) {
+		return Point{}, ErrInvalidPoint
+	}
+	return P, nil
+}
+
+// ScalarBaseMult returns s*G.
+func ScalarBaseMult(s Scalar) Point {
+	var P Point
+	edwards25519.GeScalarMultBase(&P.p, &s.s)
+	return P
+}
+
+// Bytes returns the canonical 32-byte encoding of P.
+func (P Point) Bytes() [PointSize]byte {
+	var encoded [PointSize]byte
+	P.p.ToBytes(&encoded)
+	return encoded
+}
+
+// Add returns P + Q.
+func (P Point) Add(Q Point) Point {
+	var R Point
+	edwards25519.GeAdd(&R.p, &P.p, &Q.p)
+	return R
+}
+
+// Sub returns P - Q.
+func (P Point) Sub(Q Point) Point {
+	return P.Add(Q.Negate())
+}
+
+// Negate returns -P.
+func (P Point) Negate() Point {
+	edwards25519.FeNeg(&P.p.X, &P.p.X)
+	edwards25519.FeNeg(&P.p.T, &P.p.T)
+	return P
+}
+
+// ScalarMult returns s*P.
+func (P Point) ScalarMult(s Scalar) Point {
+	var R Point
+	edwards25519.GeScalarMult(&R.p, &s.s, &P.p)
+	return R
+}
+
+// MulByCofactor returns 8*P.
+func (P Point) MulByCofactor() Point {
+	var r edwards25519.CompletedGroupElement
+	for i := 0; i < 3; i++ {
+		P.p.Double(&r)
+		r.ToExtended(&P.p)
+	}
+	return P
+}
+
+// Equal reports whether P and Q are the same point, in constant time.
+func (P Point) Equal(Q Point) bool {
+	a, b := P.Bytes(), Q.Bytes()
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// IsIdentity reports whether P is the neutral element.
+func (P Point) IsIdentity() bool {
+	return P.Equal(Identity())
+}
+
+// IsSmallOrder reports whether the order of P divides the cofactor 8.
+func (P Point) IsSmallOrder() bool {
+	return P.MulByCofactor().IsIdentity()
+}
+
+// IsTorsionFree reports whether P is in the prime-order subgroup, that is
+// l*P is the neutral element.
+func (P Point) IsTorsionFree() bool {
+	var lP Point
+	edwards25519.GeScalarMult(&lP.p, &order, &P.p)
+	return lP.IsIdentity()
+}
@@ -0,0 +1,123 @@
+package group
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	// generatorHex is the canonical encoding of the base point G.
+	generatorHex = "5866666666666666666666666666666666666666666666666666666666666666"
+	// smallOrderHex is a point of order 8.
+	smallOrderHex = "c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a"
+	// nonCanonicalIdentityHex encodes the identity with y = p + 1.
+	nonCanonicalIdentityHex = "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"
+)
+
+func decodePoint(t *testing.T, s string) Point {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	P, err := PointFromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return P
+}
+
+func TestPointFromBytes(t *testing.T) {
+	G := Generator()
+	if encoded := G.Bytes(); hex.EncodeToString(encoded[:]) != generatorHex {
+		t.Fatal("Generator is not G")
+	}
+	P := ScalarBaseMult(newTestScalar(t))
+	encoded := P.Bytes()
+	decoded, err := PointFromBytes(encoded[:])
+	if err != nil || !decoded.Equal(P) {
+		t.Fatal("encoding does not round-trip")
+	}
+
+	// Non-canonical encodings decode, to the canonical point.
+	if !decodePoint(t, nonCanonicalIdentityHex).Equal(Identity()) {
+		t.Fatal("non-canonical identity does not decode to the identity")
+	}
+
+	if _, err := PointFromBytes(encoded[:PointSize-1]); err != ErrInvalidPointLength {
+		t.Fatalf("short encoding returned %v, want %v", err, ErrInvalidPointLength)
+	}
+	if _, err := PointFromBytes(append(encoded[:], 0)); err != ErrInvalidPointLength {
+		t.Fatalf("long encoding returned %v, want %v", err, ErrInvalidPointLength)
+	}
+	notOnCurve := make([]byte, PointSize)
+	notOnCurve[0] = 2
+	if _, err := PointFromBytes(notOnCurve); err != ErrInvalidPoint {
+		t.Fatalf("point off the curve returned %v, want %v", err, ErrInvalidPoint)
+	}
+}
+
+func TestPointArithmetic(t *testing.T) {
+	s, u := newTestScalar(t), newTestScalar(t)
+	S, U := ScalarBaseMult(s), ScalarBaseMult(u)
+
+	if !S.Add(U).Equal(ScalarBaseMult(s.Add(u))) {
+		t.Fatal("s*G + u*G != (s + u)*G")
+	}
+	if !S.Sub(U).Equal(ScalarBaseMult(s.Sub(u))) {
+		t.Fatal("s*G - u*G != (s - u)*G")
+	}
+	if !S.Negate().Equal(ScalarBaseMult(s.Negate())) || !S.Add(S.Negate()).IsIdentity() {
+		t.Fatal("-(s*G) != (-s)*G")
+	}
+	if !S.ScalarMult(u).Equal(ScalarBaseMult(s.Mul(u))) || !Generator().ScalarMult(s).Equal(S) {
+		t.Fatal("u*(s*G) != (s*u)*G")
+	}
+	if !S.Add(Identity()).Equal(S) || !S.ScalarMult(Scalar{}).IsIdentity() {
+		t.Fatal("identity laws do not hold")
+	}
+
+	var eight Scalar
+	eight.s[0] = 8
+	if !S.MulByCofactor().Equal(S.ScalarMult(eight)) {
+		t.Fatal("MulByCofactor is not 8*P")
+	}
+}
+
+func TestPointOrder(t *testing.T) {
+	P := ScalarBaseMult(newTestScalar(t))
+	T := decodePoint(t, smallOrderHex)
+
+	tests := []struct {
+		name                    string
+		P                       Point
+		smallOrder, torsionFree bool
+	}{
+		{"identity", Identity(), true, true},
+		{"prime order", P, false, true},
+		{"order 8", T, true, false},
+		{"mixed order", P.Add(T), false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.P.IsSmallOrder() != test.smallOrder {
+				t.Errorf("IsSmallOrder = %v, want %v", !test.smallOrder, test.smallOrder)
+			}
+			if test.P.IsTorsionFree() != test.torsionFree {
+				t.Errorf("IsTorsionFree = %v, want %v", !test.torsionFree, test.torsionFree)
+			}
+		})
+	}
+}
+
+func TestPointIsValue(t *testing.T) {
+	P := ScalarBaseMult(newTestScalar(t))
+	copied := P
+	_ = P.Negate()
+	_ = P.MulByCofactor()
+	encoded := P.Bytes()
+	encoded[0] ^= 1
+	if !P.Equal(copied) {
+		t.Fatal("a point changed through its encoding or an operation")
+	}
+}
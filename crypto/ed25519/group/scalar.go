@@ -0,0 +1,177 @@
+// Package group implements fixed-size value types for the scalars and points
+// of the Ed25519 group.
+//
+// Scalar and Point are backed by arrays, so they are copied by assignment and
+// can never have the wrong length. They are created only by the explicit
+// decoding functions, which reject malformed input, and all arithmetic runs
+// in constant time. The slice-based Scalar and CurvePoint types of the
+// ed25519 package are thin wrappers around these types.
+package group
+
+import (
+	cryptorand "crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
+)
+
+const (
+	// ScalarSize is the size, in bytes, of an encoded scalar.
+	ScalarSize = 32
+	// UniformBytesSize is the number of bytes reduced by
+	// ScalarFromUniformBytes.
+	UniformBytesSize = 64
+)
+
+var (
+	// ErrInvalidScalarLength is returned when a scalar encoding has the wrong
+	// length.
+	ErrInvalidScalarLength = errors.New("group: invalid scalar length")
+	// ErrNonCanonicalScalar is returned when a scalar encoding is not reduced
+	// modulo l.
+	ErrNonCanonicalScalar = errors.New("group: scalar is not reduced modulo l")
+)
+
+// order is l = 2^252 + 27742317777372353535851937790883648493 in
+// little-endian form.
+var order = [ScalarSize]byte{
+	0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+// Scalar is an integer modulo the group order l. The zero value is the
+// scalar 0.
+type Scalar struct {
+	s [ScalarSize]byte
+}
+
+// ScalarFromCanonicalBytes decodes a 32-byte little-endian scalar, rejecting
+// encodings that are not reduced modulo l.
+func ScalarFromCanonicalBytes(b []byte) (Scalar, error) {
+	if len(b) != ScalarSize {
+		return Scalar{}, ErrInvalidScalarLength
+	}
+
+	var s Scalar
+	copy(s.s[:], b)
+	if !isReduced(&s.s) {
+		return Scalar{}, ErrNonCanonicalScalar
+	}
+	return s, nil
+}
+
+// ScalarFromBytesModOrder reduces a 32-byte little-endian integer modulo l.
+// It accepts the clamped keys of ed25519.GenerateKey2.
+func ScalarFromBytesModOrder(b []byte) (Scalar, error) {
+	if len(b) != ScalarSize {
+		return Scalar{}, ErrInvalidScalarLength
+	}
+
+	var wide [UniformBytesSize]byte
+	copy(wide[:], b)
+	var s Scalar
+	edwards25519.ScReduce(&s.s, &wide)
+	return s, nil
+}
+
+// ScalarFromUniformBytes reduces a 64-byte little-endian integer modulo l. If
+// b is uniformly random, so is the scalar.
+func ScalarFromUniformBytes(b []byte) (Scalar, error) {
+	if len(b) != UniformBytesSize {
+		return Scalar{}, errors.New("group: uniform bytes must be 64 bytes")
+	}
+
+	var wide [UniformBytesSize]byte
+	copy(wide[:], b)
+	var s Scalar
+	edwards25519.ScReduce(&s.s, &wide)
+	return s, nil
+}
+
+// RandomScalar returns a uniformly random scalar. If rand is nil,
+// crypto/rand.Reader will be used.
+func RandomScalar(rand io.Reader) (Scalar, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	var wide [UniformBytesSize]byte
+	if _, err := io.ReadFull(rand, wide[:]); err != nil {
+		return Scalar{}, err
+	}
+	return ScalarFromUniformBytes(wide[:])
+}
+
+// Bytes returns the canonical 32-byte little-endian encoding of s.
+func (s Scalar) Bytes() [ScalarSize]byte {
+	return s.s
+}
+
+// Add returns s + t mod l.
+func (s Scalar) Add(t Scalar) Scalar {
+	var r Scalar
+	edwards25519.ScAdd(&r.s, &s.s, &t.s)
+	return r
+}
+
+// Sub returns s - t mod l.
+func (s Scalar) Sub(t Scalar) Scalar {
+	var r Scalar
+	edwards25519.ScSub(&r.s, &s.s, &t.s)
+	return r
+}
+
+// Mul returns s * t mod l.
+func (s Scalar) Mul(t Scalar) Scalar {
+	var r Scalar
+	edwards25519.ScMul(&r.s, &s.s, &t.s)
+	return r
+}
+
+// Negate returns -s mod l.
+func (s Scalar) Negate() Scalar {
+	return Scalar{}.Sub(s)
+}
+
+// Invert returns s^-1 mod l, computed as s^(l-2) with a fixed sequence of
+// multiplications. The inverse of zero is zero.
+func (s Scalar) Invert() Scalar {
+	exponent := order
+	exponent[0] -= 2
+
+	var r Scalar
+	r.s[0] = 1
+	for i := 255; i >= 0; i-- {
+		r = r.Mul(r)
+		if exponent[i/8]>>(i%8)&1 == 1 {
+			r = r.Mul(s)
+		}
+	}
+	return r
+}
+
+// Equal reports whether s and t are equal, in constant time.
+func (s Scalar) Equal(t Scalar) bool {
+	return subtle.ConstantTimeCompare(s.s[:], t.s[:]) == 1
+}
+
+// IsZero reports whether s is zero, in constant time.
+func (s Scalar) IsZero() bool {
+	return s.Equal(Scalar{})
+}
+
+// isReduced reports whether the little-endian integer s is smaller than l.
+func isReduced(s *[ScalarSize]byte) bool {
+	for i := ScalarSize - 1; i >= 0; i-- {
+		if s[i] < order[i] {
+			return true
+		} else if s[i] > order[i] {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package group
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+// bigOrder is l as a big.Int.
+var bigOrder, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+// toBig returns the little-endian integer b.
+func toBig(b []byte) *big.Int {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(reversed)
+}
+
+// fromBig returns the scalar x mod l.
+func fromBig(t *testing.T, x *big.Int) Scalar {
+	t.Helper()
+	reduced := new(big.Int).Mod(x, bigOrder).Bytes()
+	encoded := make([]byte, ScalarSize)
+	for i := range reduced {
+		encoded[i] = reduced[len(reduced)-1-i]
+	}
+	s, err := ScalarFromCanonicalBytes(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestScalar(t *testing.T) Scalar {
+	t.Helper()
+	s, err := RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestScalarDecoding(t *testing.T) {
+	if toBig(order[:]).Cmp(bigOrder) != 0 {
+		t.Fatal("order is not l")
+	}
+	lMinusOne := order
+	lMinusOne[0]--
+	lPlusOne := order
+	lPlusOne[0]++
+
+	tests := []struct {
+		name      string
+		encoded   []byte
+		canonical error
+		modOrder  *big.Int
+	}{
+		{"zero", make([]byte, ScalarSize), nil, big.NewInt(0)},
+		{"l - 1", lMinusOne[:], nil, new(big.Int).Sub(bigOrder, big.NewInt(1))},
+		{"l", order[:], ErrNonCanonicalScalar, big.NewInt(0)},
+		{"l + 1", lPlusOne[:], ErrNonCanonicalScalar, big.NewInt(1)},
+		{"2^256 - 1", bytes.Repeat([]byte{0xff}, ScalarSize), ErrNonCanonicalScalar, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))},
+		{"short", make([]byte, ScalarSize-1), ErrInvalidScalarLength, nil},
+		{"long", make([]byte, ScalarSize+1), ErrInvalidScalarLength, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := ScalarFromCanonicalBytes(test.encoded)
+			if err != test.canonical {
+				t.Fatalf("ScalarFromCanonicalBytes returned %v, want %v", err, test.canonical)
+			}
+			if err == nil {
+				if encoded := s.Bytes(); !bytes.Equal(encoded[:], test.encoded) {
+					t.Fatal("encoding does not round-trip")
+				}
+			}
+
+			s, err = ScalarFromBytesModOrder(test.encoded)
+			if test.modOrder == nil {
+				if err != ErrInvalidScalarLength {
+					t.Fatalf("ScalarFromBytesModOrder returned %v, want %v", err, ErrInvalidScalarLength)
+				}
+				return
+			}
+			if err != nil || !s.Equal(fromBig(t, test.modOrder)) {
+				t.Fatalf("ScalarFromBytesModOrder returned %x, %v", s.Bytes(), err)
+			}
+		})
+	}
+
+	wide := bytes.Repeat([]byte{0xa5}, UniformBytesSize)
+	s, err := ScalarFromUniformBytes(wide)
+	if err != nil || !s.Equal(fromBig(t, toBig(wide))) {
+		t.Fatal("ScalarFromUniformBytes does not reduce modulo l")
+	}
+	if _, err := ScalarFromUniformBytes(wide[:ScalarSize]); err == nil {
+		t.Fatal("ScalarFromUniformBytes accepted 32 bytes")
+	}
+}
+
+func TestScalarArithmetic(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		s, u := newTestScalar(t), newTestScalar(t)
+		sb, ub := s.Bytes(), u.Bytes()
+		S, U := toBig(sb[:]), toBig(ub[:])
+
+		if !s.Add(u).Equal(fromBig(t, new(big.Int).Add(S, U))) {
+			t.Fatal("Add does not match math/big")
+		}
+		if !s.Sub(u).Equal(fromBig(t, new(big.Int).Sub(S, U))) {
+			t.Fatal("Sub does not match math/big")
+		}
+		if !s.Mul(u).Equal(fromBig(t, new(big.Int).Mul(S, U))) {
+			t.Fatal("Mul does not match math/big")
+		}
+		if !s.Negate().Equal(fromBig(t, new(big.Int).Neg(S))) {
+			t.Fatal("Negate does not match math/big")
+		}
+		if !s.Invert().Equal(fromBig(t, new(big.Int).ModInverse(S, bigOrder))) {
+			t.Fatal("Invert does not match math/big")
+		}
+	}
+
+	var zero Scalar
+	if !zero.IsZero() || !zero.Invert().IsZero() || !zero.Negate().IsZero() {
+		t.Fatal("the zero value is not the scalar 0")
+	}
+	if newTestScalar(t).IsZero() {
+		t.Fatal("IsZero accepted a random scalar")
+	}
+}
+
+func TestScalarIsValue(t *testing.T) {
+	s := newTestScalar(t)
+	copied := s
+	encoded := s.Bytes()
+	encoded[0] ^= 1
+	_ = s.Add(newTestScalar(t))
+	if !s.Equal(copied) {
+		t.Fatal("a scalar changed through its encoding or an operation")
+	}
+}
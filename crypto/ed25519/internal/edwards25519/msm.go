@@ -0,0 +1,144 @@
+package edwards25519
+
+// pippengerThreshold is the number of points from which Pippenger's bucket
+// method is faster than Straus' interleaved windows.
+const pippengerThreshold = 190
+
+// GeMultiScalarMultVartime sets h = a[0]*A[0] + ... + a[n-1]*A[n-1].
+//
+// Preconditions:
+//
+//	len(a) == len(A)
+//	a[i][31] <= 127
+//
+// It runs in variable time and must only be used with public inputs, such as
+// the values checked when verifying signatures.
+func GeMultiScalarMultVartime(h *ExtendedGroupElement, a [][32]byte, A []ExtendedGroupElement) {
+	if len(a) < pippengerThreshold {
+		straus(h, a, A)
+	} else {
+		pippenger(h, a, A)
+	}
+}
+
+// straus computes the sum with one shared chain of doublings, adding the odd
+// multiples A, 3A, ..., 15A of every point selected by the sliding window
+// form of its scalar, as GeDoubleScalarMultVartime does for two points.
+func straus(h *ExtendedGroupElement, a [][32]byte, A []ExtendedGroupElement) {
+	slides := make([][256]int8, len(a))
+	multiples := make([][8]CachedGroupElement, len(A))
+
+	var t CompletedGroupElement
+	var u, A2 ExtendedGroupElement
+	for j := range A {
+		slide(&slides[j], &a[j])
+
+		A[j].ToCached(&multiples[j][0])
+		A[j].Double(&t)
+		t.ToExtended(&A2)
+		for i := 0; i < 7; i++ {
+			geAdd(&t, &A2, &multiples[j][i])
+			t.ToExtended(&u)
+			u.ToCached(&multiples[j][i+1])
+		}
+	}
+
+	var r ProjectiveGroupElement
+	r.Zero()
+	for i := 255; i >= 0; i-- {
+		r.Double(&t)
+		t.ToExtended(&u)
+
+		for j := range slides {
+			if slides[j][i] > 0 {
+				geAdd(&t, &u, &multiples[j][slides[j][i]/2])
+				t.ToExtended(&u)
+			} else if slides[j][i] < 0 {
+				geSub(&t, &u, &multiples[j][(-slides[j][i])/2])
+				t.ToExtended(&u)
+			}
+		}
+
+		u.ToProjective(&r)
+	}
+
+	FeCopy(&h.X, &u.X)
+	FeCopy(&h.Y, &u.Y)
+	FeCopy(&h.Z, &u.Z)
+	FeCopy(&h.T, &u.T)
+}
+
+// pippenger computes the sum with the bucket method: for every window of c
+// bits, each point is added to the bucket of its digit, and the buckets are
+// combined with a running sum so that bucket k is counted k times.
+func pippenger(h *ExtendedGroupElement, a [][32]byte, A []ExtendedGroupElement) {
+	c := 6
+	if len(a) >= 800 {
+		c = 8
+	} else if len(a) >= 500 {
+		c = 7
+	}
+
+	cached := make([]CachedGroupElement, len(A))
+	for j := range A {
+		A[j].ToCached(&cached[j])
+	}
+
+	buckets := make([]ExtendedGroupElement, (1<<c)-1)
+	var t CompletedGroupElement
+	var sumCached CachedGroupElement
+	var acc, sum, total ExtendedGroupElement
+	acc.Zero()
+
+	windows := (256 + c - 1) / c
+	for w := windows - 1; w >= 0; w-- {
+		for i := 0; i < c; i++ {
+			acc.Double(&t)
+			t.ToExtended(&acc)
+		}
+
+		for k := range buckets {
+			buckets[k].Zero()
+		}
+		for j := range a {
+			digit := window(&a[j], w*c, c)
+			if digit == 0 {
+				continue
+			}
+			geAdd(&t, &buckets[digit-1], &cached[j])
+			t.ToExtended(&buckets[digit-1])
+		}
+
+		sum.Zero()
+		total.Zero()
+		for k := len(buckets) - 1; k >= 0; k-- {
+			buckets[k].ToCached(&sumCached)
+			geAdd(&t, &sum, &sumCached)
+			t.ToExtended(&sum)
+
+			sum.ToCached(&sumCached)
+			geAdd(&t, &total, &sumCached)
+			t.ToExtended(&total)
+		}
+
+		total.ToCached(&sumCached)
+		geAdd(&t, &acc, &sumCached)
+		t.ToExtended(&acc)
+	}
+
+	*h = acc
+}
+
+// window returns the c bits of the little-endian scalar a starting at bit
+// offset.
+func window(a *[32]byte, offset, c int) int {
+	digit := 0
+	for i := c - 1; i >= 0; i-- {
+		bit := offset + i
+		digit <<= 1
+		if bit < 256 {
+			digit |= int(a[bit>>3]>>uint(bit&7)) & 1
+		}
+	}
+	return digit
+}
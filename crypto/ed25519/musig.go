@@ -0,0 +1,67 @@
+package ed25519
+
+import (
+	"crypto/sha512"
+	"errors"
+)
+
+var (
+	keyListTag     = []byte("PTLC/ed25519/musig/keylist")
+	coefficientTag = []byte("PTLC/ed25519/musig/coefficient")
+)
+
+// AggregatePublicKeys combines publicKeys into a single MuSig public key
+// X = a_1*X_1 + ... + a_n*X_n, where every coefficient a_i = H(L || X_i) is
+// bound to the hash L of the complete key list. This prevents a party from
+// choosing its key as a function of the other keys (rogue-key attack).
+//
+// The coefficients are returned in the order of publicKeys. A signer
+// contributes c*a_i*x_i to a signature, so all parties must aggregate the
+// keys in the same order. Every key must pass ParsePublicKey and appear only
+// once.
+func AggregatePublicKeys(publicKeys ...PublicKey) (PublicKey, []Scalar, error) {
+	if len(publicKeys) == 0 {
+		return nil, nil, errors.New("ed25519: no public keys to aggregate")
+	}
+	seen := make(map[string]bool, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		if _, err := ParsePublicKey(publicKey); err != nil {
+			return nil, nil, err
+		}
+		if seen[string(publicKey)] {
+			return nil, nil, errors.New("ed25519: duplicate public key to aggregate")
+		}
+		seen[string(publicKey)] = true
+	}
+
+	keyListHash := hashKeyList(publicKeys)
+
+	var aggregated CurvePoint
+	coefficients := make([]Scalar, len(publicKeys))
+	for i, publicKey := range publicKeys {
+		coefficients[i] = keyCoefficient(keyListHash, publicKey)
+		weighted := CurvePoint(publicKey).ScalarMult(coefficients[i])
+		if aggregated == nil {
+			aggregated = weighted
+		} else {
+			aggregated = aggregated.Add(weighted)
+		}
+	}
+
+	return PublicKey(aggregated), coefficients, nil
+}
+
+// hashKeyList returns L = SHA512(tag || X_1 || ... || X_n).
+func hashKeyList(publicKeys []PublicKey) []byte {
+	h := sha512.New()
+	h.Write(keyListTag)
+	for _, publicKey := range publicKeys {
+		h.Write(publicKey)
+	}
+	return h.Sum(nil)
+}
+
+// keyCoefficient returns a_i = SHA512(tag || L || X_i) mod l.
+func keyCoefficient(keyListHash []byte, publicKey PublicKey) Scalar {
+	return hashToScalar(coefficientTag, keyListHash, publicKey)
+}
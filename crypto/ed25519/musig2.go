@@ -0,0 +1,225 @@
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+)
+
+// PublicNonceSize is the size, in bytes, of a public nonce pair.
+const PublicNonceSize = 2 * CurvePointSize
+
+var bindingTag = []byte("PTLC/ed25519/musig2/binding")
+
+var (
+	// ErrNonceReused is returned when a secret nonce is used for a second
+	// partial signature.
+	ErrNonceReused = errors.New("ed25519: secret nonce has already been used")
+)
+
+// PublicNonce is the byte representation R1 || R2 of the two public nonce
+// points a signer contributes to a MuSig2 signing session.
+type PublicNonce []byte
+
+// SecretNonce holds the two secret nonce scalars k1, k2 of a signer. It can
+// be used for exactly one partial signature, after which it is wiped.
+type SecretNonce struct {
+	k1, k2 Scalar
+	used   bool
+}
+
+// GenerateNonce generates a fresh secret nonce pair (k1, k2) and the public
+// nonce (k1*G || k2*G) using entropy from rand. If rand is nil,
+// crypto/rand.Reader will be used. GenerateSyntheticNonce additionally binds
+// the nonces to the signing key and context.
+func GenerateNonce(rand io.Reader) (*SecretNonce, PublicNonce, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	k1, err := RandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	k2, err := RandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newSecretNonce(k1, k2), publicNonce(k1, k2), nil
+}
+
+// newSecretNonce returns the secret nonce pair (k1, k2).
+func newSecretNonce(k1, k2 Scalar) *SecretNonce {
+	return &SecretNonce{k1: k1, k2: k2}
+}
+
+// publicNonce returns the public nonce k1*G || k2*G.
+func publicNonce(k1, k2 Scalar) PublicNonce {
+	nonce := make([]byte, PublicNonceSize)
+	copy(nonce[:CurvePointSize], k1.ToCurvePoint())
+	copy(nonce[CurvePointSize:], k2.ToCurvePoint())
+	return PublicNonce(nonce)
+}
+
+// ParsePublicNonce parses a public nonce pair, checking both points as
+// ParsePoint does.
+func ParsePublicNonce(b []byte) (PublicNonce, error) {
+	if len(b) != PublicNonceSize {
+		return nil, ErrInvalidPoint
+	}
+	if _, err := ParsePoint(b[:CurvePointSize]); err != nil {
+		return nil, err
+	}
+	if _, err := ParsePoint(b[CurvePointSize:]); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, PublicNonceSize)
+	copy(nonce, b)
+	return PublicNonce(nonce), nil
+}
+
+// R1 returns the first public nonce point.
+func (pn PublicNonce) R1() CurvePoint {
+	return CurvePoint(pn[:CurvePointSize])
+}
+
+// R2 returns the second public nonce point.
+func (pn PublicNonce) R2() CurvePoint {
+	return CurvePoint(pn[CurvePointSize:PublicNonceSize])
+}
+
+// AggregateNonces sums the public nonces of all signers component-wise.
+func AggregateNonces(nonces ...PublicNonce) (PublicNonce, error) {
+	if len(nonces) == 0 {
+		return nil, errors.New("ed25519: no public nonces to aggregate")
+	}
+	for _, nonce := range nonces {
+		if _, err := ParsePublicNonce(nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	R1 := nonces[0].R1()
+	R2 := nonces[0].R2()
+	for _, nonce := range nonces[1:] {
+		R1 = R1.Add(nonce.R1())
+		R2 = R2.Add(nonce.R2())
+	}
+
+	aggregated := make([]byte, PublicNonceSize)
+	copy(aggregated[:CurvePointSize], R1)
+	copy(aggregated[CurvePointSize:], R2)
+	return PublicNonce(aggregated), nil
+}
+
+// Session is a single MuSig2 signing session producing an adaptor signature
+// of one message under an aggregated public key and adaptor point T.
+//
+// The final nonce is R = R1 + b*R2, where R1 and R2 are the aggregated public
+// nonces and the binding factor b = H(X || R1 || R2 || T || m) ties the nonce
+// to this particular session. This defeats the concurrent-session attacks
+// that apply to single-nonce schemes when many sessions run in parallel.
+type Session struct {
+	secret    *SecretNonce
+	binding   Scalar
+	challenge Scalar
+	nonce     CurvePoint
+}
+
+// NewSession starts a signing session for message under the aggregated
+// publicKey and the adaptor point T. secretNonce is the local signer's nonce
+// and nonces holds the public nonces of all signers, including its own. If
+// secretNonce is nil, the session can only verify and aggregate the partial
+// signatures of the other signers.
+func NewSession(secretNonce *SecretNonce, nonces []PublicNonce, publicKey PublicKey, message []byte, T CurvePoint) (*Session, error) {
+	if secretNonce != nil && secretNonce.used {
+		return nil, ErrNonceReused
+	}
+	if _, err := ParsePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	if _, err := ParsePoint(T); err != nil {
+		return nil, err
+	}
+
+	aggregated, err := AggregateNonces(nonces...)
+	if err != nil {
+		return nil, err
+	}
+
+	binding := bindingFactor(publicKey, aggregated, T, message)
+	R := aggregated.R1().Add(aggregated.R2().ScalarMult(binding))
+
+	return &Session{
+		secret:    secretNonce,
+		binding:   binding,
+		challenge: Challenge(publicKey, PublicKey(R.Add(T)), message),
+		nonce:     R,
+	}, nil
+}
+
+// Nonce returns the final nonce point R = R1 + b*R2 of the session.
+func (s *Session) Nonce() CurvePoint {
+	return s.nonce
+}
+
+// Challenge returns the session challenge c = SHA512((R + T) || X || m).
+func (s *Session) Challenge() Scalar {
+	return s.challenge
+}
+
+// PartialSign returns the partial signature k1 + b*k2 + c*a*x of the local
+// signer with private scalar key and key aggregation coefficient. The secret
+// nonce is wiped afterwards, so a second call returns ErrNonceReused, as does
+// a call on a session without a secret nonce.
+func (s *Session) PartialSign(key, coefficient Scalar) (Scalar, error) {
+	if s.secret == nil || s.secret.used {
+		return nil, ErrNonceReused
+	}
+
+	partial := s.binding.Multiply(s.secret.k2).Add(s.secret.k1).Add(s.challenge.Multiply(coefficient).Multiply(key))
+
+	s.secret.used = true
+	for i := range s.secret.k1 {
+		s.secret.k1[i] = 0
+		s.secret.k2[i] = 0
+	}
+
+	return partial, nil
+}
+
+// PartialVerify reports whether partial is a valid partial signature of the
+// signer with publicKey, key aggregation coefficient and public nonce, that
+// is partial*G == R1 + b*R2 + c*a*X.
+func (s *Session) PartialVerify(partial Scalar, publicKey PublicKey, coefficient Scalar, nonce PublicNonce) bool {
+	if _, err := ParseScalar(partial); err != nil {
+		return false
+	}
+	if _, err := ParsePublicKey(publicKey); err != nil {
+		return false
+	}
+	if _, err := ParsePublicNonce(nonce); err != nil {
+		return false
+	}
+
+	caX := CurvePoint(publicKey).ScalarMult(s.challenge.Multiply(coefficient))
+	expected := nonce.R1().Add(nonce.R2().ScalarMult(s.binding)).Add(caX)
+	return partial.ToCurvePoint().Equal(expected)
+}
+
+// Aggregate sums the partial signatures of all signers into the adaptor
+// signature (R, s') of the session.
+func (s *Session) Aggregate(partials ...Scalar) *AdaptorSignature {
+	S := Scalar(make([]byte, ScalarSize))
+	for _, partial := range partials {
+		S = S.Add(partial)
+	}
+	return &AdaptorSignature{R: s.nonce, S: S}
+}
+
+// bindingFactor returns b = SHA512(tag || X || R1 || R2 || T || m) mod l.
+func bindingFactor(publicKey PublicKey, nonce PublicNonce, T CurvePoint, message []byte) Scalar {
+	return hashToScalar(bindingTag, publicKey, nonce, T, message)
+}
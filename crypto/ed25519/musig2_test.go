@@ -0,0 +1,155 @@
+package ed25519
+
+import "testing"
+
+// musig2Signer is one signer of a MuSig2 session.
+type musig2Signer struct {
+	key         Scalar
+	publicKey   PublicKey
+	coefficient Scalar
+	secret      *SecretNonce
+	nonce       PublicNonce
+	session     *Session
+}
+
+// newMusig2Sessions starts a session of n signers for message under the
+// adaptor point T and returns the signers and their aggregated public key.
+func newMusig2Sessions(t *testing.T, n int, message []byte, T CurvePoint) ([]*musig2Signer, PublicKey) {
+	t.Helper()
+	signers := make([]*musig2Signer, n)
+	publicKeys := make([]PublicKey, n)
+	nonces := make([]PublicNonce, n)
+	for i := range signers {
+		key := newTestScalar(t)
+		secret, nonce, err := GenerateNonce(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = &musig2Signer{key: key, publicKey: PublicKey(key.ToCurvePoint()), secret: secret, nonce: nonce}
+		publicKeys[i], nonces[i] = signers[i].publicKey, nonce
+	}
+
+	publicKey, coefficients, err := AggregatePublicKeys(publicKeys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, signer := range signers {
+		signer.coefficient = coefficients[i]
+		if signer.session, err = NewSession(signer.secret, nonces, publicKey, message, T); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signers, publicKey
+}
+
+func TestMusig2(t *testing.T) {
+	message := []byte("PTLC/ed25519/musig2")
+	adaptor := newTestScalar(t)
+	T := adaptor.ToCurvePoint()
+	signers, publicKey := newMusig2Sessions(t, 3, message, T)
+
+	partials := make([]Scalar, len(signers))
+	for i, signer := range signers {
+		partial, err := signer.session.PartialSign(signer.key, signer.coefficient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, verifier := range signers {
+			if !verifier.session.PartialVerify(partial, signer.publicKey, signer.coefficient, signer.nonce) {
+				t.Fatalf("partial signature %d rejected", i)
+			}
+		}
+		partials[i] = partial
+	}
+
+	as := signers[0].session.Aggregate(partials...)
+	if !as.R.Equal(signers[0].session.Nonce()) {
+		t.Fatal("aggregated nonce is not the session nonce")
+	}
+	if !PreVerify(publicKey, message, T, as) {
+		t.Fatal("PreVerify rejected the aggregated adaptor signature")
+	}
+	if !Verify(publicKey, message, Adapt(as, Adaptor(adaptor))) {
+		t.Fatal("Verify rejected the adapted aggregated signature")
+	}
+}
+
+func TestMusig2PartialVerifyRejects(t *testing.T) {
+	message := []byte("PTLC/ed25519/musig2")
+	signers, _ := newMusig2Sessions(t, 2, message, newTestScalar(t).ToCurvePoint())
+	signer, other := signers[0], signers[1]
+	partial, err := signer.session.PartialSign(signer.key, signer.coefficient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one := make(Scalar, ScalarSize)
+	one[0] = 1
+	tests := []struct {
+		name        string
+		partial     Scalar
+		publicKey   PublicKey
+		coefficient Scalar
+		nonce       PublicNonce
+	}{
+		{"tampered partial", partial.Add(one), signer.publicKey, signer.coefficient, signer.nonce},
+		{"unreduced partial", addOrder(partial), signer.publicKey, signer.coefficient, signer.nonce},
+		{"wrong key", partial, other.publicKey, signer.coefficient, signer.nonce},
+		{"wrong coefficient", partial, signer.publicKey, other.coefficient, signer.nonce},
+		{"wrong nonce", partial, signer.publicKey, signer.coefficient, other.nonce},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if other.session.PartialVerify(test.partial, test.publicKey, test.coefficient, test.nonce) {
+				t.Fatal("PartialVerify accepted an invalid partial signature")
+			}
+		})
+	}
+}
+
+func TestMusig2NonceReuse(t *testing.T) {
+	message := []byte("PTLC/ed25519/musig2")
+	T := newTestScalar(t).ToCurvePoint()
+	signers, publicKey := newMusig2Sessions(t, 2, message, T)
+	signer := signers[0]
+
+	if _, err := signer.session.PartialSign(signer.key, signer.coefficient); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.session.PartialSign(signer.key, signer.coefficient); err != ErrNonceReused {
+		t.Fatalf("second PartialSign returned %v, want %v", err, ErrNonceReused)
+	}
+
+	nonces := []PublicNonce{signers[0].nonce, signers[1].nonce}
+	if _, err := NewSession(signer.secret, nonces, publicKey, []byte("PTLC/ed25519/other"), T); err != ErrNonceReused {
+		t.Fatalf("NewSession with a used nonce returned %v, want %v", err, ErrNonceReused)
+	}
+}
+
+func TestMusig2VerifyOnlySession(t *testing.T) {
+	message := []byte("PTLC/ed25519/musig2")
+	T := newTestScalar(t).ToCurvePoint()
+	signers, publicKey := newMusig2Sessions(t, 2, message, T)
+
+	nonces := []PublicNonce{signers[0].nonce, signers[1].nonce}
+	session, err := NewSession(nil, nonces, publicKey, message, T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.PartialSign(signers[0].key, signers[0].coefficient); err != ErrNonceReused {
+		t.Fatalf("PartialSign without a secret nonce returned %v, want %v", err, ErrNonceReused)
+	}
+
+	partials := make([]Scalar, len(signers))
+	for i, signer := range signers {
+		if partials[i], err = signer.session.PartialSign(signer.key, signer.coefficient); err != nil {
+			t.Fatal(err)
+		}
+		if !session.PartialVerify(partials[i], signer.publicKey, signer.coefficient, signer.nonce) {
+			t.Fatalf("partial signature %d rejected", i)
+		}
+	}
+	if !PreVerify(publicKey, message, T, session.Aggregate(partials...)) {
+		t.Fatal("PreVerify rejected the adaptor signature aggregated without a secret nonce")
+	}
+}
@@ -0,0 +1,116 @@
+package ed25519
+
+import "testing"
+
+func newTestPublicKeys(t *testing.T, n int) []PublicKey {
+	t.Helper()
+	publicKeys := make([]PublicKey, n)
+	for i := range publicKeys {
+		publicKeys[i] = PublicKey(newTestScalar(t).ToCurvePoint())
+	}
+	return publicKeys
+}
+
+func TestAggregatePublicKeys(t *testing.T) {
+	publicKeys := newTestPublicKeys(t, 3)
+	aggregated, coefficients, err := AggregatePublicKeys(publicKeys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coefficients) != len(publicKeys) {
+		t.Fatalf("got %d coefficients, want %d", len(coefficients), len(publicKeys))
+	}
+
+	want := Identity()
+	for i, publicKey := range publicKeys {
+		want = want.Add(CurvePoint(publicKey).ScalarMult(coefficients[i]))
+	}
+	if !CurvePoint(aggregated).Equal(want) {
+		t.Error("aggregated key is not the sum of a_i*X_i")
+	}
+
+	// Aggregation is deterministic.
+	again, againCoefficients, err := AggregatePublicKeys(publicKeys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CurvePoint(again).Equal(CurvePoint(aggregated)) {
+		t.Error("aggregation is not deterministic")
+	}
+	for i := range coefficients {
+		if !againCoefficients[i].Equal(coefficients[i]) {
+			t.Errorf("coefficient %d is not deterministic", i)
+		}
+	}
+}
+
+// TestAggregatePublicKeysCoefficients checks that the coefficient of a key
+// depends on the whole key list.
+func TestAggregatePublicKeysCoefficients(t *testing.T) {
+	publicKeys := newTestPublicKeys(t, 3)
+	_, coefficients, err := AggregatePublicKeys(publicKeys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists := map[string][]PublicKey{
+		"other key":   {publicKeys[0], publicKeys[1], newTestPublicKeys(t, 1)[0]},
+		"reordered":   {publicKeys[0], publicKeys[2], publicKeys[1]},
+		"removed key": {publicKeys[0], publicKeys[1]},
+		"added key":   append(append([]PublicKey(nil), publicKeys...), newTestPublicKeys(t, 1)[0]),
+	}
+	for name, list := range lists {
+		_, other, err := AggregatePublicKeys(list...)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i := range list {
+			for j := range publicKeys {
+				if string(list[i]) == string(publicKeys[j]) && other[i].Equal(coefficients[j]) {
+					t.Errorf("%s: coefficient of key %d did not change", name, j)
+				}
+			}
+		}
+	}
+}
+
+// TestAggregatePublicKeysRogueKey checks that a party who picks its key as
+// B = X - A after seeing A does not control the aggregated key.
+func TestAggregatePublicKeysRogueKey(t *testing.T) {
+	A := PublicKey(newTestScalar(t).ToCurvePoint())
+	x := newTestScalar(t)
+	X := x.ToCurvePoint()
+	B := PublicKey(X.Sub(CurvePoint(A)))
+
+	aggregated, _, err := AggregatePublicKeys(A, B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CurvePoint(aggregated).Equal(X) {
+		t.Error("rogue key B = X - A aggregates to X")
+	}
+	if CurvePoint(aggregated).Equal(CurvePoint(A).Add(CurvePoint(B))) {
+		t.Error("aggregated key is the plain sum A + B")
+	}
+}
+
+func TestAggregatePublicKeysRejects(t *testing.T) {
+	publicKey := newTestPublicKeys(t, 1)[0]
+	smallOrder := PublicKey(decodeHex(t, smallOrderPoint))
+	nonCanonical := PublicKey(decodeHex(t, "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))
+	mixedOrder := PublicKey(CurvePoint(publicKey).Add(CurvePoint(smallOrder)))
+
+	tests := map[string][]PublicKey{
+		"empty":         nil,
+		"duplicate":     {publicKey, publicKey},
+		"small order":   {publicKey, smallOrder},
+		"mixed order":   {publicKey, mixedOrder},
+		"non-canonical": {nonCanonical, publicKey},
+		"short":         {publicKey, publicKey[:31]},
+	}
+	for name, publicKeys := range tests {
+		if _, _, err := AggregatePublicKeys(publicKeys...); err == nil {
+			t.Errorf("%s: AggregatePublicKeys accepted the keys", name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ed25519
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+var syntheticNonceTag = []byte("PTLC/ed25519/nonce/synthetic")
+
+// SyntheticNonce derives the secret nonce r of an adaptor pre-signature from
+// the private scalar key, the public key, the message, the adaptor point T
+// and 32 bytes of fresh randomness:
+//
+//	r = SHA512(tag || z || key || publicKey || T || message) mod l
+//
+// Like the deterministic nonces of RFC 8032, r never repeats for different
+// messages or adaptor points even if rand is broken, and the randomness z
+// protects against fault attacks and keeps retried steps from reusing a
+// nonce. If rand is nil, crypto/rand.Reader will be used.
+func SyntheticNonce(rand io.Reader, key Scalar, publicKey PublicKey, message []byte, T CurvePoint) (Scalar, error) {
+	z, err := nonceRandomness(rand)
+	if err != nil {
+		return nil, err
+	}
+	return syntheticNonce(z, key, publicKey, message, T, 0), nil
+}
+
+// PreSignSynthetic creates an adaptor signature like PreSign with a nonce
+// derived by SyntheticNonce.
+func PreSignSynthetic(rand io.Reader, key Scalar, publicKey PublicKey, message []byte, T CurvePoint) (*AdaptorSignature, error) {
+	nonce, err := SyntheticNonce(rand, key, publicKey, message, T)
+	if err != nil {
+		return nil, err
+	}
+	return PreSign(key, nonce, publicKey, message, T), nil
+}
+
+// GenerateSyntheticNonce generates a MuSig2 nonce pair like GenerateNonce,
+// but derives both secret nonces as SyntheticNonce does from the signer's
+// private scalar key and whatever parts of the signing context are known when
+// the nonce is created. publicKey, message and T may be nil.
+func GenerateSyntheticNonce(rand io.Reader, key Scalar, publicKey PublicKey, message []byte, T CurvePoint) (*SecretNonce, PublicNonce, error) {
+	z, err := nonceRandomness(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k1 := syntheticNonce(z, key, publicKey, message, T, 1)
+	k2 := syntheticNonce(z, key, publicKey, message, T, 2)
+	return newSecretNonce(k1, k2), publicNonce(k1, k2), nil
+}
+
+// nonceRandomness reads 32 bytes from rand.
+func nonceRandomness(rand io.Reader) ([]byte, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+
+	z := make([]byte, 32)
+	if _, err := io.ReadFull(rand, z); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// syntheticNonce hashes the randomness z, the context and a counter that
+// separates the nonces of a pair. Every variable-length input is prefixed
+// with its length, so absent inputs cannot collide with present ones.
+func syntheticNonce(z []byte, key Scalar, publicKey PublicKey, message []byte, T CurvePoint, counter byte) Scalar {
+	return hashToScalar(syntheticNonceTag,
+		z,
+		lengthPrefixed(key),
+		lengthPrefixed(publicKey),
+		lengthPrefixed(T),
+		lengthPrefixed(message),
+		[]byte{counter})
+}
+
+// lengthPrefixed returns the 8-byte big-endian length of b followed by b.
+func lengthPrefixed(b []byte) []byte {
+	prefixed := make([]byte, 8, 8+len(b))
+	binary.BigEndian.PutUint64(prefixed, uint64(len(b)))
+	return append(prefixed, b...)
+}
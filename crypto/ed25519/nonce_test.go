@@ -0,0 +1,112 @@
+package ed25519
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// fixedRand returns a reader that always yields the same randomness.
+func fixedRand(b byte) io.Reader {
+	return bytes.NewReader(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestSyntheticNonceDeterminism(t *testing.T) {
+	key := newTestScalar(t)
+	publicKey := PublicKey(key.ToCurvePoint())
+	message := []byte("PTLC/ed25519/nonce")
+	T := newTestScalar(t).ToCurvePoint()
+
+	nonce := func(rand io.Reader, key Scalar, message []byte, T CurvePoint) Scalar {
+		t.Helper()
+		r, err := SyntheticNonce(rand, key, publicKey, message, T)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	r := nonce(fixedRand(1), key, message, T)
+	if !r.Equal(nonce(fixedRand(1), key, message, T)) {
+		t.Fatal("the same inputs gave different nonces")
+	}
+
+	tests := []struct {
+		name    string
+		rand    io.Reader
+		key     Scalar
+		message []byte
+		T       CurvePoint
+	}{
+		{"changed message", fixedRand(1), key, []byte("PTLC/ed25519/other"), T},
+		{"changed T", fixedRand(1), key, message, newTestScalar(t).ToCurvePoint()},
+		{"changed key", fixedRand(1), newTestScalar(t), message, T},
+		{"changed randomness", fixedRand(2), key, message, T},
+		{"no T", fixedRand(1), key, message, nil},
+		{"T moved into the message", fixedRand(1), key, append(append([]byte(nil), message...), T...), nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if nonce(test.rand, test.key, test.message, test.T).Equal(r) {
+				t.Fatal("different inputs gave the same nonce")
+			}
+		})
+	}
+
+	if _, err := SyntheticNonce(iotest.ErrReader(iotest.ErrTimeout), key, publicKey, message, T); err != iotest.ErrTimeout {
+		t.Fatalf("SyntheticNonce with a failing rand returned %v", err)
+	}
+}
+
+func TestGenerateSyntheticNonce(t *testing.T) {
+	key := newTestScalar(t)
+	publicKey := PublicKey(key.ToCurvePoint())
+	T := newTestScalar(t).ToCurvePoint()
+
+	secret, nonce, err := GenerateSyntheticNonce(fixedRand(1), key, publicKey, nil, T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePublicNonce(nonce); err != nil {
+		t.Fatal(err)
+	}
+	if !nonce.R1().Equal(secret.k1.ToCurvePoint()) || !nonce.R2().Equal(secret.k2.ToCurvePoint()) {
+		t.Fatal("public nonce does not match the secret nonce")
+	}
+	if secret.k1.Equal(secret.k2) {
+		t.Fatal("both nonces of the pair are equal")
+	}
+
+	_, again, err := GenerateSyntheticNonce(fixedRand(1), key, publicKey, nil, T)
+	if err != nil || !bytes.Equal(again, nonce) {
+		t.Fatal("the same inputs gave different nonces")
+	}
+	_, other, err := GenerateSyntheticNonce(fixedRand(1), key, publicKey, nil, newTestScalar(t).ToCurvePoint())
+	if err != nil || bytes.Equal(other, nonce) {
+		t.Fatal("a changed T gave the same nonces")
+	}
+	_, other, err = GenerateSyntheticNonce(fixedRand(1), key, publicKey, []byte("PTLC/ed25519/nonce"), T)
+	if err != nil || bytes.Equal(other, nonce) {
+		t.Fatal("a changed message gave the same nonces")
+	}
+}
+
+func TestPreSignSynthetic(t *testing.T) {
+	key := newTestScalar(t)
+	publicKey := PublicKey(key.ToCurvePoint())
+	message := []byte("PTLC/ed25519/nonce")
+	adaptor := newTestScalar(t)
+	T := adaptor.ToCurvePoint()
+
+	as, err := PreSignSynthetic(nil, key, publicKey, message, T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !PreVerify(publicKey, message, T, as) {
+		t.Fatal("PreVerify rejected a synthetic pre-signature")
+	}
+	if !Verify(publicKey, message, Adapt(as, Adaptor(adaptor))) {
+		t.Fatal("Verify rejected the adapted synthetic pre-signature")
+	}
+}
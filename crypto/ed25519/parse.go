@@ -0,0 +1,66 @@
+package ed25519
+
+import (
+	"errors"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/group"
+)
+
+var (
+	// ErrInvalidPoint is returned when an encoding does not decode to a point
+	// on the curve.
+	ErrInvalidPoint = errors.New("ed25519: invalid point encoding")
+	// ErrNonCanonicalPoint is returned when a point is not encoded in its
+	// unique canonical form.
+	ErrNonCanonicalPoint = errors.New("ed25519: non-canonical point encoding")
+	// ErrSmallOrderPoint is returned for the identity and the other points of
+	// order dividing the cofactor.
+	ErrSmallOrderPoint = errors.New("ed25519: point has small order")
+	// ErrMixedOrderPoint is returned for points with a non-zero torsion
+	// component, which are outside the prime-order subgroup.
+	ErrMixedOrderPoint = errors.New("ed25519: point is not in the prime-order subgroup")
+	// ErrInvalidScalar is returned when a scalar has the wrong length or is
+	// not reduced modulo the group order l.
+	ErrInvalidScalar = errors.New("ed25519: scalar is not reduced modulo l")
+)
+
+// ParseScalar parses a 32-byte little-endian scalar. It rejects encodings
+// that are not reduced modulo l, so every scalar has exactly one encoding.
+func ParseScalar(b []byte) (Scalar, error) {
+	s, err := group.ScalarFromCanonicalBytes(b)
+	if err != nil {
+		return nil, ErrInvalidScalar
+	}
+	return fromScalar(s), nil
+}
+
+// ParsePoint parses a 32-byte point encoding received from an untrusted
+// source. Besides rejecting encodings that are not on the curve, it rejects
+// non-canonical encodings, points of small order and points with a torsion
+// component, so that the result is always a canonical element of the
+// prime-order subgroup.
+func ParsePoint(b []byte) (CurvePoint, error) {
+	P, err := group.PointFromBytes(b)
+	if err != nil {
+		return nil, ErrInvalidPoint
+	}
+	if canonical := P.Bytes(); string(canonical[:]) != string(b) {
+		return nil, ErrNonCanonicalPoint
+	}
+	if P.IsSmallOrder() {
+		return nil, ErrSmallOrderPoint
+	}
+	if !P.IsTorsionFree() {
+		return nil, ErrMixedOrderPoint
+	}
+	return fromPoint(P), nil
+}
+
+// ParsePublicKey parses a public key with the same checks as ParsePoint.
+func ParsePublicKey(b []byte) (PublicKey, error) {
+	point, err := ParsePoint(b)
+	if err != nil {
+		return nil, err
+	}
+	return PublicKey(point), nil
+}
@@ -0,0 +1,121 @@
+package ed25519
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// orderBytes is the 32-byte little-endian encoding of l.
+var orderBytes = [32]byte{
+	0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+// basePointHex is the canonical encoding of the base point B.
+const basePointHex = "5866666666666666666666666666666666666666666666666666666666666666"
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParsePoint(t *testing.T) {
+	smallOrder := CurvePoint(decodeHex(t, smallOrderPoint))
+	base := CurvePoint(decodeHex(t, basePointHex))
+	random := newTestScalar(t).ToCurvePoint()
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		err     error
+	}{
+		{"base point", base, nil},
+		{"random point", random, nil},
+
+		{"empty", nil, ErrInvalidPoint},
+		{"short", base[:31], ErrInvalidPoint},
+		{"long", append(append([]byte(nil), base...), 0), ErrInvalidPoint},
+		{"not on the curve", decodeHex(t, "0200000000000000000000000000000000000000000000000000000000000000"), ErrInvalidPoint},
+
+		// y = p + 1 encodes the identity, y = p the point (sqrt(-1), 0) and
+		// y = p + 3 a point of mixed order. Canonicity is checked first.
+		{"non-canonical y = p + 1", decodeHex(t, "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), ErrNonCanonicalPoint},
+		{"non-canonical y = p", decodeHex(t, "edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), ErrNonCanonicalPoint},
+		{"non-canonical y = p + 3", decodeHex(t, "f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), ErrNonCanonicalPoint},
+		{"non-canonical y = p + 3, negative x", decodeHex(t, "f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"), ErrNonCanonicalPoint},
+		{"negative zero x", decodeHex(t, "0100000000000000000000000000000000000000000000000000000000000080"), ErrNonCanonicalPoint},
+
+		{"identity", decodeHex(t, "0100000000000000000000000000000000000000000000000000000000000000"), ErrSmallOrderPoint},
+		{"order 2", decodeHex(t, "ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"), ErrSmallOrderPoint},
+		{"order 4", decodeHex(t, "0000000000000000000000000000000000000000000000000000000000000000"), ErrSmallOrderPoint},
+		{"order 4, negative x", decodeHex(t, "0000000000000000000000000000000000000000000000000000000000000080"), ErrSmallOrderPoint},
+		{"order 8", smallOrder, ErrSmallOrderPoint},
+		{"order 8, negative x", decodeHex(t, "26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05"), ErrSmallOrderPoint},
+
+		{"base point plus order 8", base.Add(smallOrder), ErrMixedOrderPoint},
+		{"random point plus order 8", random.Add(smallOrder), ErrMixedOrderPoint},
+		{"random point plus order 2", random.Add(CurvePoint(decodeHex(t, "ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"))), ErrMixedOrderPoint},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			point, err := ParsePoint(test.encoded)
+			if err != test.err {
+				t.Fatalf("ParsePoint returned %v, want %v", err, test.err)
+			}
+			if _, keyErr := ParsePublicKey(test.encoded); keyErr != test.err {
+				t.Fatalf("ParsePublicKey returned %v, want %v", keyErr, test.err)
+			}
+			if err == nil && !point.Equal(CurvePoint(test.encoded)) {
+				t.Fatal("ParsePoint changed the encoding")
+			}
+		})
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	one := make(Scalar, ScalarSize)
+	one[0] = 1
+	lMinusOne := append(Scalar(nil), orderBytes[:]...)
+	lMinusOne[0]--
+	max := make([]byte, ScalarSize)
+	for i := range max {
+		max[i] = 0xff
+	}
+	random := newTestScalar(t)
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		err     error
+	}{
+		{"zero", make([]byte, ScalarSize), nil},
+		{"one", one, nil},
+		{"l - 1", lMinusOne, nil},
+		{"random", random, nil},
+
+		{"l", orderBytes[:], ErrInvalidScalar},
+		{"l + 1", addOrder(one), ErrInvalidScalar},
+		{"random + l", addOrder(random), ErrInvalidScalar},
+		{"2^256 - 1", max, ErrInvalidScalar},
+		{"empty", nil, ErrInvalidScalar},
+		{"short", random[:31], ErrInvalidScalar},
+		{"long", append(append([]byte(nil), random...), 0), ErrInvalidScalar},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scalar, err := ParseScalar(test.encoded)
+			if err != test.err {
+				t.Fatalf("ParseScalar returned %v, want %v", err, test.err)
+			}
+			if err == nil && !scalar.Equal(Scalar(test.encoded)) {
+				t.Fatal("ParseScalar changed the encoding")
+			}
+		})
+	}
+}
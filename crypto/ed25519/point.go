@@ -0,0 +1,93 @@
+package ed25519
+
+import (
+	"strconv"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/group"
+	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
+)
+
+// Identity returns the encoding of the neutral element of the group.
+func Identity() CurvePoint {
+	return fromPoint(group.Identity())
+}
+
+// Neg returns -cp. It panics if cp is not a valid point.
+func (cp CurvePoint) Neg() CurvePoint {
+	return fromPoint(cp.value().Negate())
+}
+
+// Sub returns cp - point. It panics if either is not a valid point.
+func (cp CurvePoint) Sub(point CurvePoint) CurvePoint {
+	return fromPoint(cp.value().Sub(point.value()))
+}
+
+// ScalarMult returns sc*cp, with sc reduced modulo l. It panics if cp is not
+// a valid point or sc has the wrong length.
+func (cp CurvePoint) ScalarMult(sc Scalar) CurvePoint {
+	return fromPoint(cp.value().ScalarMult(sc.value()))
+}
+
+// Equal reports whether cp and point are the same point, in constant time.
+// Invalid encodings are never equal.
+func (cp CurvePoint) Equal(point CurvePoint) bool {
+	P, err := group.PointFromBytes(cp)
+	if err != nil {
+		return false
+	}
+	Q, err := group.PointFromBytes(point)
+	if err != nil {
+		return false
+	}
+	return P.Equal(Q)
+}
+
+// IsIdentity reports whether cp is the neutral element. It panics if cp is
+// not a valid point.
+func (cp CurvePoint) IsIdentity() bool {
+	return cp.value().IsIdentity()
+}
+
+// IsTorsionFree reports whether cp is in the prime-order subgroup, that is
+// l*cp is the neutral element. It panics if cp is not a valid point.
+func (cp CurvePoint) IsTorsionFree() bool {
+	return cp.value().IsTorsionFree()
+}
+
+// value decodes cp into a group.Point. It panics if cp is not a valid point;
+// input from untrusted sources must be validated with ParsePoint first.
+func (cp CurvePoint) value() group.Point {
+	P, err := group.PointFromBytes(cp)
+	if err != nil {
+		panic("ed25519: unable to parse point")
+	}
+	return P
+}
+
+// fromPoint returns the encoding of P.
+func fromPoint(P group.Point) CurvePoint {
+	encoded := P.Bytes()
+	return CurvePoint(encoded[:])
+}
+
+// fromElement returns the encoding of p.
+func fromElement(p *edwards25519.ExtendedGroupElement) CurvePoint {
+	var encoded [CurvePointSize]byte
+	p.ToBytes(&encoded)
+	return CurvePoint(encoded[:])
+}
+
+// value reduces sc into a group.Scalar. It panics if sc has the wrong length.
+func (sc Scalar) value() group.Scalar {
+	s, err := group.ScalarFromBytesModOrder(sc)
+	if err != nil {
+		panic("ed25519: bad scalar length: " + strconv.Itoa(len(sc)))
+	}
+	return s
+}
+
+// fromScalar returns the encoding of s.
+func fromScalar(s group.Scalar) Scalar {
+	encoded := s.Bytes()
+	return Scalar(encoded[:])
+}
@@ -0,0 +1,88 @@
+package ed25519
+
+import "testing"
+
+func TestCurvePointArithmetic(t *testing.T) {
+	a, b := newTestScalar(t), newTestScalar(t)
+	A, B := a.ToCurvePoint(), b.ToCurvePoint()
+	identity := Identity()
+
+	if !BasePoint().Equal(CurvePoint(decodeHex(t, basePointHex))) {
+		t.Fatal("BasePoint is not G")
+	}
+	if !A.Add(B).Equal(a.Add(b).ToCurvePoint()) {
+		t.Fatal("a*G + b*G != (a + b)*G")
+	}
+	if !A.Sub(B).Equal(a.Subtract(b).ToCurvePoint()) {
+		t.Fatal("a*G - b*G != (a - b)*G")
+	}
+	if !A.Neg().Equal(a.Negate().ToCurvePoint()) || !A.Add(A.Neg()).IsIdentity() {
+		t.Fatal("-(a*G) != (-a)*G")
+	}
+	if !A.ScalarMult(b).Equal(a.Multiply(b).ToCurvePoint()) || !A.ScalarMult(b).Equal(B.ScalarMult(a)) {
+		t.Fatal("b*(a*G) != (a*b)*G")
+	}
+	if !A.ScalarMult(addOrder(b)).Equal(A.ScalarMult(b)) {
+		t.Fatal("ScalarMult does not reduce the scalar modulo l")
+	}
+	if !A.Add(identity).Equal(A) || !A.ScalarMult(make(Scalar, ScalarSize)).IsIdentity() {
+		t.Fatal("identity laws do not hold")
+	}
+	if !identity.IsIdentity() || A.IsIdentity() {
+		t.Fatal("IsIdentity does not recognize the identity")
+	}
+}
+
+func TestCurvePointEqual(t *testing.T) {
+	A := newTestScalar(t).ToCurvePoint()
+	if !A.Equal(A) || A.Equal(newTestScalar(t).ToCurvePoint()) {
+		t.Fatal("Equal does not compare points")
+	}
+	// Equal compares points, not encodings.
+	if !Identity().Equal(CurvePoint(decodeHex(t, nonCanonicalIdentityHex))) {
+		t.Fatal("Equal rejected a non-canonical encoding of the same point")
+	}
+
+	invalid := CurvePoint(decodeHex(t, "0200000000000000000000000000000000000000000000000000000000000000"))
+	for _, point := range []CurvePoint{invalid, A[:31], nil} {
+		if point.Equal(point) || A.Equal(point) || point.Equal(A) {
+			t.Fatalf("Equal accepted the invalid encoding %x", []byte(point))
+		}
+	}
+}
+
+func TestCurvePointIsTorsionFree(t *testing.T) {
+	A := newTestScalar(t).ToCurvePoint()
+	smallOrder := CurvePoint(decodeHex(t, smallOrderPoint))
+	if !A.IsTorsionFree() || !Identity().IsTorsionFree() {
+		t.Fatal("IsTorsionFree rejected a point of the prime-order subgroup")
+	}
+	if smallOrder.IsTorsionFree() || A.Add(smallOrder).IsTorsionFree() {
+		t.Fatal("IsTorsionFree accepted a point with a torsion component")
+	}
+	if !smallOrder.ScalarMult(Scalar(decodeHex(t, "0800000000000000000000000000000000000000000000000000000000000000"))).IsIdentity() {
+		t.Fatal("8 times a point of order 8 is not the identity")
+	}
+}
+
+func TestCurvePointPanics(t *testing.T) {
+	invalid := CurvePoint(decodeHex(t, "0200000000000000000000000000000000000000000000000000000000000000"))
+	A := newTestScalar(t).ToCurvePoint()
+	for name, f := range map[string]func(){
+		"Add":        func() { A.Add(invalid) },
+		"Sub":        func() { invalid.Sub(A) },
+		"Neg":        func() { invalid.Neg() },
+		"ScalarMult": func() { invalid.ScalarMult(newTestScalar(t)) },
+		"short":      func() { A[:31].IsIdentity() },
+		"scalar":     func() { A.ScalarMult(Scalar{1}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("no panic on invalid input")
+				}
+			}()
+			f()
+		})
+	}
+}
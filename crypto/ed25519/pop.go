@@ -0,0 +1,86 @@
+package ed25519
+
+import (
+	"errors"
+	"io"
+)
+
+// ProofOfPossessionSize is the size, in bytes, of an encoded proof of
+// possession.
+const ProofOfPossessionSize = 2 * ScalarSize
+
+var (
+	popNonceTag     = []byte("PTLC/ed25519/pop/nonce")
+	popChallengeTag = []byte("PTLC/ed25519/pop/challenge")
+)
+
+// ProofOfPossession is a non-interactive Schnorr proof of knowledge of the
+// discrete logarithm x of a point X = x*G.
+//
+// It is the sigma protocol of sigma.go over the base G; the proof is the
+// pair (c, s). The context is part of the statement and binds the proof to
+// its sender and purpose, so that a proof cannot be replayed for a copied
+// key.
+//
+// A party that accepts a key only with a valid proof cannot be tricked into a
+// joint key chosen by its counterparty, as in the rogue-key attack B = X - A.
+type ProofOfPossession struct {
+	C Scalar
+	S Scalar
+}
+
+// ProvePossession proves knowledge of x and returns the proof together with
+// X = x*G. The statement is len(context) || context || X. If rand is nil,
+// crypto/rand.Reader will be used.
+func ProvePossession(rand io.Reader, x Scalar, context []byte) (proof *ProofOfPossession, X CurvePoint, err error) {
+	if len(x) != ScalarSize {
+		return nil, nil, ErrInvalidScalar
+	}
+
+	X = x.ToCurvePoint()
+	c, s, err := proveSigma(rand, popNonceTag, popChallengeTag, x, []CurvePoint{BasePoint()}, [][]byte{lengthPrefixed(context), X})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ProofOfPossession{C: c, S: s}, X, nil
+}
+
+// VerifyPossession reports whether proof shows knowledge of the discrete
+// logarithm of X for the given context. X must be a valid point of the
+// prime-order subgroup as accepted by ParsePoint. It returns false for a nil
+// proof.
+func VerifyPossession(proof *ProofOfPossession, X CurvePoint, context []byte) bool {
+	if proof == nil {
+		return false
+	}
+	if _, err := ParsePoint(X); err != nil {
+		return false
+	}
+	return verifySigma(popChallengeTag, proof.C, proof.S, []CurvePoint{BasePoint()}, []CurvePoint{X}, [][]byte{lengthPrefixed(context), X})
+}
+
+// Bytes returns the 64-byte encoding c || s of the proof.
+func (proof *ProofOfPossession) Bytes() []byte {
+	encoded := make([]byte, ProofOfPossessionSize)
+	copy(encoded[:ScalarSize], proof.C)
+	copy(encoded[ScalarSize:], proof.S)
+	return encoded
+}
+
+// ParseProofOfPossession parses the 64-byte encoding c || s of a proof of
+// possession.
+func ParseProofOfPossession(b []byte) (*ProofOfPossession, error) {
+	if len(b) != ProofOfPossessionSize {
+		return nil, errors.New("ed25519: bad proof of possession length")
+	}
+
+	c, err := ParseScalar(b[:ScalarSize])
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseScalar(b[ScalarSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &ProofOfPossession{C: c, S: s}, nil
+}
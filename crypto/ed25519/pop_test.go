@@ -0,0 +1,65 @@
+package ed25519
+
+import "testing"
+
+func TestProofOfPossession(t *testing.T) {
+	x := newTestScalar(t)
+	context := []byte("PTLC/ed25519/pop/test")
+	proof, X, err := ProvePossession(nil, x, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !X.Equal(x.ToCurvePoint()) {
+		t.Fatal("ProvePossession returned the wrong point")
+	}
+	if !VerifyPossession(proof, X, context) {
+		t.Fatal("VerifyPossession rejected a valid proof")
+	}
+
+	parsed, err := ParseProofOfPossession(proof.Bytes())
+	if err != nil || !VerifyPossession(parsed, X, context) {
+		t.Fatal("encoded proof does not round-trip")
+	}
+	if _, err := ParseProofOfPossession(proof.Bytes()[:ProofOfPossessionSize-1]); err == nil {
+		t.Fatal("ParseProofOfPossession accepted a short proof")
+	}
+	if _, err := ParseProofOfPossession(append(addOrder(proof.C), proof.S...)); err != ErrInvalidScalar {
+		t.Fatalf("ParseProofOfPossession with an unreduced c returned %v, want %v", err, ErrInvalidScalar)
+	}
+}
+
+func TestVerifyPossessionRejects(t *testing.T) {
+	x := newTestScalar(t)
+	context := []byte("PTLC/ed25519/pop/test")
+	proof, X, err := ProvePossession(nil, x, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := indexScalar(1)
+	// A rogue key B = Y - X, whose discrete logarithm the prover does not
+	// know, cannot reuse the proof of X.
+	rogue := newTestScalar(t).ToCurvePoint().Sub(X)
+
+	tests := []struct {
+		name    string
+		proof   *ProofOfPossession
+		X       CurvePoint
+		context []byte
+	}{
+		{"nil proof", nil, X, context},
+		{"tampered c", &ProofOfPossession{C: proof.C.Add(one), S: proof.S}, X, context},
+		{"tampered s", &ProofOfPossession{C: proof.C, S: proof.S.Add(one)}, X, context},
+		{"unreduced s", &ProofOfPossession{C: proof.C, S: addOrder(proof.S)}, X, context},
+		{"other context", proof, X, []byte("PTLC/ed25519/pop/other")},
+		{"empty context", proof, X, nil},
+		{"rogue key", proof, rogue, context},
+		{"mixed-order key", proof, X.Add(CurvePoint(decodeHex(t, smallOrderPoint))), context},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if VerifyPossession(test.proof, test.X, test.context) {
+				t.Fatal("VerifyPossession accepted an invalid proof")
+			}
+		})
+	}
+}
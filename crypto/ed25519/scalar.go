@@ -0,0 +1,60 @@
+package ed25519
+
+import (
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/group"
+)
+
+// UniformBytesSize is the number of bytes reduced by ScalarFromUniformBytes.
+const UniformBytesSize = group.UniformBytesSize
+
+// RandomScalar returns a uniformly random scalar modulo l, reducing 64 bytes
+// from rand so that the bias is negligible. If rand is nil,
+// crypto/rand.Reader will be used.
+//
+// Unlike the clamped keys of GenerateKey2, the result is suitable for any
+// secret of the swap protocols: private keys, nonces and adaptors.
+func RandomScalar(rand io.Reader) (Scalar, error) {
+	s, err := group.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	return fromScalar(s), nil
+}
+
+// ScalarFromUniformBytes returns the 64-byte little-endian integer b reduced
+// modulo l. If b is uniformly random, so is the scalar.
+func ScalarFromUniformBytes(b []byte) (Scalar, error) {
+	s, err := group.ScalarFromUniformBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return fromScalar(s), nil
+}
+
+// Negate returns -sc mod l. It panics if sc has the wrong length.
+func (sc Scalar) Negate() Scalar {
+	return fromScalar(sc.value().Negate())
+}
+
+// Invert returns sc^-1 mod l. The inverse of zero is zero. It panics if sc
+// has the wrong length.
+func (sc Scalar) Invert() Scalar {
+	return fromScalar(sc.value().Invert())
+}
+
+// Equal reports whether sc and scalar are equal modulo l, in constant time.
+// Scalars of the wrong length are never equal.
+func (sc Scalar) Equal(scalar Scalar) bool {
+	if len(sc) != ScalarSize || len(scalar) != ScalarSize {
+		return false
+	}
+	return sc.value().Equal(scalar.value())
+}
+
+// IsZero reports whether sc is zero modulo l, in constant time. It panics if
+// sc has the wrong length.
+func (sc Scalar) IsZero() bool {
+	return sc.value().IsZero()
+}
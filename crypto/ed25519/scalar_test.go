@@ -0,0 +1,93 @@
+package ed25519
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+// TestScalarArithmetic checks the slice-based operations against the group
+// scalars they wrap, whose arithmetic is checked against math/big.
+func TestScalarArithmetic(t *testing.T) {
+	zero, one := make(Scalar, ScalarSize), make(Scalar, ScalarSize)
+	one[0] = 1
+	for i := 0; i < 16; i++ {
+		a, b := newTestScalar(t), newTestScalar(t)
+		A, B := a.value(), b.value()
+
+		if got := a.Add(b); !bytes.Equal(got, fromScalar(A.Add(B))) {
+			t.Fatalf("%x + %x = %x", a, b, got)
+		}
+		if got := a.Subtract(b); !bytes.Equal(got, fromScalar(A.Sub(B))) {
+			t.Fatalf("%x - %x = %x", a, b, got)
+		}
+		if got := a.Multiply(b); !bytes.Equal(got, fromScalar(A.Mul(B))) {
+			t.Fatalf("%x * %x = %x", a, b, got)
+		}
+		if got := a.Negate(); !bytes.Equal(got, fromScalar(A.Negate())) || !a.Add(got).IsZero() {
+			t.Fatalf("-%x = %x", a, got)
+		}
+		if got := a.Invert(); !bytes.Equal(got, fromScalar(A.Invert())) {
+			t.Fatalf("%x^-1 = %x", a, got)
+		}
+		if !a.Add(zero).Equal(a) || !a.Multiply(a.Invert()).Equal(one) {
+			t.Fatal("identities do not hold")
+		}
+	}
+	if !zero.Invert().IsZero() || !zero.Negate().IsZero() {
+		t.Fatal("zero is not its own inverse and negation")
+	}
+}
+
+func TestScalarEqual(t *testing.T) {
+	a, b := newTestScalar(t), newTestScalar(t)
+	if !a.Equal(a) || a.Equal(b) {
+		t.Fatal("Equal does not compare scalars")
+	}
+	if !a.Equal(addOrder(a)) {
+		t.Fatal("Equal does not compare modulo l")
+	}
+	if a.Equal(a[:31]) || Scalar(a[:31]).Equal(a) || a.Equal(nil) {
+		t.Fatal("Equal accepted a scalar of the wrong length")
+	}
+
+	if !Scalar(make([]byte, ScalarSize)).IsZero() || !Scalar(orderBytes[:]).IsZero() {
+		t.Fatal("IsZero rejected zero")
+	}
+	if a.IsZero() {
+		t.Fatal("IsZero accepted a random scalar")
+	}
+}
+
+func TestRandomScalar(t *testing.T) {
+	a, b := newTestScalar(t), newTestScalar(t)
+	if _, err := ParseScalar(a); err != nil {
+		t.Fatal("RandomScalar returned an unreduced scalar")
+	}
+	if a.Equal(b) {
+		t.Fatal("RandomScalar repeated a scalar")
+	}
+
+	uniform := bytes.Repeat([]byte{0x5a}, UniformBytesSize)
+	fromReader, err := RandomScalar(bytes.NewReader(uniform))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBytes, err := ScalarFromUniformBytes(uniform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fromReader, fromBytes) {
+		t.Fatal("RandomScalar does not reduce 64 bytes from rand")
+	}
+
+	if _, err := RandomScalar(iotest.ErrReader(iotest.ErrTimeout)); err != iotest.ErrTimeout {
+		t.Fatalf("RandomScalar with a failing rand returned %v", err)
+	}
+	if _, err := RandomScalar(bytes.NewReader(uniform[:UniformBytesSize-1])); err == nil {
+		t.Fatal("RandomScalar accepted a short read")
+	}
+	if _, err := ScalarFromUniformBytes(uniform[:ScalarSize]); err == nil {
+		t.Fatal("ScalarFromUniformBytes accepted 32 bytes")
+	}
+}
@@ -0,0 +1,54 @@
+package ed25519
+
+import "io"
+
+// This file implements the Schnorr sigma protocol behind DLEQProof and
+// ProofOfPossession: a proof that the points X_i share the discrete
+// logarithm x relative to the bases G_i, that is X_i = x*G_i for all i.
+//
+// The prover picks a nonce k, commits to K_i = k*G_i and answers the
+// challenge c = H(statement || K_1 || ... || K_n) with s = k + c*x. The
+// verifier recomputes K_i = s*G_i - c*X_i and checks the challenge. The
+// statement holds the public inputs of the proof, including the points X_i,
+// in the encoding chosen by the proof type.
+//
+// The nonce is hedged: k = H(x || z || statement) with 32 bytes z from rand,
+// so that a weak rand alone does not leak x.
+
+// proveSigma returns the proof (c, s) that x is the discrete logarithm of
+// the points of statement relative to bases. If rand is nil,
+// crypto/rand.Reader will be used.
+func proveSigma(rand io.Reader, nonceTag, challengeTag []byte, x Scalar, bases []CurvePoint, statement [][]byte) (c, s Scalar, err error) {
+	z, err := nonceRandomness(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k := hashToScalar(nonceTag, append([][]byte{x, z}, statement...)...)
+	commitments := make([][]byte, len(bases))
+	for i, G := range bases {
+		commitments[i] = G.ScalarMult(k)
+	}
+
+	c = hashToScalar(challengeTag, append(append([][]byte(nil), statement...), commitments...)...)
+	s = c.Multiply(x).Add(k)
+	return c, s, nil
+}
+
+// verifySigma reports whether (c, s) proves that the points X_i share their
+// discrete logarithm relative to bases. The points must have been checked
+// with ParsePoint.
+func verifySigma(challengeTag []byte, c, s Scalar, bases, points []CurvePoint, statement [][]byte) bool {
+	if _, err := ParseScalar(c); err != nil {
+		return false
+	}
+	if _, err := ParseScalar(s); err != nil {
+		return false
+	}
+
+	commitments := make([][]byte, len(bases))
+	for i, G := range bases {
+		commitments[i] = G.ScalarMult(s).Sub(points[i].ScalarMult(c))
+	}
+	return hashToScalar(challengeTag, append(append([][]byte(nil), statement...), commitments...)...).Equal(c)
+}
@@ -0,0 +1,265 @@
+package ed25519
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+)
+
+const (
+	// VerifiableEncryptionRounds is the number of cut-and-choose rounds of a
+	// verifiable encryption. A cheating encrypter passes verification with
+	// probability 2^-VerifiableEncryptionRounds.
+	VerifiableEncryptionRounds = 128
+	// VerifiableEncryptionSize is the size, in bytes, of an encoded
+	// verifiable encryption.
+	VerifiableEncryptionSize = VerifiableEncryptionRounds * veRoundSize
+
+	veCiphertextSize = CurvePointSize + ScalarSize
+	veRoundSize      = CurvePointSize + 2*veCiphertextSize + 2*ScalarSize
+)
+
+var (
+	veMaskTag      = []byte("PTLC/ed25519/vencrypt/mask")
+	veChallengeTag = []byte("PTLC/ed25519/vencrypt/challenge")
+)
+
+// VerifiableEncryption is an encryption of a secret t to an agent's public
+// key P together with a proof that it decrypts to the discrete logarithm of
+// the public point T = t*G, for example the adaptor point of a swap.
+//
+// It is a Fiat-Shamir cut-and-choose proof. In every round the encrypter
+// picks a random r, publishes R = r*G and hashed ElGamal encryptions of r and
+// r + t under P. A challenge bit derived from all rounds selects one of the
+// two, which is opened by revealing its plaintext and ephemeral key; the
+// verifier recomputes the ciphertext and checks the plaintext against R or
+// R + T. The agent decrypts the unopened ciphertext of any round and combines
+// it with the opened plaintext to recover t.
+type VerifiableEncryption struct {
+	rounds []veRound
+}
+
+// veRound is one cut-and-choose round. value and ephemeral are the plaintext
+// and ephemeral key of the ciphertext selected by the challenge bit.
+type veRound struct {
+	R           CurvePoint
+	ciphertexts [2][]byte
+	value       Scalar
+	ephemeral   Scalar
+}
+
+// EncryptVerifiably encrypts t to the agent's public key. If rand is nil,
+// crypto/rand.Reader will be used.
+func EncryptVerifiably(rand io.Reader, t Scalar, agent PublicKey) (*VerifiableEncryption, error) {
+	if _, err := ParseScalar(t); err != nil {
+		return nil, err
+	}
+	if _, err := ParsePublicKey(agent); err != nil {
+		return nil, err
+	}
+
+	var values, ephemerals [VerifiableEncryptionRounds][2]Scalar
+	ve := &VerifiableEncryption{rounds: make([]veRound, VerifiableEncryptionRounds)}
+	for i := range ve.rounds {
+		r, err := RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = [2]Scalar{r, r.Add(t)}
+		ve.rounds[i].R = r.ToCurvePoint()
+
+		for b := range values[i] {
+			ephemeral, err := RandomScalar(rand)
+			if err != nil {
+				return nil, err
+			}
+			ephemerals[i][b] = ephemeral
+			ve.rounds[i].ciphertexts[b] = veEncrypt(values[i][b], ephemeral, agent)
+		}
+	}
+
+	challenge := veChallenge(agent, t.ToCurvePoint(), ve.rounds)
+	for i := range ve.rounds {
+		b := veBit(challenge, i)
+		ve.rounds[i].value = values[i][b]
+		ve.rounds[i].ephemeral = ephemerals[i][b]
+	}
+	return ve, nil
+}
+
+// Verify reports whether ve is an encryption to agent of the discrete
+// logarithm of T. It returns false if ve is nil.
+func (ve *VerifiableEncryption) Verify(agent PublicKey, T CurvePoint) bool {
+	if ve == nil || len(ve.rounds) != VerifiableEncryptionRounds {
+		return false
+	}
+	if _, err := ParsePublicKey(agent); err != nil {
+		return false
+	}
+	if _, err := ParsePoint(T); err != nil {
+		return false
+	}
+
+	challenge := veChallenge(agent, T, ve.rounds)
+	for i, round := range ve.rounds {
+		b := veBit(challenge, i)
+		ciphertext := veEncrypt(round.value, round.ephemeral, agent)
+		if subtle.ConstantTimeCompare(ciphertext, round.ciphertexts[b]) != 1 {
+			return false
+		}
+
+		expected := round.R
+		if b == 1 {
+			expected = expected.Add(T)
+		}
+		if !round.value.ToCurvePoint().Equal(expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decrypt recovers the secret t with the agent's private scalar key and
+// checks it against T. ve must have been verified with Verify; it returns
+// ErrSecretMismatch if ve is nil or no round yields the discrete logarithm
+// of T.
+func (ve *VerifiableEncryption) Decrypt(key Scalar, T CurvePoint) (Scalar, error) {
+	if ve == nil || len(ve.rounds) != VerifiableEncryptionRounds {
+		return nil, ErrSecretMismatch
+	}
+
+	agent := PublicKey(key.ToCurvePoint())
+	challenge := veChallenge(agent, T, ve.rounds)
+	for i, round := range ve.rounds {
+		b := veBit(challenge, i)
+		hidden, ok := veDecrypt(round.ciphertexts[1-b], key, agent)
+		if !ok {
+			continue
+		}
+
+		var t Scalar
+		if b == 0 {
+			t = hidden.Subtract(round.value)
+		} else {
+			t = round.value.Subtract(hidden)
+		}
+		if t.ToCurvePoint().Equal(T) {
+			return t, nil
+		}
+	}
+	return nil, ErrSecretMismatch
+}
+
+// Bytes returns the encoding of ve, the concatenation of its rounds
+// R || E_0 || c_0 || E_1 || c_1 || value || ephemeral.
+func (ve *VerifiableEncryption) Bytes() []byte {
+	encoded := make([]byte, 0, len(ve.rounds)*veRoundSize)
+	for _, round := range ve.rounds {
+		encoded = append(encoded, round.R...)
+		encoded = append(encoded, round.ciphertexts[0]...)
+		encoded = append(encoded, round.ciphertexts[1]...)
+		encoded = append(encoded, round.value...)
+		encoded = append(encoded, round.ephemeral...)
+	}
+	return encoded
+}
+
+// ParseVerifiableEncryption parses an encoded verifiable encryption, checking
+// all points as ParsePoint and all opened scalars as ParseScalar do.
+func ParseVerifiableEncryption(b []byte) (*VerifiableEncryption, error) {
+	if len(b) != VerifiableEncryptionSize {
+		return nil, errors.New("ed25519: bad verifiable encryption length")
+	}
+
+	ve := &VerifiableEncryption{rounds: make([]veRound, VerifiableEncryptionRounds)}
+	for i := range ve.rounds {
+		round := b[i*veRoundSize : (i+1)*veRoundSize]
+
+		R, err := ParsePoint(round[:CurvePointSize])
+		if err != nil {
+			return nil, err
+		}
+		round = round[CurvePointSize:]
+
+		var ciphertexts [2][]byte
+		for j := range ciphertexts {
+			if _, err := ParsePoint(round[:CurvePointSize]); err != nil {
+				return nil, err
+			}
+			ciphertexts[j] = append([]byte(nil), round[:veCiphertextSize]...)
+			round = round[veCiphertextSize:]
+		}
+
+		value, err := ParseScalar(round[:ScalarSize])
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := ParseScalar(round[ScalarSize:])
+		if err != nil {
+			return nil, err
+		}
+
+		ve.rounds[i] = veRound{R: R, ciphertexts: ciphertexts, value: value, ephemeral: ephemeral}
+	}
+	return ve, nil
+}
+
+// veEncrypt returns the hashed ElGamal ciphertext E || value XOR mask with
+// E = e*G and mask = SHA512(tag || E || P || e*P), truncated to 32 bytes.
+func veEncrypt(value, ephemeral Scalar, agent PublicKey) []byte {
+	E := ephemeral.ToCurvePoint()
+	mask := veMask(E, agent, CurvePoint(agent).ScalarMult(ephemeral))
+
+	ciphertext := make([]byte, 0, veCiphertextSize)
+	ciphertext = append(ciphertext, E...)
+	for i := range mask {
+		ciphertext = append(ciphertext, value[i]^mask[i])
+	}
+	return ciphertext
+}
+
+// veDecrypt decrypts a ciphertext of veEncrypt with the agent's private
+// scalar key. It fails if the plaintext is not a canonical scalar.
+func veDecrypt(ciphertext []byte, key Scalar, agent PublicKey) (Scalar, bool) {
+	E := CurvePoint(ciphertext[:CurvePointSize])
+	mask := veMask(E, agent, E.ScalarMult(key))
+
+	plaintext := make([]byte, ScalarSize)
+	for i := range mask {
+		plaintext[i] = ciphertext[CurvePointSize+i] ^ mask[i]
+	}
+	value, err := ParseScalar(plaintext)
+	return value, err == nil
+}
+
+// veMask derives the 32-byte mask of a ciphertext from the ephemeral point E
+// and the shared point S.
+func veMask(E CurvePoint, agent PublicKey, S CurvePoint) []byte {
+	h := sha512.New()
+	h.Write(veMaskTag)
+	h.Write(E)
+	h.Write(agent)
+	h.Write(S)
+	return h.Sum(nil)[:ScalarSize]
+}
+
+// veChallenge hashes the agent's key, T and the commitments and ciphertexts
+// of all rounds into the challenge bits.
+func veChallenge(agent PublicKey, T CurvePoint, rounds []veRound) []byte {
+	h := sha512.New()
+	h.Write(veChallengeTag)
+	h.Write(agent)
+	h.Write(T)
+	for _, round := range rounds {
+		h.Write(round.R)
+		h.Write(round.ciphertexts[0])
+		h.Write(round.ciphertexts[1])
+	}
+	return h.Sum(nil)
+}
+
+// veBit returns challenge bit i.
+func veBit(challenge []byte, i int) int {
+	return int(challenge[i/8] >> (i % 8) & 1)
+}
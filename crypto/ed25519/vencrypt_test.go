@@ -0,0 +1,99 @@
+package ed25519
+
+import "testing"
+
+// cloneVerifiableEncryption returns a deep copy of ve.
+func cloneVerifiableEncryption(t *testing.T, ve *VerifiableEncryption) *VerifiableEncryption {
+	t.Helper()
+	clone, err := ParseVerifiableEncryption(ve.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+func TestVerifiableEncryption(t *testing.T) {
+	secret := newTestScalar(t)
+	T := secret.ToCurvePoint()
+	key := newTestScalar(t)
+	agent := PublicKey(key.ToCurvePoint())
+
+	ve, err := EncryptVerifiably(nil, secret, agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ve.Verify(agent, T) {
+		t.Fatal("Verify rejected a valid encryption")
+	}
+	parsed := cloneVerifiableEncryption(t, ve)
+	if !parsed.Verify(agent, T) {
+		t.Fatal("encoded encryption does not round-trip")
+	}
+
+	decrypted, err := parsed.Decrypt(key, T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decrypted.Equal(secret) {
+		t.Fatal("Decrypt recovered the wrong secret")
+	}
+	if _, err := ve.Decrypt(newTestScalar(t), T); err != ErrSecretMismatch {
+		t.Fatalf("Decrypt with the wrong key returned %v, want %v", err, ErrSecretMismatch)
+	}
+
+	if _, err := ParseVerifiableEncryption(ve.Bytes()[:VerifiableEncryptionSize-1]); err == nil {
+		t.Fatal("ParseVerifiableEncryption accepted a short encoding")
+	}
+	unreduced := ve.Bytes()
+	copy(unreduced[veRoundSize-ScalarSize:veRoundSize], addOrder(ve.rounds[0].ephemeral))
+	if _, err := ParseVerifiableEncryption(unreduced); err != ErrInvalidScalar {
+		t.Fatalf("ParseVerifiableEncryption with an unreduced scalar returned %v, want %v", err, ErrInvalidScalar)
+	}
+}
+
+func TestVerifiableEncryptionRejects(t *testing.T) {
+	secret := newTestScalar(t)
+	T := secret.ToCurvePoint()
+	agent := PublicKey(newTestScalar(t).ToCurvePoint())
+	ve, err := EncryptVerifiably(nil, secret, agent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := indexScalar(1)
+
+	tests := []struct {
+		name   string
+		tamper func(ve *VerifiableEncryption)
+		agent  PublicKey
+		T      CurvePoint
+	}{
+		{"wrong T", nil, agent, newTestScalar(t).ToCurvePoint()},
+		{"wrong agent", nil, PublicKey(newTestScalar(t).ToCurvePoint()), T},
+		{"small-order T", nil, agent, CurvePoint(decodeHex(t, smallOrderPoint))},
+		{"tampered value", func(ve *VerifiableEncryption) { ve.rounds[3].value = ve.rounds[3].value.Add(one) }, agent, T},
+		{"tampered ephemeral", func(ve *VerifiableEncryption) { ve.rounds[5].ephemeral = ve.rounds[5].ephemeral.Add(one) }, agent, T},
+		{"tampered R", func(ve *VerifiableEncryption) { ve.rounds[7].R = ve.rounds[7].R.Add(BasePoint()) }, agent, T},
+		{"tampered first ciphertext", func(ve *VerifiableEncryption) { ve.rounds[9].ciphertexts[0][CurvePointSize] ^= 1 }, agent, T},
+		{"tampered second ciphertext", func(ve *VerifiableEncryption) { ve.rounds[9].ciphertexts[1][CurvePointSize] ^= 1 }, agent, T},
+		{"missing round", func(ve *VerifiableEncryption) { ve.rounds = ve.rounds[1:] }, agent, T},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tampered := cloneVerifiableEncryption(t, ve)
+			if test.tamper != nil {
+				test.tamper(tampered)
+			}
+			if tampered.Verify(test.agent, test.T) {
+				t.Fatal("Verify accepted an invalid encryption")
+			}
+		})
+	}
+
+	var missing *VerifiableEncryption
+	if missing.Verify(agent, T) {
+		t.Fatal("Verify accepted a nil encryption")
+	}
+	if _, err := missing.Decrypt(newTestScalar(t), T); err != ErrSecretMismatch {
+		t.Fatalf("Decrypt of a nil encryption returned %v, want %v", err, ErrSecretMismatch)
+	}
+}
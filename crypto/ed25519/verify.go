@@ -0,0 +1,145 @@
+package ed25519
+
+import (
+	"crypto/sha512"
+	"strconv"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519/group"
+	"github.com/kinggorrin/ptlc/crypto/ed25519/internal/edwards25519"
+)
+
+// VerifyMode selects the rules used to accept a signature. Ed25519
+// implementations disagree on the edge cases of the verification equation,
+// and a PTLC is only unlocked when the node accepts the signature, so the
+// mode has to be chosen with the final verifier in mind.
+type VerifyMode int
+
+const (
+	// ModeZenon matches the go-zenon PTLC contract, which verifies unlock
+	// signatures with Go's crypto/ed25519: s must be reduced modulo l, the
+	// public key may be a non-canonical or small-order encoding, and the
+	// cofactorless equation R == s*G - h*A must hold for the encoding of R
+	// in the signature. A signature accepted in this mode unlocks on chain.
+	ModeZenon VerifyMode = iota
+	// ModeCofactorless follows RFC 8032 with the cofactorless equation
+	// s*G == R + h*A. R and A must be canonical encodings and s must be
+	// reduced; small-order points are accepted.
+	ModeCofactorless
+	// ModeCofactored follows ZIP-215: s must be reduced, R and A may be
+	// non-canonical encodings, and the cofactored equation
+	// 8*s*G == 8*R + 8*h*A must hold. Every implementation that follows
+	// ZIP-215 agrees on this mode. BatchVerifier uses this equation, but
+	// only on torsion-free points, where it agrees with ModeZenon.
+	ModeCofactored
+	// ModeStrict requires R and A to be canonical elements of the
+	// prime-order subgroup, as checked by ParsePoint, s to be reduced and
+	// the cofactorless equation to hold. On such inputs every mode agrees,
+	// so a signature accepted in this mode is accepted everywhere.
+	ModeStrict
+)
+
+// String returns the name of the mode.
+func (mode VerifyMode) String() string {
+	switch mode {
+	case ModeZenon:
+		return "zenon"
+	case ModeCofactorless:
+		return "cofactorless"
+	case ModeCofactored:
+		return "cofactored"
+	case ModeStrict:
+		return "strict"
+	}
+	return "VerifyMode(" + strconv.Itoa(int(mode)) + ")"
+}
+
+// VerifyWithMode reports whether sig is a valid signature of message by
+// publicKey under the rules of mode. It will panic if len(publicKey) is not
+// PublicKeySize or mode is unknown.
+func VerifyWithMode(publicKey PublicKey, message, sig []byte, mode VerifyMode) bool {
+	if l := len(publicKey); l != PublicKeySize {
+		panic("ed25519: bad public key length: " + strconv.Itoa(l))
+	}
+	if mode < ModeZenon || mode > ModeStrict {
+		panic("ed25519: unknown verify mode: " + mode.String())
+	}
+
+	if len(sig) != SignatureSize {
+		return false
+	}
+	if _, err := ParseScalar(sig[32:]); err != nil {
+		return false
+	}
+
+	switch mode {
+	case ModeCofactorless:
+		if !isCanonicalPoint(publicKey) || !isCanonicalPoint(sig[:32]) {
+			return false
+		}
+	case ModeStrict:
+		if _, err := ParsePublicKey(publicKey); err != nil {
+			return false
+		}
+		if _, err := ParsePoint(sig[:32]); err != nil {
+			return false
+		}
+	}
+
+	// Verification requires sB = R + H(R,A,m)A
+	// So R = sB - H(R,A,m)A
+	var A edwards25519.ExtendedGroupElement
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], publicKey)
+	if !A.FromBytes(&publicKeyBytes) {
+		return false
+	}
+	edwards25519.FeNeg(&A.X, &A.X)
+	edwards25519.FeNeg(&A.T, &A.T)
+
+	// h = H(R,A,m), always over the encodings given in the signature.
+	h := sha512.New()
+	h.Write(sig[:32])
+	h.Write(publicKey[:])
+	h.Write(message)
+	var digest [64]byte
+	h.Sum(digest[:0])
+
+	var hReduced [32]byte
+	edwards25519.ScReduce(&hReduced, &digest)
+
+	// R' = - H(R,A,m)A + sB
+	var s [32]byte
+	copy(s[:], sig[32:])
+	var checkR edwards25519.ProjectiveGroupElement
+	edwards25519.GeDoubleScalarMultVartime(&checkR, &hReduced, &A, &s)
+
+	var checkRBytes [32]byte
+	checkR.ToBytes(&checkRBytes)
+
+	if mode != ModeCofactored {
+		return string(sig[:32]) == string(checkRBytes[:])
+	}
+
+	// 8*(R' - R) == 0, with R decoded from a possibly non-canonical encoding.
+	var R, checkRElement edwards25519.ExtendedGroupElement
+	var RBytes [32]byte
+	copy(RBytes[:], sig[:32])
+	if !R.FromBytes(&RBytes) || !checkRElement.FromBytes(&checkRBytes) {
+		return false
+	}
+	edwards25519.FeNeg(&R.X, &R.X)
+	edwards25519.FeNeg(&R.T, &R.T)
+	difference := GeAdd(&checkRElement, &R)
+	return fromElement(&difference).value().IsSmallOrder()
+}
+
+// isCanonicalPoint reports whether b is the canonical encoding of a point on
+// the curve.
+func isCanonicalPoint(b []byte) bool {
+	P, err := group.PointFromBytes(b)
+	if err != nil {
+		return false
+	}
+	canonical := P.Bytes()
+	return string(canonical[:]) == string(b)
+}
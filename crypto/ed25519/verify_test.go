@@ -0,0 +1,127 @@
+package ed25519
+
+import (
+	stded25519 "crypto/ed25519"
+	"strconv"
+	"testing"
+)
+
+// Encodings of the edge cases of ZIP-215 and RFC 8032.
+const (
+	// identityHex is the canonical encoding of the identity.
+	identityHex = "0100000000000000000000000000000000000000000000000000000000000000"
+	// nonCanonicalIdentityHex encodes the identity with y = p + 1.
+	nonCanonicalIdentityHex = "eeffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"
+	// negativeZeroIdentityHex encodes the identity with the sign bit of
+	// x = 0 set.
+	negativeZeroIdentityHex = "0100000000000000000000000000000000000000000000000000000000000080"
+)
+
+// newEdgeMessage returns a message for which the challenge over R and A
+// satisfies accept.
+func newEdgeMessage(t *testing.T, R, A []byte, accept func(h Scalar) bool) ([]byte, Scalar) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		message := []byte("PTLC/ed25519/verify/" + strconv.Itoa(i))
+		if h := hashToScalar(nil, R, A, message); accept(h) {
+			return message, h
+		}
+	}
+	t.Fatal("no message found")
+	return nil, nil
+}
+
+func anyChallenge(Scalar) bool { return true }
+
+func TestVerifyWithModeEdgeCases(t *testing.T) {
+	key := newTestScalar(t)
+	A := key.ToCurvePoint()
+	smallOrder := decodeHex(t, smallOrderPoint)
+	identity := decodeHex(t, identityHex)
+	zero := make([]byte, ScalarSize)
+
+	type vector struct {
+		name      string
+		publicKey []byte
+		message   []byte
+		signature []byte
+		// zenon, strict and cofactored are the expected results in ModeZenon,
+		// ModeStrict and ModeCofactored.
+		zenon, strict, cofactored bool
+	}
+	var vectors []vector
+	add := func(name string, publicKey, message, R, s []byte, zenon, strict, cofactored bool) {
+		vectors = append(vectors, vector{name, publicKey, message, append(append([]byte(nil), R...), s...), zenon, strict, cofactored})
+	}
+
+	// A valid signature of the prime-order subgroup.
+	r := newTestScalar(t)
+	R := r.ToCurvePoint()
+	message, h := newEdgeMessage(t, R, A, anyChallenge)
+	s := r.Add(h.Multiply(key))
+	add("valid", A, message, R, s, true, true, true)
+
+	// s >= l, accepted by no mode.
+	add("s + l", A, message, R, addOrder(s), false, false, false)
+	high := append([]byte(nil), s...)
+	high[31] |= 0xe0
+	add("s with the high bits set", A, message, R, high, false, false, false)
+
+	// Small-order A and R: with R the identity and s = 0 the cofactorless
+	// equation holds when h*A is the identity, that is when 8 divides h.
+	message, _ = newEdgeMessage(t, identity, smallOrder, func(h Scalar) bool { return h[0]&7 == 0 })
+	add("small-order A, identity R, h = 0 mod 8", smallOrder, message, identity, zero, true, false, true)
+	message, _ = newEdgeMessage(t, identity, smallOrder, func(h Scalar) bool { return h[0]&7 != 0 })
+	add("small-order A, identity R, h != 0 mod 8", smallOrder, message, identity, zero, false, false, true)
+	// With A = R of order 8, -h*A == R exactly when h = -1 mod 8.
+	message, _ = newEdgeMessage(t, smallOrder, smallOrder, func(h Scalar) bool { return h[0]&7 != 7 })
+	add("small-order A and R", smallOrder, message, smallOrder, zero, false, false, true)
+
+	// Small-order R under a valid key, with s = h*a.
+	message, h = newEdgeMessage(t, identity, A, anyChallenge)
+	add("identity R", A, message, identity, h.Multiply(key), true, false, true)
+	message, h = newEdgeMessage(t, smallOrder, A, anyChallenge)
+	add("small-order R", A, message, smallOrder, h.Multiply(key), false, false, true)
+
+	// A mixed-order A, which only the cofactored equation accepts.
+	mixed := newMixedOrderSignature(t)
+	vectors = append(vectors, vector{"mixed-order A", mixed.publicKey, mixed.message, mixed.signature, false, false, true})
+
+	// Non-canonical encodings of the identity as A, with R the identity
+	// and s = 0. crypto/ed25519 decodes non-canonical public keys.
+	for _, encoding := range []string{nonCanonicalIdentityHex, negativeZeroIdentityHex} {
+		nonCanonical := decodeHex(t, encoding)
+		message, _ = newEdgeMessage(t, identity, nonCanonical, anyChallenge)
+		add("non-canonical A "+encoding[:4]+"..."+encoding[60:], nonCanonical, message, identity, zero, true, false, true)
+	}
+
+	// Non-canonical encodings of the identity as R, under a valid key with
+	// s = h*a. The cofactorless check compares the encoding of R.
+	for _, encoding := range []string{nonCanonicalIdentityHex, negativeZeroIdentityHex} {
+		nonCanonical := decodeHex(t, encoding)
+		message, h = newEdgeMessage(t, nonCanonical, A, anyChallenge)
+		add("non-canonical R "+encoding[:4]+"..."+encoding[60:], A, message, nonCanonical, h.Multiply(key), false, false, true)
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			for _, test := range []struct {
+				mode VerifyMode
+				want bool
+			}{
+				{ModeZenon, v.zenon},
+				{ModeStrict, v.strict},
+				{ModeCofactored, v.cofactored},
+			} {
+				if got := VerifyWithMode(v.publicKey, v.message, v.signature, test.mode); got != test.want {
+					t.Errorf("%v: got %v, want %v", test.mode, got, test.want)
+				}
+			}
+			// ModeZenon must agree with crypto/ed25519, which the PTLC
+			// contract uses.
+			if got := stded25519.Verify(stded25519.PublicKey(v.publicKey), v.message, v.signature); got != v.zenon {
+				t.Errorf("crypto/ed25519: got %v, want %v", got, v.zenon)
+			}
+		})
+	}
+}
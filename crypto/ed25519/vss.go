@@ -0,0 +1,182 @@
+package ed25519
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// VSSShareSize is the size, in bytes, of an encoded VSS share.
+const VSSShareSize = 2 + ScalarSize
+
+// ErrSecretMismatch is returned when the secret reconstructed from VSS shares
+// is not the discrete logarithm of the expected public point.
+var ErrSecretMismatch = errors.New("ed25519: reconstructed secret does not match public point")
+
+// VSSShare is the share of participant Index in a Feldman verifiable secret
+// sharing: the value f(Index) of the dealer's polynomial.
+type VSSShare struct {
+	Index uint16
+	Value Scalar
+}
+
+// VSSCommitment holds the commitments C_k = a_k*G to the coefficients of the
+// dealer's polynomial f(x) = a_0 + a_1*x + ... + a_t-1*x^(t-1). C_0 is the
+// public point of the shared secret, for example the adaptor point T, and
+// len(VSSCommitment) is the threshold.
+type VSSCommitment []CurvePoint
+
+// SplitSecret shares secret among participants 1..participants with Feldman
+// VSS, so that any threshold of them can reconstruct it while fewer learn
+// nothing about it. The commitment must be published to all participants,
+// each share sent privately to its participant. If rand is nil,
+// crypto/rand.Reader will be used.
+func SplitSecret(rand io.Reader, secret Scalar, threshold, participants int) ([]*VSSShare, VSSCommitment, error) {
+	if threshold < 1 || threshold > participants || participants > 0xffff {
+		return nil, nil, ErrInvalidParticipant
+	}
+	if _, err := ParseScalar(secret); err != nil {
+		return nil, nil, err
+	}
+
+	coefficients := make([]Scalar, threshold)
+	coefficients[0] = append(Scalar(nil), secret...)
+	for k := 1; k < threshold; k++ {
+		coefficient, err := RandomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients[k] = coefficient
+	}
+
+	commitment := make(VSSCommitment, threshold)
+	for k, coefficient := range coefficients {
+		commitment[k] = coefficient.ToCurvePoint()
+	}
+
+	shares := make([]*VSSShare, participants)
+	for j := range shares {
+		index := uint16(j + 1)
+		shares[j] = &VSSShare{
+			Index: index,
+			Value: evaluatePolynomial(coefficients, indexScalar(index)),
+		}
+	}
+
+	for _, coefficient := range coefficients {
+		for i := range coefficient {
+			coefficient[i] = 0
+		}
+	}
+	return shares, commitment, nil
+}
+
+// Verify reports whether share is consistent with the commitment, that is
+// f(i)*G == C_0 + i*C_1 + ... + i^(t-1)*C_t-1. A participant must verify its
+// share, and check that C_0 is the expected public point, before relying on
+// it.
+func (vc VSSCommitment) Verify(share *VSSShare) bool {
+	if len(vc) == 0 || share.Index == 0 {
+		return false
+	}
+	for _, point := range vc {
+		if _, err := ParsePoint(point); err != nil {
+			return false
+		}
+	}
+	if _, err := ParseScalar(share.Value); err != nil {
+		return false
+	}
+	return share.Value.ToCurvePoint().Equal(evaluateCommitments(vc, indexScalar(share.Index)))
+}
+
+// PublicPoint returns C_0, the public point of the shared secret.
+func (vc VSSCommitment) PublicPoint() CurvePoint {
+	return vc[0]
+}
+
+// Bytes returns the encoding C_0 || ... || C_t-1 of the commitment.
+func (vc VSSCommitment) Bytes() []byte {
+	encoded := make([]byte, 0, len(vc)*CurvePointSize)
+	for _, point := range vc {
+		encoded = append(encoded, point...)
+	}
+	return encoded
+}
+
+// ParseVSSCommitment parses the commitment of a VSS with the given threshold,
+// checking all points as ParsePoint does.
+func ParseVSSCommitment(b []byte, threshold int) (VSSCommitment, error) {
+	if threshold < 1 || len(b) != threshold*CurvePointSize {
+		return nil, errors.New("ed25519: bad VSS commitment length")
+	}
+
+	commitment := make(VSSCommitment, threshold)
+	for k := range commitment {
+		point, err := ParsePoint(b[k*CurvePointSize : (k+1)*CurvePointSize])
+		if err != nil {
+			return nil, err
+		}
+		commitment[k] = point
+	}
+	return commitment, nil
+}
+
+// Bytes returns the encoding index || value of the share, with the index in
+// big-endian order.
+func (vs *VSSShare) Bytes() []byte {
+	encoded := make([]byte, 2, VSSShareSize)
+	binary.BigEndian.PutUint16(encoded, vs.Index)
+	return append(encoded, vs.Value...)
+}
+
+// ParseVSSShare parses an encoded share, rejecting the index zero and values
+// that are not reduced modulo l.
+func ParseVSSShare(b []byte) (*VSSShare, error) {
+	if len(b) != VSSShareSize {
+		return nil, errors.New("ed25519: bad VSS share length")
+	}
+
+	index := binary.BigEndian.Uint16(b)
+	if index == 0 {
+		return nil, ErrInvalidParticipant
+	}
+	value, err := ParseScalar(b[2:])
+	if err != nil {
+		return nil, err
+	}
+	return &VSSShare{Index: index, Value: value}, nil
+}
+
+// ReconstructSecret interpolates the secret f(0) from at least threshold
+// shares with distinct indices and checks that it is the discrete logarithm
+// of the public point, for example the adaptor point T. With too few or
+// wrong shares it returns ErrSecretMismatch.
+func ReconstructSecret(shares []*VSSShare, publicPoint CurvePoint) (Scalar, error) {
+	if len(shares) == 0 {
+		return nil, ErrInvalidParticipant
+	}
+
+	indices := make([]uint16, len(shares))
+	seen := make(map[uint16]bool, len(shares))
+	for i, share := range shares {
+		if share.Index == 0 || seen[share.Index] {
+			return nil, ErrInvalidParticipant
+		}
+		if _, err := ParseScalar(share.Value); err != nil {
+			return nil, err
+		}
+		seen[share.Index] = true
+		indices[i] = share.Index
+	}
+
+	secret := Scalar(make([]byte, ScalarSize))
+	for _, share := range shares {
+		secret = secret.Add(lagrangeCoefficient(share.Index, indices).Multiply(share.Value))
+	}
+
+	if !secret.ToCurvePoint().Equal(publicPoint) {
+		return nil, ErrSecretMismatch
+	}
+	return secret, nil
+}
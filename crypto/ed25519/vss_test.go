@@ -0,0 +1,118 @@
+package ed25519
+
+import "testing"
+
+func TestVSS(t *testing.T) {
+	secret := newTestScalar(t)
+	T := secret.ToCurvePoint()
+	shares, commitment, err := SplitSecret(nil, secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 || len(commitment) != 3 || !commitment.PublicPoint().Equal(T) {
+		t.Fatal("SplitSecret returned the wrong shares or commitment")
+	}
+	for _, share := range shares {
+		if !commitment.Verify(share) {
+			t.Fatalf("share %d rejected", share.Index)
+		}
+	}
+
+	for _, subset := range [][]*VSSShare{
+		shares[:3],
+		{shares[4], shares[0], shares[2]},
+		shares,
+	} {
+		reconstructed, err := ReconstructSecret(subset, T)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reconstructed.Equal(secret) {
+			t.Fatalf("%d shares reconstructed the wrong secret", len(subset))
+		}
+	}
+	if _, err := ReconstructSecret(shares[:2], T); err != ErrSecretMismatch {
+		t.Fatalf("two of three shares returned %v, want %v", err, ErrSecretMismatch)
+	}
+}
+
+func TestVSSEncoding(t *testing.T) {
+	shares, commitment, err := SplitSecret(nil, newTestScalar(t), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsedCommitment, err := ParseVSSCommitment(commitment.Bytes(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedShare, err := ParseVSSShare(shares[1].Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedShare.Index != 2 || !parsedCommitment.Verify(parsedShare) {
+		t.Fatal("encoded share and commitment do not round-trip")
+	}
+
+	if _, err := ParseVSSCommitment(commitment.Bytes(), 3); err == nil {
+		t.Fatal("ParseVSSCommitment accepted a commitment of another threshold")
+	}
+	mixed := append(commitment.Bytes()[:CurvePointSize], commitment[1].Add(CurvePoint(decodeHex(t, smallOrderPoint)))...)
+	if _, err := ParseVSSCommitment(mixed, 2); err != ErrMixedOrderPoint {
+		t.Fatalf("ParseVSSCommitment with a mixed-order point returned %v, want %v", err, ErrMixedOrderPoint)
+	}
+
+	zeroIndex := shares[0].Bytes()
+	zeroIndex[0], zeroIndex[1] = 0, 0
+	if _, err := ParseVSSShare(zeroIndex); err != ErrInvalidParticipant {
+		t.Fatalf("ParseVSSShare with index 0 returned %v, want %v", err, ErrInvalidParticipant)
+	}
+	if _, err := ParseVSSShare(append(shares[0].Bytes()[:2], addOrder(shares[0].Value)...)); err != ErrInvalidScalar {
+		t.Fatalf("ParseVSSShare with an unreduced value returned %v, want %v", err, ErrInvalidScalar)
+	}
+	if _, err := ParseVSSShare(shares[0].Bytes()[:VSSShareSize-1]); err == nil {
+		t.Fatal("ParseVSSShare accepted a short share")
+	}
+}
+
+func TestVSSRejects(t *testing.T) {
+	secret := newTestScalar(t)
+	T := secret.ToCurvePoint()
+	shares, commitment, err := SplitSecret(nil, secret, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one := indexScalar(1)
+
+	tests := []struct {
+		name       string
+		share      *VSSShare
+		commitment VSSCommitment
+	}{
+		{"tampered value", &VSSShare{Index: 1, Value: shares[0].Value.Add(one)}, commitment},
+		{"unreduced value", &VSSShare{Index: 1, Value: addOrder(shares[0].Value)}, commitment},
+		{"wrong index", &VSSShare{Index: 2, Value: shares[0].Value}, commitment},
+		{"index zero", &VSSShare{Index: 0, Value: secret}, commitment},
+		{"tampered commitment", shares[0], VSSCommitment{commitment[0], commitment[1].Add(one.ToCurvePoint())}},
+		{"truncated commitment", shares[0], commitment[:1]},
+		{"empty commitment", shares[0], nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.commitment.Verify(test.share) {
+				t.Fatal("Verify accepted an inconsistent share")
+			}
+		})
+	}
+
+	tampered := &VSSShare{Index: 2, Value: shares[1].Value.Add(one)}
+	if _, err := ReconstructSecret([]*VSSShare{shares[0], tampered}, T); err != ErrSecretMismatch {
+		t.Fatalf("ReconstructSecret with a tampered share returned %v, want %v", err, ErrSecretMismatch)
+	}
+	if _, err := ReconstructSecret([]*VSSShare{shares[0], shares[0]}, T); err != ErrInvalidParticipant {
+		t.Fatalf("ReconstructSecret with a duplicate share returned %v, want %v", err, ErrInvalidParticipant)
+	}
+	if _, _, err := SplitSecret(nil, secret, 4, 3); err != ErrInvalidParticipant {
+		t.Fatalf("SplitSecret with threshold above participants returned %v, want %v", err, ErrInvalidParticipant)
+	}
+}
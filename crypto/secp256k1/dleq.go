@@ -0,0 +1,160 @@
+package secp256k1
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// DLEQProofSize is the size, in bytes, of an encoded DLEQ proof.
+const DLEQProofSize = 64
+
+var (
+	dleqNonceTag     = []byte("PTLC/secp256k1/dleq/nonce")
+	dleqChallengeTag = []byte("PTLC/secp256k1/dleq/challenge")
+)
+
+// ErrInvalidDLEQProof is returned when a DLEQ proof encoding is malformed.
+var ErrInvalidDLEQProof = errors.New("secp256k1: invalid DLEQ proof encoding")
+
+// DLEQProof is a non-interactive Chaum-Pedersen proof that two points X1 and
+// X2 have the same discrete logarithm x relative to the bases G1 and G2, that
+// is X1 = x*G1 and X2 = x*G2, without revealing x.
+//
+// It is the secp256k1 counterpart of ed25519.DLEQProof: the prover commits
+// to K1 = k*G1 and K2 = k*G2 and answers the challenge
+// c = H(G1 || X1 || G2 || X2 || K1 || K2) with s = k + c*x.
+type DLEQProof struct {
+	C btcec.ModNScalar
+	S btcec.ModNScalar
+}
+
+// ProveDLEQ proves that X1 = x*G1 and X2 = x*G2 share the discrete logarithm
+// x and returns the proof together with X1 and X2. The nonce is hedged as in
+// ed25519.ProveDLEQ, with a BIP-340 tagged hash instead of SHA-512. If rand
+// is nil, crypto/rand.Reader will be used.
+func ProveDLEQ(rand io.Reader, x *btcec.ModNScalar, G1, G2 *btcec.PublicKey) (proof *DLEQProof, X1, X2 *btcec.PublicKey, err error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if x.IsZero() {
+		return nil, nil, nil, errors.New("secp256k1: DLEQ secret is zero")
+	}
+
+	var entropy [32]byte
+	if _, err := io.ReadFull(rand, entropy[:]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	X1 = secretScalarMultPoint(x, G1)
+	X2 = secretScalarMultPoint(x, G2)
+
+	xBytes := x.Bytes()
+	nonce := chainhash.TaggedHash(dleqNonceTag, xBytes[:], entropy[:], G1.SerializeCompressed(), X1.SerializeCompressed(), G2.SerializeCompressed(), X2.SerializeCompressed())
+	var k btcec.ModNScalar
+	k.SetByteSlice(nonce[:])
+	if k.IsZero() {
+		return nil, nil, nil, errors.New("secp256k1: generated nonce is zero")
+	}
+
+	K1 := secretScalarMultPoint(&k, G1)
+	K2 := secretScalarMultPoint(&k, G2)
+
+	proof = &DLEQProof{C: *dleqChallenge(G1, X1, G2, X2, K1, K2)}
+	proof.S.Mul2(&proof.C, x).Add(&k)
+	k.Zero()
+
+	return proof, X1, X2, nil
+}
+
+// VerifyDLEQ reports whether proof shows that X1 and X2 have the same discrete
+// logarithm relative to the bases G1 and G2.
+func VerifyDLEQ(proof *DLEQProof, G1, X1, G2, X2 *btcec.PublicKey) bool {
+	if proof == nil || G1 == nil || X1 == nil || G2 == nil || X2 == nil {
+		return false
+	}
+
+	var negC btcec.ModNScalar
+	negC.NegateVal(&proof.C)
+
+	K1 := linearCombination(&proof.S, G1, &negC, X1)
+	K2 := linearCombination(&proof.S, G2, &negC, X2)
+	if K1 == nil || K2 == nil {
+		return false
+	}
+
+	return dleqChallenge(G1, X1, G2, X2, K1, K2).Equals(&proof.C)
+}
+
+// Bytes returns the 64-byte encoding c || s of the proof.
+func (proof *DLEQProof) Bytes() []byte {
+	c := proof.C.Bytes()
+	s := proof.S.Bytes()
+
+	encoded := make([]byte, 0, DLEQProofSize)
+	encoded = append(encoded, c[:]...)
+	return append(encoded, s[:]...)
+}
+
+// ParseDLEQProof parses the 64-byte encoding c || s of a DLEQ proof.
+func ParseDLEQProof(b []byte) (*DLEQProof, error) {
+	if len(b) != DLEQProofSize {
+		return nil, ErrInvalidDLEQProof
+	}
+
+	proof := new(DLEQProof)
+	if proof.C.SetByteSlice(b[:32]) || proof.S.SetByteSlice(b[32:]) {
+		return nil, ErrInvalidDLEQProof
+	}
+	return proof, nil
+}
+
+// dleqChallenge returns c = H(G1 || X1 || G2 || X2 || K1 || K2).
+func dleqChallenge(G1, X1, G2, X2, K1, K2 *btcec.PublicKey) *btcec.ModNScalar {
+	challenge := chainhash.TaggedHash(dleqChallengeTag,
+		G1.SerializeCompressed(), X1.SerializeCompressed(),
+		G2.SerializeCompressed(), X2.SerializeCompressed(),
+		K1.SerializeCompressed(), K2.SerializeCompressed())
+
+	var c btcec.ModNScalar
+	c.SetByteSlice(challenge[:])
+	return &c
+}
+
+// secretScalarMultPoint returns k*P in time independent of k. k must be
+// non-zero, so that the result is not the point at infinity.
+func secretScalarMultPoint(k *btcec.ModNScalar, P *btcec.PublicKey) *btcec.PublicKey {
+	var PJacobian, result btcec.JacobianPoint
+	P.AsJacobian(&PJacobian)
+	ScalarMult(k, &PJacobian, &result)
+	return btcec.NewPublicKey(&result.X, &result.Y)
+}
+
+// scalarMultPoint returns k*P. It is not constant time and only used with
+// public scalars.
+func scalarMultPoint(k *btcec.ModNScalar, P *btcec.PublicKey) *btcec.PublicKey {
+	var PJacobian, result btcec.JacobianPoint
+	P.AsJacobian(&PJacobian)
+	btcec.ScalarMultNonConst(k, &PJacobian, &result)
+	result.ToAffine()
+	return btcec.NewPublicKey(&result.X, &result.Y)
+}
+
+// linearCombination returns a*P + b*Q, or nil if the result is the point at
+// infinity.
+func linearCombination(a *btcec.ModNScalar, P *btcec.PublicKey, b *btcec.ModNScalar, Q *btcec.PublicKey) *btcec.PublicKey {
+	var PJacobian, QJacobian, aP, bQ, result btcec.JacobianPoint
+	P.AsJacobian(&PJacobian)
+	Q.AsJacobian(&QJacobian)
+	btcec.ScalarMultNonConst(a, &PJacobian, &aP)
+	btcec.ScalarMultNonConst(b, &QJacobian, &bQ)
+	btcec.AddNonConst(&aP, &bQ, &result)
+	if isInfinity(&result) {
+		return nil
+	}
+	result.ToAffine()
+	return btcec.NewPublicKey(&result.X, &result.Y)
+}
@@ -0,0 +1,273 @@
+package secp256k1
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// ECDSAAdaptorSignatureSize is the size, in bytes, of an encoded ECDSA
+	// adaptor signature.
+	ECDSAAdaptorSignatureSize = 2*btcec.PubKeyBytesLenCompressed + 32 + DLEQProofSize
+	// ECDSASignatureSize is the size, in bytes, of a recoverable ECDSA
+	// signature r || s || v.
+	ECDSASignatureSize = 65
+	// AddressSize is the size, in bytes, of an Ethereum address.
+	AddressSize = 20
+)
+
+var ecdsaNonceTag = []byte("PTLC/secp256k1/ecdsa/nonce")
+
+// ErrInvalidECDSAAdaptorSignature is returned when an ECDSA adaptor signature
+// encoding is malformed.
+var ErrInvalidECDSAAdaptorSignature = errors.New("secp256k1: invalid ECDSA adaptor signature encoding")
+
+// ECDSAAdaptorSignature is an ECDSA pre-signature encrypted under an adaptor
+// point Y = y*G.
+//
+// The signer picks a nonce k and publishes R' = k*G and R = k*Y, together
+// with a DLEQ proof that both share k. The pre-signature is
+// S = k^-1 * (z + r*d) with r = x(R) mod n. Completing it with y gives
+// s = S * y^-1, which is an ordinary ECDSA signature (r, s) with nonce k*y,
+// since R = (k*y)*G.
+type ECDSAAdaptorSignature struct {
+	R      *btcec.PublicKey
+	RPrime *btcec.PublicKey
+	S      btcec.ModNScalar
+	Proof  DLEQProof
+}
+
+// Bytes returns the 162-byte encoding R || R' || S || proof of the adaptor
+// signature, with both points in compressed form.
+func (as *ECDSAAdaptorSignature) Bytes() []byte {
+	encoded := make([]byte, 0, ECDSAAdaptorSignatureSize)
+	encoded = append(encoded, as.R.SerializeCompressed()...)
+	encoded = append(encoded, as.RPrime.SerializeCompressed()...)
+	s := as.S.Bytes()
+	encoded = append(encoded, s[:]...)
+	return append(encoded, as.Proof.Bytes()...)
+}
+
+// ParseECDSAAdaptorSignature parses the 162-byte encoding R || R' || S ||
+// proof of an ECDSA adaptor signature.
+func ParseECDSAAdaptorSignature(b []byte) (*ECDSAAdaptorSignature, error) {
+	if len(b) != ECDSAAdaptorSignatureSize {
+		return nil, ErrInvalidECDSAAdaptorSignature
+	}
+
+	R, err := btcec.ParsePubKey(b[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, err
+	}
+	b = b[btcec.PubKeyBytesLenCompressed:]
+	RPrime, err := btcec.ParsePubKey(b[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, err
+	}
+	b = b[btcec.PubKeyBytesLenCompressed:]
+
+	as := &ECDSAAdaptorSignature{R: R, RPrime: RPrime}
+	if as.S.SetByteSlice(b[:32]) {
+		return nil, ErrInvalidECDSAAdaptorSignature
+	}
+	proof, err := ParseDLEQProof(b[32:])
+	if err != nil {
+		return nil, err
+	}
+	as.Proof = *proof
+	return as, nil
+}
+
+// ECDSAPreSign creates an ECDSA adaptor signature of hash under privKey,
+// encrypted under the adaptor point Y. The nonce is derived as in RFC 6979,
+// with Y and 32 bytes from rand as additional data. If rand is nil,
+// crypto/rand.Reader will be used.
+func ECDSAPreSign(rand io.Reader, privKey *btcec.PrivateKey, hash []byte, Y *btcec.PublicKey) (*ECDSAAdaptorSignature, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if len(hash) != HashSize {
+		return nil, ErrInvalidHash
+	}
+
+	var aux [32]byte
+	if _, err := io.ReadFull(rand, aux[:]); err != nil {
+		return nil, err
+	}
+	extra := chainhash.TaggedHash(ecdsaNonceTag, Y.SerializeCompressed(), aux[:])
+
+	var z btcec.ModNScalar
+	z.SetByteSlice(hash)
+	keyBytes := privKey.Key.Bytes()
+
+	for iteration := uint32(0); ; iteration++ {
+		k := btcec.NonceRFC6979(keyBytes[:], hash, extra[:], nil, iteration)
+
+		proof, RPrime, R, err := ProveDLEQ(rand, k, btcec.Generator(), Y)
+		if err != nil {
+			k.Zero()
+			return nil, err
+		}
+
+		// Require x(R) < n, so that the recovery id of the completed
+		// signature is 0 or 1 as ecrecover expects.
+		r, ok := nonceX(R)
+		if !ok {
+			k.Zero()
+			continue
+		}
+
+		// S = k^-1 * (z + r*d)
+		var kInverse btcec.ModNScalar
+		scalarInverse(&kInverse, k)
+		as := &ECDSAAdaptorSignature{R: R, RPrime: RPrime, Proof: *proof}
+		as.S.Mul2(r, &privKey.Key).Add(&z).Mul(&kInverse)
+		k.Zero()
+		kInverse.Zero()
+		if as.S.IsZero() {
+			continue
+		}
+		return as, nil
+	}
+}
+
+// ECDSAPreVerify reports whether as is a valid ECDSA adaptor signature of hash
+// under pubKey and the adaptor point Y, that is the DLEQ proof shows
+// R' = k*G and R = k*Y, and S*R' == z*G + r*P.
+func ECDSAPreVerify(pubKey *btcec.PublicKey, hash []byte, Y *btcec.PublicKey, as *ECDSAAdaptorSignature) bool {
+	if len(hash) != HashSize || as.R == nil || as.RPrime == nil || as.S.IsZero() {
+		return false
+	}
+	if !VerifyDLEQ(&as.Proof, btcec.Generator(), as.RPrime, Y, as.R) {
+		return false
+	}
+
+	r, ok := nonceX(as.R)
+	if !ok {
+		return false
+	}
+
+	var z btcec.ModNScalar
+	z.SetByteSlice(hash)
+
+	expected := linearCombination(&z, btcec.Generator(), r, pubKey)
+	if expected == nil {
+		return false
+	}
+	return scalarMultPoint(&as.S, as.RPrime).IsEqual(expected)
+}
+
+// ECDSAAdapt completes the adaptor signature with the secret adaptor y and
+// returns the 65-byte recoverable signature r || s || v, where v is the
+// recovery id 0 or 1. s is normalized to the lower half of the group order,
+// as EIP-2 requires; EVM contracts expect v + 27.
+func ECDSAAdapt(as *ECDSAAdaptorSignature, y *btcec.ModNScalar) []byte {
+	r, _ := nonceX(as.R)
+
+	var s btcec.ModNScalar
+	scalarInverse(&s, y)
+	s.Mul(&as.S)
+
+	v := byte(0)
+	if isOdd(as.R) {
+		v = 1
+	}
+	if s.IsOverHalfOrder() {
+		s.Negate()
+		v ^= 1
+	}
+
+	rBytes := r.Bytes()
+	sBytes := s.Bytes()
+	signature := make([]byte, 0, ECDSASignatureSize)
+	signature = append(signature, rBytes[:]...)
+	signature = append(signature, sBytes[:]...)
+	return append(signature, v)
+}
+
+// ECDSAExtract recovers the secret adaptor y of the adaptor point Y from a
+// completed ECDSA signature and the adaptor signature it was adapted from.
+// Because s may have been negated, y is checked against Y.
+func ECDSAExtract(signature []byte, as *ECDSAAdaptorSignature, Y *btcec.PublicKey) (*btcec.ModNScalar, error) {
+	if len(signature) != ECDSASignatureSize {
+		return nil, ErrInvalidSignature
+	}
+
+	r, _ := nonceX(as.R)
+	rBytes := r.Bytes()
+	if string(signature[:32]) != string(rBytes[:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	var s btcec.ModNScalar
+	if s.SetByteSlice(signature[32:64]) || s.IsZero() {
+		return nil, ErrInvalidSignature
+	}
+
+	// y = S * s^-1, up to sign.
+	var y btcec.ModNScalar
+	y.InverseValNonConst(&s).Mul(&as.S)
+	if secretScalarMultPoint(&y, btcec.Generator()).IsEqual(Y) {
+		return &y, nil
+	}
+	y.Negate()
+	if secretScalarMultPoint(&y, btcec.Generator()).IsEqual(Y) {
+		return &y, nil
+	}
+	return nil, ErrInvalidSignature
+}
+
+// Ecrecover recovers the public key that produced the recoverable signature
+// r || s || v of hash, the way the EVM ecrecover precompile does. v may be
+// given either as 0 or 1 or with the offset 27 used by EVM contracts.
+func Ecrecover(hash, signature []byte) (*btcec.PublicKey, error) {
+	if len(hash) != HashSize {
+		return nil, ErrInvalidHash
+	}
+	if len(signature) != ECDSASignatureSize {
+		return nil, ErrInvalidSignature
+	}
+
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	// btcec expects the compact form header || r || s, with the header
+	// 27 + recovery id for uncompressed keys.
+	compact := make([]byte, 0, ECDSASignatureSize)
+	compact = append(compact, 27+v)
+	compact = append(compact, signature[:64]...)
+
+	pubKey, _, err := ecdsa.RecoverCompact(compact, hash)
+	return pubKey, err
+}
+
+// Address returns the Ethereum address of pubKey, the last 20 bytes of the
+// Keccak-256 hash of its uncompressed encoding without the 0x04 prefix.
+func Address(pubKey *btcec.PublicKey) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(pubKey.SerializeUncompressed()[1:])
+	return h.Sum(nil)[32-AddressSize:]
+}
+
+// nonceX returns r = x(R) as a scalar and reports whether x(R) is smaller
+// than the group order and r is non-zero.
+func nonceX(R *btcec.PublicKey) (*btcec.ModNScalar, bool) {
+	var RJacobian btcec.JacobianPoint
+	R.AsJacobian(&RJacobian)
+	xBytes := RJacobian.X.Bytes()
+
+	var r btcec.ModNScalar
+	overflow := r.SetByteSlice(xBytes[:])
+	return &r, !overflow && !r.IsZero()
+}
@@ -0,0 +1,130 @@
+package secp256k1
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/sha3"
+)
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestEcrecoverVector checks Ecrecover and Address against a signature made
+// by go-ethereum's crypto.Sign with the key 0x4646...46 of the EIP-155
+// example.
+func TestEcrecoverVector(t *testing.T) {
+	hash := keccak256([]byte("PTLC/secp256k1/ecrecover"))
+	signature := mustDecodeHex(t, "d71131b7c5a1f95aa34ce00209df077c9f1c98d65e51c9245cfeb95da10ffecc0d28d0006921c092fae53acdeb83b071f89f73a2e16f9bb2d27bfe0de1cfd84700")
+	address := mustDecodeHex(t, "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+
+	for _, v := range []byte{0, 27} {
+		signature[64] = v
+		pubKey, err := Ecrecover(hash, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(Address(pubKey), address) {
+			t.Fatalf("v = %d: recovered %x, want %x", v, Address(pubKey), address)
+		}
+	}
+}
+
+func TestECDSAAdaptorSignature(t *testing.T) {
+	hash := keccak256([]byte("PTLC/secp256k1/test"))
+
+	for i := 0; i < 16; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret := randomScalar(t)
+		Y := btcec.PrivKeyFromScalar(secret).PubKey()
+
+		as, err := ECDSAPreSign(nil, privKey, hash, Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ECDSAPreVerify(privKey.PubKey(), hash, Y, as) {
+			t.Fatal("valid adaptor signature rejected")
+		}
+
+		parsed, err := ParseECDSAAdaptorSignature(as.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed.Bytes(), as.Bytes()) {
+			t.Fatal("encoding does not round-trip")
+		}
+
+		signature := ECDSAAdapt(as, secret)
+		var s btcec.ModNScalar
+		s.SetByteSlice(signature[32:64])
+		if s.IsOverHalfOrder() {
+			t.Fatal("s is not in the lower half of the group order")
+		}
+		if v := signature[64]; v > 1 {
+			t.Fatalf("recovery id %d", v)
+		}
+
+		// The EVM accepts the signature if ecrecover returns the
+		// signer's address, with v offset by 27.
+		signature[64] += 27
+		pubKey, err := Ecrecover(hash, signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(Address(pubKey), Address(privKey.PubKey())) {
+			t.Fatal("ecrecover returned another address")
+		}
+
+		extracted, err := ECDSAExtract(signature, as, Y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !extracted.Equals(secret) {
+			t.Fatal("extracted the wrong secret")
+		}
+	}
+}
+
+func TestECDSAAdaptorSignatureRejects(t *testing.T) {
+	hash := keccak256([]byte("PTLC/secp256k1/test"))
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := randomScalar(t)
+	Y := btcec.PrivKeyFromScalar(secret).PubKey()
+
+	as, err := ECDSAPreSign(nil, privKey, hash, Y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ECDSAPreVerify(privKey.PubKey(), keccak256([]byte("PTLC/secp256k1/other")), Y, as) {
+		t.Fatal("adaptor signature accepted for another message")
+	}
+	if ECDSAPreVerify(privKey.PubKey(), hash, btcec.PrivKeyFromScalar(randomScalar(t)).PubKey(), as) {
+		t.Fatal("adaptor signature accepted for another adaptor point")
+	}
+
+	pubKey, err := Ecrecover(hash, ECDSAAdapt(as, randomScalar(t)))
+	if err == nil && bytes.Equal(Address(pubKey), Address(privKey.PubKey())) {
+		t.Fatal("signature adapted with the wrong secret recovers the signer")
+	}
+}
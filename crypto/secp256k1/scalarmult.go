@@ -0,0 +1,215 @@
+package secp256k1
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// This file implements constant-time counterparts of btcec's
+// ScalarBaseMultNonConst, ScalarMultNonConst and AddNonConst for scalars and
+// points that are secret. Points are kept in homogeneous projective
+// coordinates (X : Y : Z) with x = X/Z and y = Y/Z, where the complete
+// addition formulas of Renes, Costello and Batina ("Complete addition
+// formulas for prime order elliptic curves", algorithm 7) handle doubling and
+// the point at infinity without branches. Scalars are processed in fixed
+// 4-bit windows, and every table entry is read for every window. Secret
+// scalars are inverted with scalarInverse instead of btcec's
+// InverseValNonConst.
+
+// b3 is 3*b for the curve equation y^2 = x^3 + b with b = 7.
+const b3 = 21
+
+// orderMinusTwo is n - 2, the exponent that inverts a scalar by Fermat's
+// little theorem.
+var orderMinusTwo, _ = hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd036413f")
+
+// projectivePoint is a point in homogeneous projective coordinates. The
+// coordinates are always normalized.
+type projectivePoint struct {
+	x, y, z btcec.FieldVal
+}
+
+// ScalarBaseMult sets result to k*G in constant time and returns result in
+// affine coordinates (Z = 1), or as the point at infinity (Z = 0).
+func ScalarBaseMult(k *btcec.ModNScalar, result *btcec.JacobianPoint) {
+	var G btcec.JacobianPoint
+	btcec.GeneratorJacobian(&G)
+	ScalarMult(k, &G, result)
+}
+
+// ScalarMult sets result to k*P in time independent of k and returns result
+// in affine coordinates (Z = 1), or as the point at infinity (Z = 0).
+func ScalarMult(k *btcec.ModNScalar, P, result *btcec.JacobianPoint) {
+	var table [16]projectivePoint
+	table[0].setInfinity()
+	table[1].fromJacobian(P)
+	for i := 2; i < len(table); i++ {
+		table[i].add(&table[i-1], &table[1])
+	}
+
+	var acc, entry projectivePoint
+	acc.setInfinity()
+	for _, b := range k.Bytes() {
+		for _, window := range [2]byte{b >> 4, b & 0x0f} {
+			for i := 0; i < 4; i++ {
+				acc.add(&acc, &acc)
+			}
+			entry.lookup(&table, window)
+			acc.add(&acc, &entry)
+		}
+	}
+	acc.toJacobian(result)
+}
+
+// Add sets result to P + Q in constant time and returns result in affine
+// coordinates (Z = 1), or as the point at infinity (Z = 0). result may alias
+// P or Q.
+func Add(P, Q, result *btcec.JacobianPoint) {
+	var p, q projectivePoint
+	p.fromJacobian(P)
+	q.fromJacobian(Q)
+	p.add(&p, &q)
+	p.toJacobian(result)
+}
+
+// scalarInverse sets result to k^-1 mod n in constant time, by raising k to
+// the power n - 2. The exponent is public, so branching on its bits does not
+// depend on k. The inverse of zero is zero. result may alias k.
+func scalarInverse(result, k *btcec.ModNScalar) {
+	var base, acc btcec.ModNScalar
+	base.Set(k)
+	acc.SetInt(1)
+	for _, b := range orderMinusTwo {
+		for i := 7; i >= 0; i-- {
+			acc.Square()
+			if b>>i&1 == 1 {
+				acc.Mul(&base)
+			}
+		}
+	}
+	result.Set(&acc)
+}
+
+func (p *projectivePoint) setInfinity() {
+	p.x.Zero()
+	p.y.SetInt(1)
+	p.z.Zero()
+}
+
+// fromJacobian sets p to the Jacobian point (X, Y, Z), which is
+// (X*Z : Y : Z^3) in projective coordinates. The point at infinity, which
+// btcec represents with Z = 0 or X = Y = 0, becomes (0 : 1 : 0).
+func (p *projectivePoint) fromJacobian(P *btcec.JacobianPoint) {
+	var x, y, z, z2 btcec.FieldVal
+	x.Set(&P.X).Normalize()
+	y.Set(&P.Y).Normalize()
+	z.Set(&P.Z).Normalize()
+	infinity := z.IsZeroBit() | (x.IsZeroBit() & y.IsZeroBit())
+
+	z2.SquareVal(&z)
+	p.x.Mul2(&x, &z).Normalize()
+	p.y.Set(&y)
+	p.z.Mul2(&z2, &z).Normalize()
+
+	var inf projectivePoint
+	inf.setInfinity()
+	selectField(&p.x, &inf.x, infinity)
+	selectField(&p.y, &inf.y, infinity)
+	selectField(&p.z, &inf.z, infinity)
+}
+
+// toJacobian sets P to p in affine coordinates, or to the point at infinity.
+func (p *projectivePoint) toJacobian(P *btcec.JacobianPoint) {
+	var zInv btcec.FieldVal
+	zInv.Set(&p.z).Inverse()
+	P.X.Mul2(&p.x, &zInv).Normalize()
+	P.Y.Mul2(&p.y, &zInv).Normalize()
+	P.Z.SetInt(uint16(1 ^ p.z.IsZeroBit()))
+}
+
+// lookup sets p to table[index], reading every entry of the table.
+func (p *projectivePoint) lookup(table *[16]projectivePoint, index byte) {
+	p.setInfinity()
+	for i := range table {
+		choose := uint32(subtle.ConstantTimeByteEq(byte(i), index))
+		selectField(&p.x, &table[i].x, choose)
+		selectField(&p.y, &table[i].y, choose)
+		selectField(&p.z, &table[i].z, choose)
+	}
+}
+
+// add sets p to p1 + p2. p may alias p1 or p2.
+func (p *projectivePoint) add(p1, p2 *projectivePoint) {
+	var t0, t1, t2, t3, t4, x3, y3, z3 btcec.FieldVal
+
+	fieldMul(&t0, &p1.x, &p2.x)
+	fieldMul(&t1, &p1.y, &p2.y)
+	fieldMul(&t2, &p1.z, &p2.z)
+	fieldAdd(&t3, &p1.x, &p1.y)
+	fieldAdd(&t4, &p2.x, &p2.y)
+	fieldMul(&t3, &t3, &t4)
+	fieldAdd(&t4, &t0, &t1)
+	fieldSub(&t3, &t3, &t4)
+	fieldAdd(&t4, &p1.y, &p1.z)
+	fieldAdd(&x3, &p2.y, &p2.z)
+	fieldMul(&t4, &t4, &x3)
+	fieldAdd(&x3, &t1, &t2)
+	fieldSub(&t4, &t4, &x3)
+	fieldAdd(&x3, &p1.x, &p1.z)
+	fieldAdd(&y3, &p2.x, &p2.z)
+	fieldMul(&x3, &x3, &y3)
+	fieldAdd(&y3, &t0, &t2)
+	fieldSub(&y3, &x3, &y3)
+	fieldAdd(&x3, &t0, &t0)
+	fieldAdd(&t0, &x3, &t0)
+	fieldMulB3(&t2, &t2)
+	fieldAdd(&z3, &t1, &t2)
+	fieldSub(&t1, &t1, &t2)
+	fieldMulB3(&y3, &y3)
+	fieldMul(&x3, &t4, &y3)
+	fieldMul(&t2, &t3, &t1)
+	fieldSub(&x3, &t2, &x3)
+	fieldMul(&y3, &y3, &t0)
+	fieldMul(&t1, &t1, &z3)
+	fieldAdd(&y3, &t1, &y3)
+	fieldMul(&t0, &t0, &t3)
+	fieldMul(&z3, &z3, &t4)
+	fieldAdd(&z3, &z3, &t0)
+
+	p.x, p.y, p.z = x3, y3, z3
+}
+
+// The field helpers below take and return normalized values, which keeps
+// the magnitudes within the bounds required by btcec.FieldVal.
+
+func fieldMul(result, a, b *btcec.FieldVal) {
+	result.Mul2(a, b).Normalize()
+}
+
+func fieldAdd(result, a, b *btcec.FieldVal) {
+	var sum btcec.FieldVal
+	sum.Set(a).Add(b)
+	result.Set(sum.Normalize())
+}
+
+func fieldSub(result, a, b *btcec.FieldVal) {
+	var difference btcec.FieldVal
+	difference.NegateVal(b, 1).Add(a)
+	result.Set(difference.Normalize())
+}
+
+func fieldMulB3(result, a *btcec.FieldVal) {
+	result.Set(a).MulInt(b3).Normalize()
+}
+
+// selectField sets f to v if choose is 1 and leaves it unchanged if choose
+// is 0, in constant time. Both values must be normalized.
+func selectField(f, v *btcec.FieldVal, choose uint32) {
+	var fBytes, vBytes [32]byte
+	f.PutBytes(&fBytes)
+	v.PutBytes(&vBytes)
+	subtle.ConstantTimeCopy(int(choose), fBytes[:], vBytes[:])
+	f.SetBytes(&fBytes)
+}
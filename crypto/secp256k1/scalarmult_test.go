@@ -0,0 +1,102 @@
+package secp256k1
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func randomScalar(t *testing.T) *btcec.ModNScalar {
+	t.Helper()
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatal(err)
+	}
+	var s btcec.ModNScalar
+	s.SetBytes(&b)
+	return &s
+}
+
+func pointsEqual(P, Q *btcec.JacobianPoint) bool {
+	P.ToAffine()
+	Q.ToAffine()
+	if P.Z.IsZero() || Q.Z.IsZero() {
+		return P.Z.IsZero() == Q.Z.IsZero()
+	}
+	return P.X.Equals(&Q.X) && P.Y.Equals(&Q.Y)
+}
+
+func TestScalarMult(t *testing.T) {
+	var zero, one, minusOne btcec.ModNScalar
+	one.SetInt(1)
+	minusOne.NegateVal(&one)
+	scalars := []*btcec.ModNScalar{&zero, &one, &minusOne}
+	for i := 0; i < 20; i++ {
+		scalars = append(scalars, randomScalar(t))
+	}
+
+	var P btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(randomScalar(t), &P)
+	for _, k := range scalars {
+		var want, got btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(k, &want)
+		ScalarBaseMult(k, &got)
+		if !pointsEqual(&want, &got) {
+			t.Fatalf("ScalarBaseMult(%v) differs from btcec", k)
+		}
+
+		btcec.ScalarMultNonConst(k, &P, &want)
+		ScalarMult(k, &P, &got)
+		if !pointsEqual(&want, &got) {
+			t.Fatalf("ScalarMult(%v) differs from btcec", k)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	var P, Q, negP, infinity btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(randomScalar(t), &P)
+	btcec.ScalarBaseMultNonConst(randomScalar(t), &Q)
+	negP.Set(&P)
+	negP.ToAffine()
+	negP.Y.Negate(1).Normalize()
+
+	tests := []struct {
+		name string
+		P, Q *btcec.JacobianPoint
+	}{
+		{"distinct", &P, &Q},
+		{"double", &P, &P},
+		{"inverse", &P, &negP},
+		{"infinity", &P, &infinity},
+		{"both infinity", &infinity, &infinity},
+	}
+	for _, test := range tests {
+		var want, got btcec.JacobianPoint
+		btcec.AddNonConst(test.P, test.Q, &want)
+		Add(test.P, test.Q, &got)
+		if !pointsEqual(&want, &got) {
+			t.Errorf("%s: Add differs from btcec", test.name)
+		}
+	}
+}
+
+func TestScalarInverse(t *testing.T) {
+	var zero, one, minusOne btcec.ModNScalar
+	one.SetInt(1)
+	minusOne.NegateVal(&one)
+	scalars := []*btcec.ModNScalar{&zero, &one, &minusOne}
+	for i := 0; i < 20; i++ {
+		scalars = append(scalars, randomScalar(t))
+	}
+
+	for _, k := range scalars {
+		var want, got btcec.ModNScalar
+		want.InverseValNonConst(k)
+		scalarInverse(&got, k)
+		if !got.Equals(&want) {
+			t.Fatalf("scalarInverse(%v) differs from btcec", k)
+		}
+	}
+}
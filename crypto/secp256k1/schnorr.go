@@ -0,0 +1,278 @@
+// Package secp256k1 implements adaptor signatures on the secp256k1 curve, so
+// that swaps on Zenon can be paired with chains that verify BIP340 Schnorr or
+// ECDSA signatures.
+//
+// The API mirrors the adaptor signatures of the ed25519 package: PreSign
+// creates a signature encrypted under an adaptor point T, PreVerify checks
+// it, Adapt completes it with the secret t and Extract recovers t from the
+// completed signature. The ECDSA variants, for EVM counterparties that only
+// check signatures with ecrecover, carry an ECDSA prefix.
+//
+// Secret keys, nonces and adaptor secrets are multiplied and inverted in
+// constant time with the functions of scalarmult.go; btcec's NonConst
+// functions are only used to verify, where every input is public.
+package secp256k1
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+const (
+	// HashSize is the size, in bytes, of the message hashes that are signed.
+	HashSize = 32
+	// AdaptorSignatureSize is the size, in bytes, of an encoded Schnorr
+	// adaptor signature.
+	AdaptorSignatureSize = btcec.PubKeyBytesLenCompressed + 32
+)
+
+var (
+	// ErrInvalidHash is returned when the message hash is not HashSize bytes.
+	ErrInvalidHash = errors.New("secp256k1: message hash must be 32 bytes")
+	// ErrInvalidAdaptorSignature is returned when an adaptor signature
+	// encoding is malformed.
+	ErrInvalidAdaptorSignature = errors.New("secp256k1: invalid adaptor signature encoding")
+	// ErrInvalidSignature is returned when a signature encoding is
+	// malformed or does not belong to the adaptor signature.
+	ErrInvalidSignature = errors.New("secp256k1: invalid signature")
+)
+
+// AdaptorSignature is a BIP340 Schnorr pre-signature encrypted under an
+// adaptor point T. R = k*G + T is the final nonce point, including its y
+// parity, and S = k + e*d with the challenge e = H(x(R) || x(P) || m).
+//
+// BIP340 signatures only commit to x(R) and imply an even y coordinate. When
+// R has an odd y coordinate the signer negates k, so the completed signature
+// is (x(R), S - t) instead of (x(R), S + t).
+type AdaptorSignature struct {
+	R *btcec.PublicKey
+	S btcec.ModNScalar
+}
+
+// Bytes returns the 65-byte encoding R || S of the adaptor signature, with R
+// in compressed form.
+func (as *AdaptorSignature) Bytes() []byte {
+	encoded := make([]byte, 0, AdaptorSignatureSize)
+	encoded = append(encoded, as.R.SerializeCompressed()...)
+	s := as.S.Bytes()
+	return append(encoded, s[:]...)
+}
+
+// ParseAdaptorSignature parses the 65-byte encoding R || S of an adaptor
+// signature.
+func ParseAdaptorSignature(b []byte) (*AdaptorSignature, error) {
+	if len(b) != AdaptorSignatureSize {
+		return nil, ErrInvalidAdaptorSignature
+	}
+
+	R, err := btcec.ParsePubKey(b[:btcec.PubKeyBytesLenCompressed])
+	if err != nil {
+		return nil, err
+	}
+
+	as := &AdaptorSignature{R: R}
+	if as.S.SetByteSlice(b[btcec.PubKeyBytesLenCompressed:]) {
+		return nil, ErrInvalidAdaptorSignature
+	}
+	return as, nil
+}
+
+// PreSign creates a BIP340 adaptor signature of hash under privKey, encrypted
+// under the adaptor point T. The nonce is derived as in BIP340 from the key,
+// 32 bytes of auxiliary randomness from rand, T and the message. If rand is
+// nil, crypto/rand.Reader will be used.
+func PreSign(rand io.Reader, privKey *btcec.PrivateKey, hash []byte, T *btcec.PublicKey) (*AdaptorSignature, error) {
+	if rand == nil {
+		rand = cryptorand.Reader
+	}
+	if len(hash) != HashSize {
+		return nil, ErrInvalidHash
+	}
+
+	var aux [32]byte
+	if _, err := io.ReadFull(rand, aux[:]); err != nil {
+		return nil, err
+	}
+
+	// BIP340 public keys have an even y coordinate.
+	d := privKey.Key
+	P := privKey.PubKey()
+	if isOdd(P) {
+		d.Negate()
+	}
+	pBytes := schnorr.SerializePubKey(P)
+
+	k := schnorrNonce(&d, pBytes, T, hash, aux)
+	if k.IsZero() {
+		return nil, errors.New("secp256k1: generated nonce is zero")
+	}
+
+	var kG, TJacobian, R btcec.JacobianPoint
+	ScalarBaseMult(k, &kG)
+	T.AsJacobian(&TJacobian)
+	Add(&kG, &TJacobian, &R)
+	if isInfinity(&R) {
+		return nil, errors.New("secp256k1: nonce point is infinity")
+	}
+	R.ToAffine()
+	if R.Y.IsOdd() {
+		k.Negate()
+	}
+
+	e := schnorrChallenge(&R.X, pBytes, hash)
+
+	as := &AdaptorSignature{R: btcec.NewPublicKey(&R.X, &R.Y)}
+	as.S.Mul2(e, &d).Add(k)
+	return as, nil
+}
+
+// PreVerify reports whether as is a valid adaptor signature of hash under
+// pubKey and the adaptor point T, that is S*G == ±(R - T) + e*P where the
+// sign is that of the y parity of R. It returns false if pubKey, T, as or
+// its R is nil.
+func PreVerify(pubKey *btcec.PublicKey, hash []byte, T *btcec.PublicKey, as *AdaptorSignature) bool {
+	if len(hash) != HashSize || pubKey == nil || T == nil || as == nil || as.R == nil {
+		return false
+	}
+
+	pBytes := schnorr.SerializePubKey(pubKey)
+	P, err := schnorr.ParsePubKey(pBytes)
+	if err != nil {
+		return false
+	}
+
+	var R, TJacobian, PJacobian btcec.JacobianPoint
+	as.R.AsJacobian(&R)
+	T.AsJacobian(&TJacobian)
+	P.AsJacobian(&PJacobian)
+	e := schnorrChallenge(&R.X, pBytes, hash)
+
+	// R' = ±(R - T)
+	var nonce btcec.JacobianPoint
+	negate(&TJacobian)
+	btcec.AddNonConst(&R, &TJacobian, &nonce)
+	if R.Y.IsOdd() {
+		negate(&nonce)
+	}
+
+	var expected, eP, sG btcec.JacobianPoint
+	btcec.ScalarMultNonConst(e, &PJacobian, &eP)
+	btcec.AddNonConst(&nonce, &eP, &expected)
+	btcec.ScalarBaseMultNonConst(&as.S, &sG)
+
+	return equal(&sG, &expected)
+}
+
+// Adapt completes the adaptor signature with the secret adaptor t and returns
+// the 64-byte BIP340 signature accepted by schnorr.Signature.Verify.
+func Adapt(as *AdaptorSignature, t *btcec.ModNScalar) []byte {
+	var R btcec.JacobianPoint
+	as.R.AsJacobian(&R)
+
+	var s btcec.ModNScalar
+	if R.Y.IsOdd() {
+		var negT btcec.ModNScalar
+		negT.NegateVal(t)
+		s.Add2(&as.S, &negT)
+	} else {
+		s.Add2(&as.S, t)
+	}
+
+	return schnorr.NewSignature(&R.X, &s).Serialize()
+}
+
+// Extract recovers the secret adaptor t of the adaptor point T from a
+// completed BIP340 signature and the adaptor signature it was adapted from.
+// It returns ErrInvalidSignature unless the signature commits to R and
+// t*G == T.
+func Extract(signature []byte, as *AdaptorSignature, T *btcec.PublicKey) (*btcec.ModNScalar, error) {
+	if len(signature) != schnorr.SignatureSize || as == nil || as.R == nil || T == nil {
+		return nil, ErrInvalidSignature
+	}
+
+	var R btcec.JacobianPoint
+	as.R.AsJacobian(&R)
+	rBytes := R.X.Bytes()
+	if string(signature[:32]) != string(rBytes[:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	var s btcec.ModNScalar
+	if s.SetByteSlice(signature[32:]) {
+		return nil, ErrInvalidSignature
+	}
+
+	var t, negPre btcec.ModNScalar
+	if R.Y.IsOdd() {
+		// s = S - t
+		s.Negate()
+		t.Add2(&as.S, &s)
+	} else {
+		// s = S + t
+		negPre.NegateVal(&as.S)
+		t.Add2(&s, &negPre)
+	}
+	if !secretScalarMultPoint(&t, btcec.Generator()).IsEqual(T) {
+		return nil, ErrInvalidSignature
+	}
+	return &t, nil
+}
+
+// schnorrNonce derives the nonce k = H_nonce((d xor H_aux(aux)) || P || T || m)
+// following BIP340, with the adaptor point committed to as well.
+func schnorrNonce(d *btcec.ModNScalar, pBytes []byte, T *btcec.PublicKey, hash []byte, aux [32]byte) *btcec.ModNScalar {
+	dBytes := d.Bytes()
+	masked := chainhash.TaggedHash(chainhash.TagBIP0340Aux, aux[:])
+	for i := range dBytes {
+		dBytes[i] ^= masked[i]
+	}
+
+	nonce := chainhash.TaggedHash(chainhash.TagBIP0340Nonce, dBytes[:], pBytes, T.SerializeCompressed(), hash)
+
+	var k btcec.ModNScalar
+	k.SetByteSlice(nonce[:])
+	return &k
+}
+
+// schnorrChallenge returns e = H_challenge(x(R) || x(P) || m).
+func schnorrChallenge(rX *btcec.FieldVal, pBytes []byte, hash []byte) *btcec.ModNScalar {
+	rBytes := rX.Bytes()
+	challenge := chainhash.TaggedHash(chainhash.TagBIP0340Challenge, rBytes[:], pBytes, hash)
+
+	var e btcec.ModNScalar
+	e.SetByteSlice(challenge[:])
+	return &e
+}
+
+// isOdd reports whether the y coordinate of pubKey is odd.
+func isOdd(pubKey *btcec.PublicKey) bool {
+	var P btcec.JacobianPoint
+	pubKey.AsJacobian(&P)
+	return P.Y.IsOdd()
+}
+
+// isInfinity reports whether p is the point at infinity.
+func isInfinity(p *btcec.JacobianPoint) bool {
+	return (p.X.IsZero() && p.Y.IsZero()) || p.Z.IsZero()
+}
+
+// negate negates p in place.
+func negate(p *btcec.JacobianPoint) {
+	p.ToAffine()
+	p.Y.Negate(1).Normalize()
+}
+
+// equal reports whether p and q are the same point.
+func equal(p, q *btcec.JacobianPoint) bool {
+	if isInfinity(p) || isInfinity(q) {
+		return isInfinity(p) && isInfinity(q)
+	}
+	p.ToAffine()
+	q.ToAffine()
+	return p.X.Equals(&q.X) && p.Y.Equals(&q.Y)
+}
@@ -0,0 +1,149 @@
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+func TestAdaptorSignature(t *testing.T) {
+	hash := sha256.Sum256([]byte("PTLC/secp256k1/test"))
+
+	// Cover both parities of R and of the public key.
+	var oddR, evenR bool
+	for i := 0; i < 32 || !oddR || !evenR; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret := randomScalar(t)
+		T := btcec.PrivKeyFromScalar(secret).PubKey()
+
+		as, err := PreSign(nil, privKey, hash[:], T)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isOdd(as.R) {
+			oddR = true
+		} else {
+			evenR = true
+		}
+		if !PreVerify(privKey.PubKey(), hash[:], T, as) {
+			t.Fatal("valid adaptor signature rejected")
+		}
+
+		parsed, err := ParseAdaptorSignature(as.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed.Bytes(), as.Bytes()) {
+			t.Fatal("encoding does not round-trip")
+		}
+
+		signature, err := schnorr.ParseSignature(Adapt(as, secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !signature.Verify(hash[:], privKey.PubKey()) {
+			t.Fatal("adapted signature rejected by schnorr.Signature.Verify")
+		}
+
+		extracted, err := Extract(signature.Serialize(), as, T)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !extracted.Equals(secret) {
+			t.Fatal("extracted the wrong secret")
+		}
+	}
+}
+
+func TestAdaptorSignatureRejects(t *testing.T) {
+	hash := sha256.Sum256([]byte("PTLC/secp256k1/test"))
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := randomScalar(t)
+	T := btcec.PrivKeyFromScalar(secret).PubKey()
+
+	as, err := PreSign(nil, privKey, hash[:], T)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := sha256.Sum256([]byte("PTLC/secp256k1/other"))
+	if PreVerify(privKey.PubKey(), other[:], T, as) {
+		t.Fatal("adaptor signature accepted for another message")
+	}
+	if PreVerify(privKey.PubKey(), hash[:], btcec.PrivKeyFromScalar(randomScalar(t)).PubKey(), as) {
+		t.Fatal("adaptor signature accepted for another adaptor point")
+	}
+
+	if PreVerify(nil, hash[:], T, as) {
+		t.Fatal("adaptor signature accepted for a nil public key")
+	}
+	if PreVerify(privKey.PubKey(), hash[:], nil, as) {
+		t.Fatal("adaptor signature accepted for a nil adaptor point")
+	}
+	if PreVerify(privKey.PubKey(), hash[:], T, nil) {
+		t.Fatal("nil adaptor signature accepted")
+	}
+	if PreVerify(privKey.PubKey(), hash[:], T, &AdaptorSignature{S: as.S}) {
+		t.Fatal("adaptor signature without R accepted")
+	}
+
+	signature, err := schnorr.ParseSignature(Adapt(as, randomScalar(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature.Verify(hash[:], privKey.PubKey()) {
+		t.Fatal("signature adapted with the wrong secret accepted")
+	}
+
+	// A signature with the x(R) of the adaptor signature but another s
+	// does not reveal the secret of T.
+	forged := Adapt(as, randomScalar(t))
+	if _, err := Extract(forged, as, T); err != ErrInvalidSignature {
+		t.Fatalf("Extract of a forged signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+	if _, err := Extract(Adapt(as, secret), as, btcec.PrivKeyFromScalar(randomScalar(t)).PubKey()); err != ErrInvalidSignature {
+		t.Fatalf("Extract for another adaptor point: got %v, want %v", err, ErrInvalidSignature)
+	}
+	if _, err := Extract(Adapt(as, secret)[:63], as, T); err != ErrInvalidSignature {
+		t.Fatalf("Extract of a short signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+
+	if _, err := PreSign(nil, privKey, hash[:31], T); err != ErrInvalidHash {
+		t.Fatalf("PreSign of a short hash: got %v, want %v", err, ErrInvalidHash)
+	}
+}
+
+func TestPreSignRand(t *testing.T) {
+	hash := sha256.Sum256([]byte("PTLC/secp256k1/test"))
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := btcec.PrivKeyFromScalar(randomScalar(t)).PubKey()
+
+	aux := bytes.Repeat([]byte{1}, 32)
+	as1, err := PreSign(bytes.NewReader(aux), privKey, hash[:], T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	as2, err := PreSign(bytes.NewReader(aux), privKey, hash[:], T)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(as1.Bytes(), as2.Bytes()) {
+		t.Fatal("the same auxiliary randomness gave different signatures")
+	}
+
+	if _, err := PreSign(bytes.NewReader(nil), privKey, hash[:], T); err == nil {
+		t.Fatal("PreSign succeeded without randomness")
+	}
+}
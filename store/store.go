@@ -0,0 +1,218 @@
+// Package store keeps the journals of swaps in a goleveldb database, so that
+// a swap can be resumed from its last committed state after the process
+// restarted.
+//
+// Every commit of a swap is stored under its own key, address || account ||
+// index || sequence number, so the database holds the full history of each
+// swap and the last key of a swap holds its current state. The values are
+// the state followed by the snapshot of the party, which includes its
+// secrets, so the database must be protected like the wallet.
+//
+// The store also allocates the indexes from which the keys of new swaps are
+// derived, with a counter per address and account.
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// prefixSwap is the prefix of the keys of swap journals.
+const prefixSwap = 's'
+
+// prefixIndex is the prefix of the keys of the swap index counters.
+const prefixIndex = 'i'
+
+// swapKeySize is the size of a key without the sequence number.
+const swapKeySize = 1 + types.AddressSize + 4 + 4
+
+var (
+	// ErrNotFound is returned when the store holds no journal for a swap.
+	ErrNotFound = errors.New("store: swap not found")
+	// ErrCorrupt is returned for entries that cannot be decoded.
+	ErrCorrupt = errors.New("store: corrupt entry")
+)
+
+// Store is a database of swap journals.
+type Store struct {
+	db *leveldb.DB
+
+	// mu serializes the allocation of swap indexes.
+	mu sync.Mutex
+}
+
+// Entry is a committed state of a swap.
+type Entry struct {
+	Account  uint32
+	Index    uint32
+	Sequence uint64
+	State    swap.State
+	Snapshot []byte
+}
+
+// Open opens the store at path, creating it if it does not exist.
+func Open(path string) (*Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// NextIndex allocates the index of a new swap of the party with the given
+// address and account. Indexes are handed out in order from a counter that
+// is synced to disk before NextIndex returns, skipping indexes that already
+// have a journal, so that the keys of a swap are never derived for a second
+// one.
+func (s *Store) NextIndex(address types.Address, account uint32) (uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := indexKey(address, account)
+	var index uint32
+	value, err := s.db.Get(key, nil)
+	switch err {
+	case nil:
+		if len(value) != 4 {
+			return 0, ErrCorrupt
+		}
+		index = binary.BigEndian.Uint32(value)
+	case leveldb.ErrNotFound:
+	default:
+		return 0, err
+	}
+
+	for {
+		_, err := s.Last(address, account, index)
+		if err == ErrNotFound {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		index++
+	}
+
+	if err := s.db.Put(key, binary.BigEndian.AppendUint32(nil, index+1), &opt.WriteOptions{Sync: true}); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// Journal returns the journal of the swap of the party with the given
+// address whose keys were derived with account and index. Its commits are
+// appended after the entries already stored for the swap, and every commit
+// is synced to disk before it returns. Only one journal of a swap may be used
+// at a time.
+func (s *Store) Journal(address types.Address, account, index uint32) (swap.Journal, error) {
+	j := &journal{db: s.db, key: swapKey(address, account, index)}
+	last, err := s.Last(address, account, index)
+	switch err {
+	case nil:
+		j.sequence = last.Sequence + 1
+	case ErrNotFound:
+	default:
+		return nil, err
+	}
+	return j, nil
+}
+
+// Last returns the last committed state of the swap of the party with the
+// given address whose keys were derived with account and index.
+func (s *Store) Last(address types.Address, account, index uint32) (*Entry, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(swapKey(address, account, index)), nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+	return decodeEntry(iter.Key(), iter.Value())
+}
+
+// Unfinished returns the last committed state of every swap of the party
+// with the given address that has not ended yet, as told by swap.Ended.
+// This includes aborted swaps whose PTLC still has to be reclaimed.
+func (s *Store) Unfinished(address types.Address) ([]*Entry, error) {
+	prefix := append([]byte{prefixSwap}, address.Bytes()...)
+	iter := s.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	var unfinished []*Entry
+	var last *Entry
+	for iter.Next() {
+		entry, err := decodeEntry(iter.Key(), iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && (last.Account != entry.Account || last.Index != entry.Index) && !swap.Ended(last.State, last.Snapshot) {
+			unfinished = append(unfinished, last)
+		}
+		last = entry
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if last != nil && !swap.Ended(last.State, last.Snapshot) {
+		unfinished = append(unfinished, last)
+	}
+	return unfinished, nil
+}
+
+// journal appends the commits of one swap to the database.
+type journal struct {
+	db       *leveldb.DB
+	key      []byte
+	sequence uint64
+}
+
+func (j *journal) Commit(state swap.State, snapshot []byte) error {
+	key := binary.BigEndian.AppendUint64(append([]byte(nil), j.key...), j.sequence)
+	value := append([]byte{byte(state)}, snapshot...)
+	if err := j.db.Put(key, value, &opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+	j.sequence++
+	return nil
+}
+
+// swapKey returns the key prefix of the entries of a swap.
+func swapKey(address types.Address, account, index uint32) []byte {
+	key := append([]byte{prefixSwap}, address.Bytes()...)
+	key = binary.BigEndian.AppendUint32(key, account)
+	return binary.BigEndian.AppendUint32(key, index)
+}
+
+// indexKey returns the key of the index counter of an address and account.
+func indexKey(address types.Address, account uint32) []byte {
+	key := append([]byte{prefixIndex}, address.Bytes()...)
+	return binary.BigEndian.AppendUint32(key, account)
+}
+
+// decodeEntry decodes a stored key and value.
+func decodeEntry(key, value []byte) (*Entry, error) {
+	if len(key) != swapKeySize+8 || len(value) < 1 {
+		return nil, ErrCorrupt
+	}
+	return &Entry{
+		Account:  binary.BigEndian.Uint32(key[1+types.AddressSize:]),
+		Index:    binary.BigEndian.Uint32(key[1+types.AddressSize+4:]),
+		Sequence: binary.BigEndian.Uint64(key[swapKeySize:]),
+		State:    swap.State(value[0]),
+		Snapshot: append([]byte(nil), value[1:]...),
+	}, nil
+}
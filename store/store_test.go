@@ -0,0 +1,428 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+const testChainIdentifier = 3
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func newTestScalar(t *testing.T) ed25519.Scalar {
+	t.Helper()
+	s, err := ed25519.RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestPtlc returns a PTLC id derived from name.
+func newTestPtlc(name string) types.Hash {
+	return types.Hash(sha256.Sum256([]byte(name)))
+}
+
+// attach attaches the journal of the swap with the given index to party.
+func attach(t *testing.T, s *Store, party interface{ SetJournal(swap.Journal) error }, address types.Address, index uint32) {
+	t.Helper()
+	journal, err := s.Journal(address, 0, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := party.SetJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestSwap starts a swap between a new initiator and responder whose
+// journals are kept in s, and runs it until both PTLCs are funded.
+func newTestSwap(t *testing.T, s *Store) (initiator *swap.Initiator, responder *swap.Responder, addressA, addressB types.Address) {
+	t.Helper()
+	addressA = types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+	addressB = types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+	keysA := &swap.Keys{Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+	keysB := &swap.Keys{Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+	initiator, err := swap.NewInitiator(nil, keysA, addressA, testChainIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder = swap.NewResponder(nil, keysB, addressB, testChainIdentifier)
+	attach(t, s, initiator, addressA, 0)
+	attach(t, s, responder, addressB, 0)
+
+	ke, err := responder.ReceiveKeyExchange(addressA, initiator.KeyExchange())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := initiator.ReceiveKeyExchange(addressB, ke); err != nil {
+		t.Fatal(err)
+	}
+	ptlc1, ptlc2 := newTestPtlc("PTLC1"), newTestPtlc("PTLC2")
+	if err := initiator.Created(ptlc1); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.Created(ptlc2); err != nil {
+		t.Fatal(err)
+	}
+	if err := initiator.Funded(ptlc1, ptlc2); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.Funded(ptlc2, ptlc1); err != nil {
+		t.Fatal(err)
+	}
+	return initiator, responder, addressA, addressB
+}
+
+// resume restores both parties of the swap from their last commits.
+func resume(t *testing.T, s *Store, addressA, addressB types.Address) (*swap.Initiator, *swap.Responder) {
+	t.Helper()
+	entryA, err := s.Last(addressA, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryB, err := s.Last(addressB, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initiator, err := swap.ResumeInitiator(nil, entryA.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder, err := swap.ResumeResponder(nil, entryB.Snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initiator.State() != entryA.State || responder.State() != entryB.State {
+		t.Fatal("resumed parties are not in their committed states")
+	}
+	attach(t, s, initiator, addressA, 0)
+	attach(t, s, responder, addressB, 0)
+	return initiator, responder
+}
+
+// finish exchanges the partial signatures if the initiator has not sent its
+// own yet, and claims both PTLCs.
+func finish(t *testing.T, initiator *swap.Initiator, responder *swap.Responder, sa1, sa2 ed25519.Scalar, addressA, addressB types.Address) {
+	t.Helper()
+	var err error
+	if sa1 == nil {
+		if sa1, sa2, err = initiator.PartialSignatures(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sb1, err := responder.ReceivePartialSignatures(sa1, sa2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimA, err := initiator.ReceivePartialSignature(sb1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimB, err := responder.ReceiveSignature(claimA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ptlc1, ptlc2 := initiator.Ptlcs()
+	if !ed25519.Verify(initiator.CounterpartyLockKey(), swap.UnlockMessage(swap.MessageContract, testChainIdentifier, ptlc2, addressA), claimA) {
+		t.Fatal("claim signature of PTLC2 does not verify")
+	}
+	if !ed25519.Verify(responder.CounterpartyLockKey(), swap.UnlockMessage(swap.MessageContract, testChainIdentifier, ptlc1, addressB), claimB) {
+		t.Fatal("claim signature of PTLC1 does not verify")
+	}
+	if err := initiator.Claimed(); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.Claimed(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJournalLast(t *testing.T) {
+	s := newTestStore(t)
+	address := types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+	if _, err := s.Last(address, 0, 7); err != ErrNotFound {
+		t.Fatalf("Last of an unknown swap returned %v, want %v", err, ErrNotFound)
+	}
+
+	journal, err := s.Journal(address, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, state := range []swap.State{swap.StateNew, swap.StateKeysExchanged} {
+		if err := journal.Commit(state, []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last, err := s.Last(address, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Account != 0 || last.Index != 7 || last.Sequence != 1 || last.State != swap.StateKeysExchanged || !bytes.Equal(last.Snapshot, []byte{1}) {
+		t.Fatalf("Last returned %+v", last)
+	}
+
+	// A journal opened again appends after the stored commits.
+	journal, err = s.Journal(address, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Commit(swap.StatePtlcFunded, []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if last, err = s.Last(address, 0, 7); err != nil || last.Sequence != 2 || last.State != swap.StatePtlcFunded {
+		t.Fatalf("Last after reopening the journal returned %+v, %v", last, err)
+	}
+	if _, err := s.Last(address, 1, 7); err != ErrNotFound {
+		t.Fatalf("Last of another account returned %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestNextIndex(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+
+	if index, err := s.NextIndex(address, 0); err != nil || index != 0 {
+		t.Fatalf("first NextIndex returned %d, %v", index, err)
+	}
+	// Swaps 1 and 2 were journaled without an allocated index.
+	for _, index := range []uint32{1, 2} {
+		journal, err := s.Journal(address, 0, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := journal.Commit(swap.StateNew, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if index, err := s.NextIndex(address, 0); err != nil || index != 3 {
+		t.Fatalf("NextIndex returned %d, %v, want 3", index, err)
+	}
+	if index, err := s.NextIndex(address, 1); err != nil || index != 0 {
+		t.Fatalf("NextIndex of another account returned %d, %v, want 0", index, err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Open(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if index, err := s.NextIndex(address, 0); err != nil || index != 4 {
+		t.Fatalf("NextIndex after reopening returned %d, %v, want 4", index, err)
+	}
+}
+
+func TestUnfinished(t *testing.T) {
+	s := newTestStore(t)
+	address := types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+
+	// newInitiator starts swap index of address, with its keys exchanged if
+	// exchanged is set.
+	newInitiator := func(index uint32, exchanged bool) *swap.Initiator {
+		keys := &swap.Keys{Index: index, Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+		initiator, err := swap.NewInitiator(nil, keys, address, testChainIdentifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attach(t, s, initiator, address, index)
+		if exchanged {
+			counterparty := types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+			keys := &swap.Keys{Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+			responder := swap.NewResponder(nil, keys, counterparty, testChainIdentifier)
+			ke, err := responder.ReceiveKeyExchange(address, initiator.KeyExchange())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := initiator.ReceiveKeyExchange(counterparty, ke); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return initiator
+	}
+
+	// Swap 0 is still running.
+	newInitiator(0, false)
+	// Swap 1 was aborted before its PTLC was created.
+	if err := newInitiator(1, true).Abort(); err != nil {
+		t.Fatal(err)
+	}
+	// Swap 2 was aborted after its PTLC was created, which must be reclaimed.
+	aborted := newInitiator(2, true)
+	if err := aborted.Created(newTestPtlc("PTLC1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := aborted.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	// Swap 3 was refunded after it was aborted.
+	refunded := newInitiator(3, true)
+	if err := refunded.Created(newTestPtlc("PTLC1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := refunded.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	if err := refunded.Refunded(); err != nil {
+		t.Fatal(err)
+	}
+	// Swaps of other addresses are not listed.
+	newTestSwap(t, s)
+
+	unfinished, err := s.Unfinished(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unfinished) != 2 || unfinished[0].Index != 0 || unfinished[1].Index != 2 {
+		t.Fatalf("Unfinished returned %d swaps, want swaps 0 and 2", len(unfinished))
+	}
+	if unfinished[0].State != swap.StateNew || unfinished[1].State != swap.StateAborted {
+		t.Fatalf("Unfinished returned states %v and %v", unfinished[0].State, unfinished[1].State)
+	}
+}
+
+func TestResumeBeforeSigning(t *testing.T) {
+	s := newTestStore(t)
+	_, _, addressA, addressB := newTestSwap(t, s)
+	initiator, responder := resume(t, s, addressA, addressB)
+
+	// The secret nonces were not journaled, so the resumed parties cannot
+	// sign until they exchanged fresh ones.
+	if _, _, err := initiator.PartialSignatures(); err != ed25519.ErrNonceReused {
+		t.Fatalf("PartialSignatures without nonces returned %v, want %v", err, ed25519.ErrNonceReused)
+	}
+	if err := responder.ReceiveNonces(initiator.KeyExchange()); err != swap.ErrInvalidState {
+		t.Fatalf("ReceiveNonces before RefreshNonces returned %v, want %v", err, swap.ErrInvalidState)
+	}
+
+	keA, err := initiator.RefreshNonces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keB, err := responder.RefreshNonces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !initiator.Resumes(keB) || !responder.Resumes(keA) {
+		t.Fatal("refreshed key exchange messages do not resume the swap")
+	}
+	if err := initiator.ReceiveNonces(keB); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.ReceiveNonces(keA); err != nil {
+		t.Fatal(err)
+	}
+	finish(t, initiator, responder, nil, nil, addressA, addressB)
+
+	for _, address := range []types.Address{addressA, addressB} {
+		last, err := s.Last(address, 0, 0)
+		if err != nil || last.State != swap.StateClaimed {
+			t.Fatalf("last commit is %+v, %v", last, err)
+		}
+	}
+}
+
+func TestResumeWhileRefreshing(t *testing.T) {
+	s := newTestStore(t)
+	initiator, _, addressA, addressB := newTestSwap(t, s)
+	if _, err := initiator.RefreshNonces(); err != nil {
+		t.Fatal(err)
+	}
+	initiator, responder := resume(t, s, addressA, addressB)
+
+	// The resumed initiator still waits for the responder's nonces.
+	if _, _, err := initiator.PartialSignatures(); err != swap.ErrInvalidState {
+		t.Fatalf("PartialSignatures while refreshing returned %v, want %v", err, swap.ErrInvalidState)
+	}
+	keB, err := responder.RefreshNonces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := initiator.ReceiveNonces(keB); err != nil {
+		t.Fatalf("ReceiveNonces after resuming while refreshing returned %v", err)
+	}
+
+	// Its refreshed secret nonces were lost, so both parties refresh again.
+	keA, err := initiator.RefreshNonces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keB, err = responder.RefreshNonces(); err != nil {
+		t.Fatal(err)
+	}
+	if err := initiator.ReceiveNonces(keB); err != nil {
+		t.Fatal(err)
+	}
+	if err := responder.ReceiveNonces(keA); err != nil {
+		t.Fatal(err)
+	}
+	finish(t, initiator, responder, nil, nil, addressA, addressB)
+}
+
+func TestResumeAfterSigning(t *testing.T) {
+	s := newTestStore(t)
+	initiator, responder, addressA, addressB := newTestSwap(t, s)
+	sa1, sa2, err := initiator.PartialSignatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the initiator restarts: the responder's nonces are bound to the
+	// partial signatures the initiator already sent.
+	entry, err := s.Last(addressA, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initiator, err = swap.ResumeInitiator(nil, entry.Snapshot); err != nil {
+		t.Fatal(err)
+	}
+	attach(t, s, initiator, addressA, 0)
+
+	// The initiator signed before the restart and must not sign again.
+	if _, err := initiator.RefreshNonces(); err != swap.ErrInvalidState {
+		t.Fatalf("RefreshNonces after signing returned %v, want %v", err, swap.ErrInvalidState)
+	}
+	if _, _, err := initiator.PartialSignatures(); err != swap.ErrInvalidState {
+		t.Fatalf("second PartialSignatures returned %v, want %v", err, swap.ErrInvalidState)
+	}
+	finish(t, initiator, responder, sa1, sa2, addressA, addressB)
+}
+
+func TestReceiveNoncesRejectsChangedKeys(t *testing.T) {
+	s := newTestStore(t)
+	initiator, _, addressA, addressB := newTestSwap(t, s)
+	if _, err := initiator.RefreshNonces(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &swap.Keys{Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+	other := swap.NewResponder(nil, keys, addressB, testChainIdentifier)
+	ke, err := other.ReceiveKeyExchange(addressA, initiator.KeyExchange())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if initiator.Resumes(ke) {
+		t.Fatal("key exchange message with other keys resumes the swap")
+	}
+	if err := initiator.ReceiveNonces(ke); err != swap.ErrKeysChanged {
+		t.Fatalf("ReceiveNonces with other keys returned %v, want %v", err, swap.ErrKeysChanged)
+	}
+}
@@ -0,0 +1,184 @@
+package swap
+
+import (
+	"errors"
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+var keyExchangeContext = []byte("PTLC/zenon/key-exchange")
+
+// ErrInvalidProofOfPossession is returned when a key of a key exchange comes
+// without a valid proof that its sender knows the discrete logarithm.
+var ErrInvalidProofOfPossession = errors.New("swap: invalid proof of possession")
+
+// KeyExchange is the message in which a party of a swap announces its public
+// keys, its adaptor point if it is the initiator, and its public nonces. Every
+// point the party knows the discrete logarithm of comes with a proof of
+// possession bound to the party's address and the slot of the key.
+//
+// The public nonces carry no proof: the binding factor of MuSig2 already
+// prevents a counterparty from choosing its nonces as a function of ours.
+type KeyExchange struct {
+	PublicKey1   ed25519.PublicKey
+	PublicKey2   ed25519.PublicKey
+	AdaptorPoint ed25519.CurvePoint
+	Nonce1       ed25519.PublicNonce
+	Nonce2       ed25519.PublicNonce
+	Proof1       *ed25519.ProofOfPossession
+	Proof2       *ed25519.ProofOfPossession
+	AdaptorProof *ed25519.ProofOfPossession
+}
+
+// NewKeyExchange creates the key exchange message of the party with the
+// given address for its keys and public nonces. The adaptor point and its
+// proof are included only if initiator is set. If rand is nil,
+// crypto/rand.Reader will be used.
+func NewKeyExchange(rand io.Reader, keys *Keys, address types.Address, initiator bool, nonce1, nonce2 ed25519.PublicNonce) (*KeyExchange, error) {
+	proof1, A1, err := ed25519.ProvePossession(rand, keys.Key1, possessionContext(address, slotKey1))
+	if err != nil {
+		return nil, err
+	}
+	proof2, A2, err := ed25519.ProvePossession(rand, keys.Key2, possessionContext(address, slotKey2))
+	if err != nil {
+		return nil, err
+	}
+
+	ke := &KeyExchange{
+		PublicKey1: ed25519.PublicKey(A1),
+		PublicKey2: ed25519.PublicKey(A2),
+		Nonce1:     nonce1,
+		Nonce2:     nonce2,
+		Proof1:     proof1,
+		Proof2:     proof2,
+	}
+	if initiator {
+		ke.AdaptorProof, ke.AdaptorPoint, err = ed25519.ProvePossession(rand, keys.Adaptor, possessionContext(address, slotAdaptor))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ke, nil
+}
+
+// Verify checks the key exchange message received from the party with the
+// given address: all points must be valid and every key must come with a
+// valid proof of possession. The adaptor point is required only if the
+// sender is the initiator. A party must abort the swap before funding its
+// PTLC if Verify fails.
+func (ke *KeyExchange) Verify(address types.Address, initiator bool) error {
+	if _, err := ed25519.ParsePublicKey(ke.PublicKey1); err != nil {
+		return err
+	}
+	if _, err := ed25519.ParsePublicKey(ke.PublicKey2); err != nil {
+		return err
+	}
+	if _, err := ed25519.ParsePublicNonce(ke.Nonce1); err != nil {
+		return err
+	}
+	if _, err := ed25519.ParsePublicNonce(ke.Nonce2); err != nil {
+		return err
+	}
+
+	if !ed25519.VerifyPossession(ke.Proof1, ed25519.CurvePoint(ke.PublicKey1), possessionContext(address, slotKey1)) {
+		return ErrInvalidProofOfPossession
+	}
+	if !ed25519.VerifyPossession(ke.Proof2, ed25519.CurvePoint(ke.PublicKey2), possessionContext(address, slotKey2)) {
+		return ErrInvalidProofOfPossession
+	}
+	if initiator != (ke.AdaptorPoint != nil) {
+		return errors.New("swap: adaptor point must be sent by the initiator only")
+	}
+	if initiator && !ed25519.VerifyPossession(ke.AdaptorProof, ke.AdaptorPoint, possessionContext(address, slotAdaptor)) {
+		return ErrInvalidProofOfPossession
+	}
+	return nil
+}
+
+// Bytes returns the encoding of the message: a flag byte that is 1 if an
+// adaptor point is present, followed by A1 || A2 || [T] || N1 || N2 ||
+// proof1 || proof2 || [adaptor proof].
+func (ke *KeyExchange) Bytes() []byte {
+	encoded := []byte{0}
+	if ke.AdaptorPoint != nil {
+		encoded[0] = 1
+	}
+	encoded = append(encoded, ke.PublicKey1...)
+	encoded = append(encoded, ke.PublicKey2...)
+	if ke.AdaptorPoint != nil {
+		encoded = append(encoded, ke.AdaptorPoint...)
+	}
+	encoded = append(encoded, ke.Nonce1...)
+	encoded = append(encoded, ke.Nonce2...)
+	encoded = append(encoded, ke.Proof1.Bytes()...)
+	encoded = append(encoded, ke.Proof2.Bytes()...)
+	if ke.AdaptorPoint != nil {
+		encoded = append(encoded, ke.AdaptorProof.Bytes()...)
+	}
+	return encoded
+}
+
+// ParseKeyExchange parses an encoded key exchange message. It checks the
+// encoding of every field, but not the proofs; use Verify for that.
+func ParseKeyExchange(b []byte) (*KeyExchange, error) {
+	size := 1 + 2*ed25519.PublicKeySize + 2*ed25519.PublicNonceSize + 2*ed25519.ProofOfPossessionSize
+	if len(b) == 0 || b[0] > 1 {
+		return nil, errors.New("swap: bad key exchange encoding")
+	}
+	initiator := b[0] == 1
+	if initiator {
+		size += ed25519.CurvePointSize + ed25519.ProofOfPossessionSize
+	}
+	if len(b) != size {
+		return nil, errors.New("swap: bad key exchange length")
+	}
+	b = b[1:]
+
+	next := func(n int) []byte {
+		field := b[:n]
+		b = b[n:]
+		return field
+	}
+
+	ke := new(KeyExchange)
+	var err error
+	if ke.PublicKey1, err = ed25519.ParsePublicKey(next(ed25519.PublicKeySize)); err != nil {
+		return nil, err
+	}
+	if ke.PublicKey2, err = ed25519.ParsePublicKey(next(ed25519.PublicKeySize)); err != nil {
+		return nil, err
+	}
+	if initiator {
+		if ke.AdaptorPoint, err = ed25519.ParsePoint(next(ed25519.CurvePointSize)); err != nil {
+			return nil, err
+		}
+	}
+	if ke.Nonce1, err = ed25519.ParsePublicNonce(next(ed25519.PublicNonceSize)); err != nil {
+		return nil, err
+	}
+	if ke.Nonce2, err = ed25519.ParsePublicNonce(next(ed25519.PublicNonceSize)); err != nil {
+		return nil, err
+	}
+	if ke.Proof1, err = ed25519.ParseProofOfPossession(next(ed25519.ProofOfPossessionSize)); err != nil {
+		return nil, err
+	}
+	if ke.Proof2, err = ed25519.ParseProofOfPossession(next(ed25519.ProofOfPossessionSize)); err != nil {
+		return nil, err
+	}
+	if initiator {
+		if ke.AdaptorProof, err = ed25519.ParseProofOfPossession(next(ed25519.ProofOfPossessionSize)); err != nil {
+			return nil, err
+		}
+	}
+	return ke, nil
+}
+
+// possessionContext binds a proof of possession to the address of its
+// sender and the slot of the key.
+func possessionContext(address types.Address, slot int) []byte {
+	context := append([]byte(nil), keyExchangeContext...)
+	context = append(context, address.Bytes()...)
+	return append(context, byte(slot))
+}
@@ -0,0 +1,85 @@
+package swap_test
+
+import (
+	"testing"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// newTestKeyExchange returns the key exchange message of a new party with
+// the given address.
+func newTestKeyExchange(t *testing.T, address types.Address, initiator bool) *swap.KeyExchange {
+	t.Helper()
+	_, N1, err := ed25519.GenerateNonce(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, N2, err := ed25519.GenerateNonce(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ke, err := swap.NewKeyExchange(nil, newTestKeys(t, 0), address, initiator, N1, N2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ke
+}
+
+func TestKeyExchange(t *testing.T) {
+	address := newTestAddress(t)
+	for _, initiator := range []bool{true, false} {
+		ke := newTestKeyExchange(t, address, initiator)
+		if err := ke.Verify(address, initiator); err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := swap.ParseKeyExchange(ke.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parsed.Verify(address, initiator); err != nil {
+			t.Fatal(err)
+		}
+		if err := ke.Verify(address, !initiator); err == nil {
+			t.Fatal("Verify accepted a message of the other role")
+		}
+	}
+}
+
+func TestKeyExchangeRejectsProofs(t *testing.T) {
+	address := newTestAddress(t)
+	ke := newTestKeyExchange(t, address, true)
+	other := newTestKeyExchange(t, address, true)
+	// rogue is B1 = Y - A1, a key whose discrete logarithm is unknown.
+	rogue := ed25519.PublicKey(ed25519.CurvePoint(other.PublicKey1).Sub(ed25519.CurvePoint(ke.PublicKey1)))
+
+	tests := []struct {
+		name    string
+		tamper  func(ke *swap.KeyExchange)
+		address types.Address
+	}{
+		{"other address", nil, newTestAddress(t)},
+		{"swapped key proofs", func(ke *swap.KeyExchange) { ke.Proof1, ke.Proof2 = ke.Proof2, ke.Proof1 }, address},
+		{"adaptor proof as key proof", func(ke *swap.KeyExchange) { ke.Proof1 = ke.AdaptorProof }, address},
+		{"copied key", func(ke *swap.KeyExchange) { ke.PublicKey2 = other.PublicKey2 }, address},
+		{"copied adaptor point", func(ke *swap.KeyExchange) { ke.AdaptorPoint = other.AdaptorPoint }, address},
+		{"rogue key", func(ke *swap.KeyExchange) { ke.PublicKey1 = rogue }, address},
+		{"missing proof", func(ke *swap.KeyExchange) { ke.Proof2 = nil }, address},
+		{"missing adaptor proof", func(ke *swap.KeyExchange) { ke.AdaptorProof = nil }, address},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tampered, err := swap.ParseKeyExchange(ke.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.tamper != nil {
+				test.tamper(tampered)
+			}
+			if err := tampered.Verify(test.address, true); err != swap.ErrInvalidProofOfPossession {
+				t.Fatalf("Verify returned %v, want %v", err, swap.ErrInvalidProofOfPossession)
+			}
+		})
+	}
+}
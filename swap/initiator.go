@@ -0,0 +1,125 @@
+package swap
+
+import (
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// Initiator is the party that chooses the adaptor secret t, creates PTLC1
+// and claims PTLC2 first. Publishing its claim signature reveals t to the
+// responder.
+//
+// The steps of the initiator are:
+//
+//  1. send KeyExchange and pass the reply to ReceiveKeyExchange;
+//  2. create PTLC1 locked to LockKey and call Created, check the
+//     responder's PTLC2 with CheckCounterpartyPtlc and call Funded;
+//  3. send PartialSignatures and pass the reply to ReceivePartialSignature,
+//     which returns the claim signature;
+//  4. unlock PTLC2 with the claim signature and call Claimed.
+//
+// An initiator resumed with ResumeInitiator before step 3 has no secret
+// nonces; it must send the message returned by RefreshNonces and pass the
+// responder's refreshed message to ReceiveNonces before it can sign.
+type Initiator struct {
+	party
+}
+
+// NewInitiator starts a swap as initiator with the given keys and address on
+// the chain with the given identifier. If rand is nil, crypto/rand.Reader
+// will be used.
+func NewInitiator(rand io.Reader, keys *Keys, address types.Address, chainIdentifier uint64) (*Initiator, error) {
+	i := &Initiator{party: party{
+		rand:            rand,
+		initiator:       true,
+		chainIdentifier: chainIdentifier,
+		keys:            keys,
+		address:         address,
+	}}
+
+	N1, N2, err := i.generateNonces(keys.AdaptorPoint())
+	if err != nil {
+		return nil, err
+	}
+	i.local, err = NewKeyExchange(rand, keys, address, true, N1, N2)
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// KeyExchange returns the key exchange message to send to the responder.
+func (i *Initiator) KeyExchange() *KeyExchange {
+	return i.local
+}
+
+// ReceiveKeyExchange verifies the key exchange message of the responder with
+// the given address.
+func (i *Initiator) ReceiveKeyExchange(address types.Address, ke *KeyExchange) error {
+	if i.state != StateNew {
+		return ErrInvalidState
+	}
+	if err := i.receiveKeyExchange(address, ke); err != nil {
+		return err
+	}
+	if err := i.aggregateKeys(); err != nil {
+		return err
+	}
+
+	i.state = StateKeysExchanged
+	return i.commit()
+}
+
+// PartialSignatures returns the initiator's partial signatures sa1 and sa2
+// of both sessions, which must be sent to the responder. They can be created
+// only once.
+func (i *Initiator) PartialSignatures() (sa1, sa2 ed25519.Scalar, err error) {
+	if i.state != StatePtlcFunded || i.partial1 != nil || i.refreshing {
+		return nil, nil, ErrInvalidState
+	}
+
+	sa1, err = i.session1.PartialSign(i.keys.Key1, i.coefficients1[i.role()])
+	if err != nil {
+		return nil, nil, err
+	}
+	sa2, err = i.session2.PartialSign(i.keys.Key2, i.coefficients2[i.role()])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i.partial1 = sa1
+	if err := i.commit(); err != nil {
+		return nil, nil, err
+	}
+	return sa1, sa2, nil
+}
+
+// ReceivePartialSignature verifies the responder's partial signature sb1 of
+// session 1, completes the adaptor signature with t and returns the claim
+// signature that unlocks PTLC2.
+func (i *Initiator) ReceivePartialSignature(sb1 ed25519.Scalar) ([]byte, error) {
+	if i.state != StatePtlcFunded || i.partial1 == nil {
+		return nil, ErrInvalidState
+	}
+	if !i.session1.PartialVerify(sb1, i.remote.PublicKey1, i.coefficients1[1], i.remote.Nonce1) {
+		return nil, ErrInvalidPartialSignature
+	}
+
+	as := i.session1.Aggregate(i.partial1, sb1)
+	if !ed25519.PreVerify(i.jointKey1, i.message1, i.local.AdaptorPoint, as) {
+		return nil, ErrInvalidSignature
+	}
+	signature := ed25519.Adapt(as, ed25519.Adaptor(i.keys.Adaptor))
+	if !ed25519.Verify(i.jointKey1, i.message1, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	i.claim = signature
+	i.state = StatePreSignaturesExchanged
+	if err := i.commit(); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
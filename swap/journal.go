@@ -0,0 +1,287 @@
+package swap
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// snapshotVersion is the version of the snapshot encoding.
+const snapshotVersion = 3
+
+// snapshotFields is the number of fields of a snapshot.
+const snapshotFields = 19
+
+// ErrInvalidSnapshot is returned when a snapshot cannot be decoded or does
+// not belong to the role it is resumed as.
+var ErrInvalidSnapshot = errors.New("swap: invalid snapshot")
+
+// Journal durably records the state of a party after every step of a swap,
+// so that the swap can be resumed with ResumeInitiator or ResumeResponder
+// after the process died.
+//
+// Snapshots contain the secret keys of the party and must be stored as
+// securely as the wallet. They never contain secret nonces: a resumed party
+// that has not signed yet must exchange fresh nonces with RefreshNonces.
+type Journal interface {
+	// Commit stores the snapshot of the party in the given state. It must
+	// not return before the snapshot is durable, because the party reveals
+	// the result of a step to the caller only after its commit succeeded.
+	Commit(state State, snapshot []byte) error
+}
+
+// SetJournal attaches j to the party and commits its current state. From
+// then on every step commits the new state before it returns; if a commit
+// fails, the step returns the error and the swap must be aborted.
+func (p *party) SetJournal(j Journal) error {
+	p.journal = j
+	return p.commit()
+}
+
+// Snapshot returns the encoding of the party's state, including its secrets.
+func (p *party) Snapshot() []byte {
+	var initiator, refreshing byte
+	if p.initiator {
+		initiator = 1
+	}
+	if p.refreshing {
+		refreshing = 1
+	}
+	var chainIdentifier [8]byte
+	binary.BigEndian.PutUint64(chainIdentifier[:], p.chainIdentifier)
+	var account, index [4]byte
+	binary.BigEndian.PutUint32(account[:], p.keys.Account)
+	binary.BigEndian.PutUint32(index[:], p.keys.Index)
+
+	var counterparty, local, remote, ptlc1, ptlc2, adaptorSignature1, adaptorSignature2 []byte
+	if p.remote != nil {
+		counterparty = p.counterparty.Bytes()
+		remote = p.remote.Bytes()
+	}
+	if p.local != nil {
+		local = p.local.Bytes()
+	}
+	if !p.ptlc1.IsZero() {
+		ptlc1 = p.ptlc1.Bytes()
+	}
+	if !p.ptlc2.IsZero() {
+		ptlc2 = p.ptlc2.Bytes()
+	}
+	if p.adaptorSignature1 != nil {
+		adaptorSignature1, adaptorSignature2 = p.adaptorSignature1.Bytes(), p.adaptorSignature2.Bytes()
+	}
+
+	fields := [][]byte{
+		{initiator},
+		{byte(p.state)},
+		chainIdentifier[:],
+		account[:],
+		index[:],
+		p.keys.Key1,
+		p.keys.Key2,
+		p.keys.Adaptor,
+		p.address.Bytes(),
+		counterparty,
+		local,
+		remote,
+		ptlc1,
+		ptlc2,
+		p.partial1,
+		adaptorSignature1,
+		adaptorSignature2,
+		p.claim,
+		{refreshing},
+	}
+
+	encoded := []byte{snapshotVersion}
+	for _, field := range fields {
+		encoded = binary.BigEndian.AppendUint16(encoded, uint16(len(field)))
+		encoded = append(encoded, field...)
+	}
+	return encoded
+}
+
+// ResumeInitiator restores an initiator from a snapshot, typically the last
+// one committed to its journal. The journal must be attached again with
+// SetJournal. If rand is nil, crypto/rand.Reader will be used.
+func ResumeInitiator(rand io.Reader, snapshot []byte) (*Initiator, error) {
+	p, err := restore(rand, snapshot, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Initiator{party: *p}, nil
+}
+
+// ResumeResponder restores a responder from a snapshot, typically the last
+// one committed to its journal. The journal must be attached again with
+// SetJournal. If rand is nil, crypto/rand.Reader will be used.
+func ResumeResponder(rand io.Reader, snapshot []byte) (*Responder, error) {
+	p, err := restore(rand, snapshot, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Responder{party: *p}, nil
+}
+
+// restore decodes a snapshot and recomputes the joint keys and signing
+// sessions of the party. Without its secret nonces, the restored sessions
+// can only verify and aggregate partial signatures.
+func restore(rand io.Reader, snapshot []byte, initiator bool) (*party, error) {
+	fields, err := splitSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields[0]) != 1 || (fields[0][0] == 1) != initiator || fields[0][0] > 1 {
+		return nil, ErrInvalidSnapshot
+	}
+	if len(fields[1]) != 1 || State(fields[1][0]) > StateAborted ||
+		len(fields[2]) != 8 || len(fields[3]) != 4 || len(fields[4]) != 4 {
+		return nil, ErrInvalidSnapshot
+	}
+
+	p := &party{
+		rand:            rand,
+		state:           State(fields[1][0]),
+		initiator:       initiator,
+		chainIdentifier: binary.BigEndian.Uint64(fields[2]),
+		keys: &Keys{
+			Account: binary.BigEndian.Uint32(fields[3]),
+			Index:   binary.BigEndian.Uint32(fields[4]),
+		},
+	}
+	if p.keys.Key1, err = ed25519.ParseScalar(fields[5]); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if p.keys.Key2, err = ed25519.ParseScalar(fields[6]); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if p.keys.Adaptor, err = ed25519.ParseScalar(fields[7]); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+	if p.address, err = types.BytesToAddress(fields[8]); err != nil {
+		return nil, ErrInvalidSnapshot
+	}
+
+	if len(fields[10]) > 0 {
+		if p.local, err = ParseKeyExchange(fields[10]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[11]) > 0 {
+		if p.counterparty, err = types.BytesToAddress(fields[9]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if p.remote, err = ParseKeyExchange(fields[11]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if p.local == nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if err := p.aggregateKeys(); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[12]) > 0 {
+		if p.ptlc1, err = types.BytesToHash(fields[12]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[13]) > 0 {
+		if p.ptlc2, err = types.BytesToHash(fields[13]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if !p.ptlc1.IsZero() && !p.ptlc2.IsZero() {
+		if p.remote == nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if err := p.startSessions(); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[14]) > 0 {
+		if p.partial1, err = ed25519.ParseScalar(fields[14]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[15]) > 0 {
+		if p.adaptorSignature1, err = ed25519.ParseAdaptorSignature(fields[15]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+		if p.adaptorSignature2, err = ed25519.ParseAdaptorSignature(fields[16]); err != nil {
+			return nil, ErrInvalidSnapshot
+		}
+	}
+	if len(fields[17]) > 0 {
+		if len(fields[17]) != ed25519.SignatureSize {
+			return nil, ErrInvalidSnapshot
+		}
+		p.claim = fields[17]
+	}
+	// A party that stopped between RefreshNonces and ReceiveNonces still
+	// waits for the counterparty's refreshed nonces.
+	if len(fields[18]) != 1 || fields[18][0] > 1 {
+		return nil, ErrInvalidSnapshot
+	}
+	p.refreshing = fields[18][0] == 1
+
+	return p, nil
+}
+
+// Ended reports whether the swap committed in state with snapshot needs
+// nothing more from the party: it was claimed or refunded, or it was aborted
+// before the party created its own PTLC. An aborted swap whose PTLC exists
+// ends only once the PTLC was reclaimed and Refunded was called.
+func Ended(state State, snapshot []byte) bool {
+	switch state {
+	case StateClaimed, StateRefunded:
+		return true
+	case StateAborted:
+		fields, err := splitSnapshot(snapshot)
+		if err != nil || len(fields[0]) != 1 {
+			return false
+		}
+		if fields[0][0] == 1 {
+			return len(fields[12]) == 0
+		}
+		return len(fields[13]) == 0
+	default:
+		return false
+	}
+}
+
+// splitSnapshot splits a snapshot into its length-prefixed fields.
+func splitSnapshot(snapshot []byte) ([][]byte, error) {
+	if len(snapshot) == 0 || snapshot[0] != snapshotVersion {
+		return nil, ErrInvalidSnapshot
+	}
+	b := snapshot[1:]
+
+	fields := make([][]byte, 0, snapshotFields)
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, ErrInvalidSnapshot
+		}
+		n := int(binary.BigEndian.Uint16(b))
+		if len(b) < 2+n {
+			return nil, ErrInvalidSnapshot
+		}
+		fields = append(fields, append([]byte(nil), b[2:2+n]...))
+		b = b[2+n:]
+	}
+	if len(fields) != snapshotFields {
+		return nil, ErrInvalidSnapshot
+	}
+	return fields, nil
+}
+
+// commit records the state of the party in its journal, if it has one.
+func (p *party) commit() error {
+	if p.journal == nil {
+		return nil
+	}
+	return p.journal.Commit(p.state, p.Snapshot())
+}
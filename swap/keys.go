@@ -0,0 +1,125 @@
+// Package swap implements the protocol of PTLC atomic swaps on Zenon, in
+// which each party locks funds in a PTLC to a MuSig2 joint key of both
+// parties, and the initiator's claim of one PTLC reveals the adaptor secret
+// that lets the responder claim the other.
+//
+// The two roles are the state machines Initiator and Responder. Each step
+// of a swap is a method that checks the current State, verifies the
+// counterparty's input and moves the party forward, from StateNew through
+// StateKeysExchanged, StatePtlcFunded and StatePreSignaturesExchanged to
+// StateClaimed, or to StateRefunded or StateAborted. The parties first
+// swap their KeyExchange messages, which carry their public keys, the
+// initiator's adaptor point and their public nonces, with proofs of
+// possession bound to their addresses. Both then sign the unlock messages of
+// both PTLCs, built by UnlockMessage as the contract verifies them. The
+// package does not send anything itself; the wire package encodes the
+// messages and the transport package carries them.
+//
+// The keys of a swap are derived from the wallet seed with DeriveKeys. A
+// party attached to a Journal with SetJournal commits a snapshot after every
+// step, so that a swap interrupted by a crash can be resumed with
+// ResumeInitiator or ResumeResponder and its PTLCs claimed or reclaimed.
+package swap
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+// PathFormat is the derivation path of the secrets of a swap:
+//
+//	m/44'/73404'/account'/purpose'/index'/slot'
+//
+// It extends the Zenon account path wallet.ZenonAccountPathFormat with the
+// constant Purpose, the swap index and the slot of the secret within the
+// swap. Like every Ed25519 SLIP-10 path it uses hardened indices only.
+const PathFormat = "m/44'/73404'/%d'/%d'/%d'/%d'"
+
+// Purpose separates swap secrets from other keys derived below a Zenon
+// account. It is the ASCII string "PTLC" read as a big-endian integer.
+const Purpose = 0x50544c43
+
+// The slots of the secrets of a swap.
+const (
+	slotKey1 = iota
+	slotKey2
+	slotAdaptor
+)
+
+// maxIndex is the largest index of a hardened derivation step.
+const maxIndex = wallet.FirstHardenedIndex - 1
+
+// ErrInvalidIndex is returned when an account or swap index does not fit in a
+// hardened derivation step.
+var ErrInvalidIndex = errors.New("swap: index must be smaller than 2^31")
+
+// Keys are the secrets a party needs to take part in the swap with the given
+// Index. Key1 and Key2 are the private scalars of its shares of the joint
+// keys locking the first and second PTLC. Adaptor is the adaptor secret t,
+// which only the initiator of a swap uses.
+//
+// The keys are derived from the wallet seed, so a party that crashed after
+// funding a PTLC recovers them from its mnemonic and the swap index alone.
+// Nonces are deliberately neither derived nor journaled: a restarted signing
+// session must generate fresh nonces with RefreshNonces, because signing
+// twice with one nonce reveals the key.
+type Keys struct {
+	Account uint32
+	Index   uint32
+	Key1    ed25519.Scalar
+	Key2    ed25519.Scalar
+	Adaptor ed25519.Scalar
+}
+
+// DeriveKeys derives the secrets of the swap with the given index from the
+// seed of ks, using the Zenon account with the given index.
+func DeriveKeys(ks *wallet.KeyStore, account, index uint32) (*Keys, error) {
+	if account > maxIndex || index > maxIndex {
+		return nil, ErrInvalidIndex
+	}
+
+	keys := &Keys{Account: account, Index: index}
+	for slot, key := range []*ed25519.Scalar{&keys.Key1, &keys.Key2, &keys.Adaptor} {
+		var err error
+		*key, err = deriveScalar(ks, fmt.Sprintf(PathFormat, account, Purpose, index, slot))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// PublicKey1 returns the public key of Key1.
+func (k *Keys) PublicKey1() ed25519.PublicKey {
+	return ed25519.PublicKey(k.Key1.ToCurvePoint())
+}
+
+// PublicKey2 returns the public key of Key2.
+func (k *Keys) PublicKey2() ed25519.PublicKey {
+	return ed25519.PublicKey(k.Key2.ToCurvePoint())
+}
+
+// AdaptorPoint returns the adaptor point T of Adaptor.
+func (k *Keys) AdaptorPoint() ed25519.CurvePoint {
+	return k.Adaptor.ToCurvePoint()
+}
+
+// deriveScalar derives the SLIP-10 key at path and maps it to a uniform
+// scalar. The scalar is SHA512(key) reduced modulo l instead of the clamped
+// scalar of an Ed25519 account key, so it is also suitable as an adaptor
+// secret.
+func deriveScalar(ks *wallet.KeyStore, path string) (ed25519.Scalar, error) {
+	_, kp, err := ks.DeriveForFullPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha512.Sum512(kp.Private.Seed())
+	key, err := ed25519.ScalarFromUniformBytes(digest[:])
+	digest = [sha512.Size]byte{}
+	return key, err
+}
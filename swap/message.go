@@ -0,0 +1,50 @@
+package swap
+
+import (
+	"encoding/binary"
+
+	"github.com/zenon-network/go-zenon/common"
+	"github.com/zenon-network/go-zenon/common/crypto"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// MessageVersion selects the construction of the unlock message of a PTLC.
+type MessageVersion uint8
+
+const (
+	// MessageContract is the unlock message verified by the deployed go-zenon
+	// PTLC contract, SHA3(id || destination). It does not commit to the chain
+	// identifier; signatures cannot be replayed on another chain only because
+	// the id, the hash of the block that created the PTLC, already does.
+	MessageContract MessageVersion = iota
+	// MessageDomainSeparated is the unlock message for future contract
+	// versions, SHA3(tag || version || chainIdentifier || id || destination)
+	// with a fixed tag and the chain identifier as 8 big-endian bytes, which
+	// cannot collide with unlock messages of other protocols or chains.
+	MessageDomainSeparated
+)
+
+var unlockMessageTag = []byte("PTLC/zenon/unlock")
+
+// UnlockMessage returns the message that the point lock of the PTLC with the
+// given id must sign to unlock it to destination on the chain with the given
+// identifier. Both parties of a swap must build their messages with this
+// function and the version of the contract holding the PTLC, as a signature
+// over any other message is useless.
+//
+// The chain identifier is ignored for MessageContract, because the deployed
+// contract does not commit to it. Parties pass the identifier of their
+// network anyway, so that switching to MessageDomainSeparated does not
+// change any caller.
+func UnlockMessage(version MessageVersion, chainIdentifier uint64, id types.Hash, destination types.Address) []byte {
+	switch version {
+	case MessageContract:
+		return crypto.Hash(common.JoinBytes(id.Bytes(), destination.Bytes()))
+	case MessageDomainSeparated:
+		var chain [8]byte
+		binary.BigEndian.PutUint64(chain[:], chainIdentifier)
+		return crypto.Hash(common.JoinBytes(unlockMessageTag, []byte{byte(version)}, chain[:], id.Bytes(), destination.Bytes()))
+	default:
+		panic("swap: unknown unlock message version")
+	}
+}
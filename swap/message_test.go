@@ -0,0 +1,80 @@
+package swap
+
+import (
+	"bytes"
+	stded25519 "crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/wallet"
+)
+
+func testUnlockTarget(t *testing.T) (types.Hash, types.Address) {
+	t.Helper()
+	b := make([]byte, types.HashSize)
+	for i := range b {
+		b[i] = byte(i + 1)
+	}
+	id, err := types.BytesToHash(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	destination, err := types.ParseAddress("z1qqjnwjjpnue8xmmpanz6csze6tcmtzzdtfsww7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, destination
+}
+
+// TestUnlockMessageContract checks MessageContract against an unlock that
+// the go-zenon PTLC contract accepted: TestPtlc_unlock in
+// vm/embedded/tests/ptlc_test.go creates PTLC 6809e10e… locked to the key of
+// g.User2, and its expected logs record the signature with which unlockPtlc
+// in vm/embedded/implementation/ptlc.go unlocked it to the address of
+// g.User2. TestPtlc_proxy_unlock logs the same signature being rejected for
+// another destination.
+func TestUnlockMessageContract(t *testing.T) {
+	id := types.HexToHashPanic("6809e10e211036a33d43ce4a72b71a5389ac8050df1249edefd52b632ce45b79")
+	destination, err := types.ParseAddress("z1qr4pexnnfaexqqz8nscjjcsajy5hdqfkgadvwx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := types.ParseAddress("z1qrs2lpccnsneglhnnfwvlsj0qncnxjnwlfmjac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pointLock, err := base64.StdEncoding.DecodeString("tUJu3P7Drp25XP662lIjyFlFpvj8bWUpyC+0y5YTzXM=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := base64.StdEncoding.DecodeString("aFRIn613J+TaTP40Yzv9bk3eC2UPyc3PtIIp75yDnfbh+vQtm5ZOumAVNM6noBpHGjO6nFrAzHZ67Np9r8ArDA==")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := UnlockMessage(MessageContract, 0, id, destination)
+	valid, err := wallet.VerifySignature(stded25519.PublicKey(pointLock), message, signature)
+	if err != nil || !valid {
+		t.Fatal("the contract's unlock signature does not verify over the unlock message")
+	}
+	if !bytes.Equal(UnlockMessage(MessageContract, 321, id, destination), message) {
+		t.Fatal("the contract message depends on the chain identifier")
+	}
+	valid, err = wallet.VerifySignature(stded25519.PublicKey(pointLock), UnlockMessage(MessageContract, 0, id, other), signature)
+	if err != nil || valid {
+		t.Fatal("the unlock signature verifies for another destination")
+	}
+}
+
+func TestUnlockMessageDomainSeparated(t *testing.T) {
+	id, destination := testUnlockTarget(t)
+
+	message := UnlockMessage(MessageDomainSeparated, 1, id, destination)
+	if bytes.Equal(message, UnlockMessage(MessageContract, 1, id, destination)) {
+		t.Fatal("domain-separated message equals the contract message")
+	}
+	if bytes.Equal(message, UnlockMessage(MessageDomainSeparated, 2, id, destination)) {
+		t.Fatal("domain-separated message does not depend on the chain identifier")
+	}
+}
@@ -0,0 +1,360 @@
+package swap
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+// ExpiryMargin is the minimum time, in seconds, by which PTLC2 must expire
+// before PTLC1. The initiator reveals t when it claims PTLC2, at the latest
+// just before PTLC2 expires, and the responder needs the margin to read the
+// claim from the chain and unlock PTLC1.
+const ExpiryMargin = 60 * 60
+
+// Terms are the token and amount of a PTLC, as agreed by both parties before
+// the swap starts.
+type Terms struct {
+	Token  types.ZenonTokenStandard
+	Amount *big.Int
+}
+
+// party holds the state shared by the Initiator and the Responder of a
+// swap.
+//
+// The initiator creates PTLC1 and the responder PTLC2. PTLC1 is locked to the
+// joint key 2 of A2 and B2 and unlocked by the responder with the signature
+// of session 2; PTLC2 is locked to the joint key 1 of A1 and B1 and unlocked
+// by the initiator with the signature of session 1. Both sessions use the
+// initiator's adaptor point T.
+//
+// The secret nonces live only in memory. A party resumed from a snapshot has
+// none, and refreshing tells whether it generated new ones with
+// RefreshNonces but has not yet received the counterparty's.
+type party struct {
+	rand            io.Reader
+	state           State
+	initiator       bool
+	chainIdentifier uint64
+	keys            *Keys
+	address         types.Address
+	counterparty    types.Address
+
+	secretNonce1 *ed25519.SecretNonce
+	secretNonce2 *ed25519.SecretNonce
+	local        *KeyExchange
+	remote       *KeyExchange
+
+	jointKey1     ed25519.PublicKey
+	jointKey2     ed25519.PublicKey
+	coefficients1 []ed25519.Scalar
+	coefficients2 []ed25519.Scalar
+
+	ptlc1    types.Hash
+	ptlc2    types.Hash
+	message1 []byte
+	message2 []byte
+	session1 *ed25519.Session
+	session2 *ed25519.Session
+
+	partial1          ed25519.Scalar
+	adaptorSignature1 *ed25519.AdaptorSignature
+	adaptorSignature2 *ed25519.AdaptorSignature
+	claim             []byte
+
+	refreshing bool
+	journal    Journal
+}
+
+// State returns the current state of the party.
+func (p *party) State() State {
+	return p.state
+}
+
+// LockKey returns the joint key that the party's own PTLC must be locked to.
+// It is known from StateKeysExchanged on.
+func (p *party) LockKey() ed25519.PublicKey {
+	if p.initiator {
+		return p.jointKey2
+	}
+	return p.jointKey1
+}
+
+// CounterpartyLockKey returns the joint key that the counterparty's PTLC
+// must be locked to. It is known from StateKeysExchanged on.
+func (p *party) CounterpartyLockKey() ed25519.PublicKey {
+	if p.initiator {
+		return p.jointKey1
+	}
+	return p.jointKey2
+}
+
+// CheckCounterpartyPtlc checks that the counterparty's PTLC is owned by the
+// counterparty, locked to CounterpartyLockKey and holds the token and amount
+// of terms.
+//
+// expirationTime is the expiration time of the party's own PTLC, which the
+// initiator has already created and the responder is about to create. PTLC2
+// must expire at least ExpiryMargin before PTLC1, so the initiator requires
+// the counterparty's PTLC to expire that much before its own, and the
+// responder that much after.
+func (p *party) CheckCounterpartyPtlc(info *definition.PtlcInfo, terms Terms, expirationTime int64) error {
+	if p.state != StateKeysExchanged {
+		return ErrInvalidState
+	}
+	if info.TimeLocked != p.counterparty || info.PointType != definition.PointTypeED25519 {
+		return ErrPtlcMismatch
+	}
+	if !bytes.Equal(info.PointLock, p.CounterpartyLockKey()) {
+		return ErrPtlcMismatch
+	}
+	if info.TokenStandard != terms.Token || info.Amount == nil || info.Amount.Cmp(terms.Amount) != 0 {
+		return ErrPtlcMismatch
+	}
+
+	expiration1, expiration2 := expirationTime, info.ExpirationTime
+	if !p.initiator {
+		expiration1, expiration2 = expiration2, expiration1
+	}
+	if expiration2 > expiration1-ExpiryMargin {
+		return ErrUnsafeExpiration
+	}
+	return nil
+}
+
+// Created records the id of the party's own PTLC as soon as it was sent, so
+// that it can be reclaimed after a restart even if the swap never gets
+// funded.
+func (p *party) Created(own types.Hash) error {
+	if p.state != StateKeysExchanged {
+		return ErrInvalidState
+	}
+	if p.initiator {
+		p.ptlc1 = own
+	} else {
+		p.ptlc2 = own
+	}
+	return p.commit()
+}
+
+// Ptlcs returns the ids of the party's own PTLC and of the counterparty's
+// PTLC. They are zero until passed to Created or Funded.
+func (p *party) Ptlcs() (own, counterparty types.Hash) {
+	if p.initiator {
+		return p.ptlc1, p.ptlc2
+	}
+	return p.ptlc2, p.ptlc1
+}
+
+// Counterparty returns the address of the counterparty. It is known from
+// StateKeysExchanged on.
+func (p *party) Counterparty() types.Address {
+	return p.counterparty
+}
+
+// Resumes reports whether ke carries the keys that the counterparty announced
+// in its key exchange message, as its refreshed message must when it resumes
+// the swap.
+func (p *party) Resumes(ke *KeyExchange) bool {
+	return p.remote != nil && bytes.Equal(ke.PublicKey1, p.remote.PublicKey1) &&
+		bytes.Equal(ke.PublicKey2, p.remote.PublicKey2) && bytes.Equal(ke.AdaptorPoint, p.remote.AdaptorPoint)
+}
+
+// ClaimSignature returns the signature that unlocks the counterparty's PTLC,
+// once it is known.
+func (p *party) ClaimSignature() []byte {
+	return p.claim
+}
+
+// Funded records the ids of the party's own PTLC and of the counterparty's
+// PTLC, which must have been checked with CheckCounterpartyPtlc, and starts
+// the signing sessions over the unlock messages of both.
+func (p *party) Funded(own, counterparty types.Hash) error {
+	if p.state != StateKeysExchanged {
+		return ErrInvalidState
+	}
+
+	p.ptlc1, p.ptlc2 = own, counterparty
+	if !p.initiator {
+		p.ptlc1, p.ptlc2 = counterparty, own
+	}
+	if err := p.startSessions(); err != nil {
+		return err
+	}
+
+	p.state = StatePtlcFunded
+	return p.commit()
+}
+
+// startSessions computes the unlock messages of both PTLCs and starts the
+// signing sessions over them. Without the secret nonces, the sessions can
+// only verify and aggregate partial signatures.
+func (p *party) startSessions() error {
+	A, B := p.exchanges()
+	initiatorAddress, responderAddress := p.address, p.counterparty
+	if !p.initiator {
+		initiatorAddress, responderAddress = responderAddress, initiatorAddress
+	}
+	p.message1 = UnlockMessage(MessageContract, p.chainIdentifier, p.ptlc2, initiatorAddress)
+	p.message2 = UnlockMessage(MessageContract, p.chainIdentifier, p.ptlc1, responderAddress)
+
+	var err error
+	p.session1, err = ed25519.NewSession(p.secretNonce1, []ed25519.PublicNonce{A.Nonce1, B.Nonce1}, p.jointKey1, p.message1, A.AdaptorPoint)
+	if err != nil {
+		return err
+	}
+	p.session2, err = ed25519.NewSession(p.secretNonce2, []ed25519.PublicNonce{A.Nonce2, B.Nonce2}, p.jointKey2, p.message2, A.AdaptorPoint)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RefreshNonces replaces the nonces of a party that has not signed yet and
+// returns its key exchange message with the new public nonces, which must be
+// sent to the counterparty. Secret nonces are never journaled, so a party
+// resumed before signing cannot sign until both parties have refreshed their
+// nonces and passed each other's message to ReceiveNonces. This holds also
+// for a party that stopped while refreshing: it still accepts the
+// counterparty's message, but must refresh again to sign.
+func (p *party) RefreshNonces() (*KeyExchange, error) {
+	if (p.state != StateKeysExchanged && p.state != StatePtlcFunded) || p.partial1 != nil {
+		return nil, ErrInvalidState
+	}
+
+	A, _ := p.exchanges()
+	N1, N2, err := p.generateNonces(A.AdaptorPoint)
+	if err != nil {
+		return nil, err
+	}
+	local, err := NewKeyExchange(p.rand, p.keys, p.address, p.initiator, N1, N2)
+	if err != nil {
+		return nil, err
+	}
+
+	p.local = local
+	p.refreshing = true
+	if err := p.commit(); err != nil {
+		return nil, err
+	}
+	return p.local, nil
+}
+
+// ReceiveNonces verifies the counterparty's key exchange message with its
+// refreshed nonces, which must carry the keys it announced before, and
+// restarts the signing sessions over the new nonces.
+func (p *party) ReceiveNonces(ke *KeyExchange) error {
+	if !p.refreshing {
+		return ErrInvalidState
+	}
+	if err := ke.Verify(p.counterparty, !p.initiator); err != nil {
+		return err
+	}
+	if !p.Resumes(ke) {
+		return ErrKeysChanged
+	}
+
+	p.remote = ke
+	p.refreshing = false
+	if p.state == StatePtlcFunded {
+		if err := p.startSessions(); err != nil {
+			return err
+		}
+	}
+	return p.commit()
+}
+
+// Claimed records that the party unlocked the counterparty's PTLC with its
+// claim signature.
+func (p *party) Claimed() error {
+	if p.state != StatePreSignaturesExchanged || p.claim == nil {
+		return ErrInvalidState
+	}
+	p.state = StateClaimed
+	return p.commit()
+}
+
+// Refunded records that the party reclaimed its own PTLC after expiry.
+func (p *party) Refunded() error {
+	switch p.state {
+	case StateKeysExchanged, StatePtlcFunded, StatePreSignaturesExchanged, StateAborted:
+		p.state = StateRefunded
+		return p.commit()
+	default:
+		return ErrInvalidState
+	}
+}
+
+// Abort ends the swap. A party that already created its PTLC must still
+// reclaim it after expiry and then call Refunded.
+func (p *party) Abort() error {
+	if p.state.Final() {
+		return ErrInvalidState
+	}
+	p.state = StateAborted
+	return p.commit()
+}
+
+// receiveKeyExchange verifies the counterparty's key exchange message and
+// records it.
+func (p *party) receiveKeyExchange(address types.Address, ke *KeyExchange) error {
+	if err := ke.Verify(address, !p.initiator); err != nil {
+		return err
+	}
+
+	p.counterparty = address
+	p.remote = ke
+	return nil
+}
+
+// aggregateKeys computes the joint keys once both key exchange messages are
+// known.
+func (p *party) aggregateKeys() error {
+	A, B := p.exchanges()
+
+	var err error
+	p.jointKey1, p.coefficients1, err = ed25519.AggregatePublicKeys(A.PublicKey1, B.PublicKey1)
+	if err != nil {
+		return err
+	}
+	p.jointKey2, p.coefficients2, err = ed25519.AggregatePublicKeys(A.PublicKey2, B.PublicKey2)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// exchanges returns the key exchange messages of the initiator and the
+// responder.
+func (p *party) exchanges() (A, B *KeyExchange) {
+	if p.initiator {
+		return p.local, p.remote
+	}
+	return p.remote, p.local
+}
+
+// role returns the index of the party in the key aggregation coefficients.
+func (p *party) role() int {
+	if p.initiator {
+		return 0
+	}
+	return 1
+}
+
+// generateNonces generates the party's nonce pairs for both sessions, bound
+// to its keys and the adaptor point T.
+func (p *party) generateNonces(T ed25519.CurvePoint) (N1, N2 ed25519.PublicNonce, err error) {
+	p.secretNonce1, N1, err = ed25519.GenerateSyntheticNonce(p.rand, p.keys.Key1, p.keys.PublicKey1(), nil, T)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.secretNonce2, N2, err = ed25519.GenerateSyntheticNonce(p.rand, p.keys.Key2, p.keys.PublicKey2(), nil, T)
+	if err != nil {
+		return nil, nil, err
+	}
+	return N1, N2, nil
+}
@@ -0,0 +1,135 @@
+package swap
+
+import (
+	"io"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// Responder is the party that creates PTLC2 after checking the initiator's
+// PTLC1, and claims PTLC1 with the adaptor secret t that the initiator
+// reveals by claiming PTLC2.
+//
+// The steps of the responder are:
+//
+//  1. pass the initiator's key exchange message to ReceiveKeyExchange and
+//     send the reply;
+//  2. check the initiator's PTLC1 with CheckCounterpartyPtlc, create PTLC2
+//     locked to LockKey and call Created and Funded;
+//  3. pass the initiator's partial signatures to ReceivePartialSignatures
+//     and send the returned partial signature;
+//  4. pass the initiator's claim signature of PTLC2, read from the chain, to
+//     ReceiveSignature, unlock PTLC1 with the returned claim signature and
+//     call Claimed.
+//
+// A responder resumed with ResumeResponder before step 3 has no secret
+// nonces; it must send the message returned by RefreshNonces and pass the
+// initiator's refreshed message to ReceiveNonces before it can sign.
+type Responder struct {
+	party
+}
+
+// NewResponder starts a swap as responder with the given keys and address on
+// the chain with the given identifier. If rand is nil, crypto/rand.Reader
+// will be used.
+func NewResponder(rand io.Reader, keys *Keys, address types.Address, chainIdentifier uint64) *Responder {
+	return &Responder{party: party{
+		rand:            rand,
+		chainIdentifier: chainIdentifier,
+		keys:            keys,
+		address:         address,
+	}}
+}
+
+// ReceiveKeyExchange verifies the key exchange message of the initiator with
+// the given address and returns the responder's key exchange message, whose
+// nonces are bound to the initiator's adaptor point.
+func (r *Responder) ReceiveKeyExchange(address types.Address, ke *KeyExchange) (*KeyExchange, error) {
+	if r.state != StateNew {
+		return nil, ErrInvalidState
+	}
+	if err := r.receiveKeyExchange(address, ke); err != nil {
+		return nil, err
+	}
+
+	N1, N2, err := r.generateNonces(ke.AdaptorPoint)
+	if err != nil {
+		return nil, err
+	}
+	r.local, err = NewKeyExchange(r.rand, r.keys, r.address, false, N1, N2)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.aggregateKeys(); err != nil {
+		return nil, err
+	}
+
+	r.state = StateKeysExchanged
+	if err := r.commit(); err != nil {
+		return nil, err
+	}
+	return r.local, nil
+}
+
+// ReceivePartialSignatures verifies the initiator's partial signatures sa1
+// and sa2, and returns the responder's partial signature sb1 of session 1,
+// which must be sent to the initiator. The responder's own adaptor signature
+// of session 2 is verified before sb1 is released.
+func (r *Responder) ReceivePartialSignatures(sa1, sa2 ed25519.Scalar) (ed25519.Scalar, error) {
+	if r.state != StatePtlcFunded || r.refreshing {
+		return nil, ErrInvalidState
+	}
+	if !r.session1.PartialVerify(sa1, r.remote.PublicKey1, r.coefficients1[0], r.remote.Nonce1) ||
+		!r.session2.PartialVerify(sa2, r.remote.PublicKey2, r.coefficients2[0], r.remote.Nonce2) {
+		return nil, ErrInvalidPartialSignature
+	}
+
+	sb1, err := r.session1.PartialSign(r.keys.Key1, r.coefficients1[r.role()])
+	if err != nil {
+		return nil, err
+	}
+	sb2, err := r.session2.PartialSign(r.keys.Key2, r.coefficients2[r.role()])
+	if err != nil {
+		return nil, err
+	}
+
+	r.adaptorSignature2 = r.session2.Aggregate(sa2, sb2)
+	if !ed25519.PreVerify(r.jointKey2, r.message2, r.remote.AdaptorPoint, r.adaptorSignature2) {
+		return nil, ErrInvalidSignature
+	}
+	r.adaptorSignature1 = r.session1.Aggregate(sa1, sb1)
+
+	r.state = StatePreSignaturesExchanged
+	if err := r.commit(); err != nil {
+		return nil, err
+	}
+	return sb1, nil
+}
+
+// ReceiveSignature verifies the initiator's claim signature of PTLC2,
+// extracts the adaptor secret t from it and returns the claim signature that
+// unlocks PTLC1.
+func (r *Responder) ReceiveSignature(signature []byte) ([]byte, error) {
+	if r.state != StatePreSignaturesExchanged {
+		return nil, ErrInvalidState
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(r.jointKey1, r.message1, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	t, err := ed25519.Extract(signature, r.adaptorSignature1)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	claim := ed25519.Adapt(r.adaptorSignature2, t)
+	if !ed25519.Verify(r.jointKey2, r.message2, claim) {
+		return nil, ErrInvalidSignature
+	}
+
+	r.claim = claim
+	if err := r.commit(); err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
@@ -0,0 +1,91 @@
+package swap
+
+import (
+	"errors"
+	"strconv"
+)
+
+// State is the progress of a party through a swap.
+//
+// A swap moves forward through StateNew, StateKeysExchanged,
+// StatePtlcFunded, StatePreSignaturesExchanged and StateClaimed. It may be
+// aborted in any state before it has ended, and a party whose PTLC was
+// created but not claimed by the counterparty ends in StateRefunded after
+// reclaiming it on expiry.
+type State int
+
+const (
+	// StateNew is the state of a party that has not yet received the keys
+	// of its counterparty.
+	StateNew State = iota
+	// StateKeysExchanged is reached once both parties know each other's keys
+	// and public nonces and have verified the proofs of possession, so that
+	// the joint point locks are known.
+	StateKeysExchanged
+	// StatePtlcFunded is reached once both PTLCs exist and the counterparty's
+	// PTLC has been checked against the swap.
+	StatePtlcFunded
+	// StatePreSignaturesExchanged is reached once the party holds verified
+	// adaptor signatures and can complete its claim signature.
+	StatePreSignaturesExchanged
+	// StateClaimed is the final state of a party that unlocked the
+	// counterparty's PTLC.
+	StateClaimed
+	// StateRefunded is the final state of a party that reclaimed its own PTLC
+	// after expiry.
+	StateRefunded
+	// StateAborted is the final state of a party that gave up on the swap.
+	// A party that already created its PTLC must still reclaim it after
+	// expiry.
+	StateAborted
+)
+
+var (
+	// ErrInvalidState is returned when a step of the swap is not allowed in
+	// the current state of the party.
+	ErrInvalidState = errors.New("swap: step not allowed in current state")
+	// ErrInvalidPartialSignature is returned when a partial signature of the
+	// counterparty does not verify.
+	ErrInvalidPartialSignature = errors.New("swap: invalid partial signature")
+	// ErrInvalidSignature is returned when an adaptor or claim signature does
+	// not verify.
+	ErrInvalidSignature = errors.New("swap: invalid signature")
+	// ErrPtlcMismatch is returned when the counterparty's PTLC is not locked
+	// as agreed in the swap.
+	ErrPtlcMismatch = errors.New("swap: counterparty PTLC does not match the swap")
+	// ErrUnsafeExpiration is returned when PTLC2 does not expire at least
+	// ExpiryMargin before PTLC1.
+	ErrUnsafeExpiration = errors.New("swap: PTLC2 does not expire safely before PTLC1")
+	// ErrKeysChanged is returned when the counterparty's refreshed key
+	// exchange message does not carry the keys it announced before.
+	ErrKeysChanged = errors.New("swap: counterparty changed its keys")
+)
+
+// String returns a human-readable name of the state.
+func (s State) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateKeysExchanged:
+		return "keys exchanged"
+	case StatePtlcFunded:
+		return "PTLC funded"
+	case StatePreSignaturesExchanged:
+		return "pre-signatures exchanged"
+	case StateClaimed:
+		return "claimed"
+	case StateRefunded:
+		return "refunded"
+	case StateAborted:
+		return "aborted"
+	default:
+		return "State(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Final reports whether the protocol has ended in state s. A party aborted
+// after creating its PTLC must still reclaim it; the package function
+// [Ended] tells whether anything is left to do.
+func (s State) Final() bool {
+	return s == StateClaimed || s == StateRefunded || s == StateAborted
+}
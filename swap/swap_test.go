@@ -0,0 +1,392 @@
+package swap_test
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/kinggorrin/ptlc/transport"
+	"github.com/kinggorrin/ptlc/wire"
+	"github.com/zenon-network/go-zenon/common/types"
+	"github.com/zenon-network/go-zenon/vm/embedded/definition"
+)
+
+const (
+	testChainIdentifier = 3
+	testExpiration1     = 1_000_000 + 2*swap.ExpiryMargin
+	testExpiration2     = 1_000_000
+)
+
+var (
+	testTerms1 = swap.Terms{Token: types.ZnnTokenStandard, Amount: big.NewInt(1000000000)}
+	testTerms2 = swap.Terms{Token: types.QsrTokenStandard, Amount: big.NewInt(10000000000)}
+)
+
+// ledger stands in for the PTLC contract of the chain.
+type ledger struct {
+	mu      sync.Mutex
+	ptlcs   map[types.Hash]definition.PtlcInfo
+	unlocks map[types.Hash][]byte
+}
+
+func newLedger() *ledger {
+	return &ledger{ptlcs: make(map[types.Hash]definition.PtlcInfo), unlocks: make(map[types.Hash][]byte)}
+}
+
+func (l *ledger) create(owner types.Address, terms swap.Terms, expirationTime int64, lock ed25519.PublicKey) types.Hash {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(len(l.ptlcs)))
+	id := types.Hash(sha256.Sum256(append(owner.Bytes(), counter[:]...)))
+	l.ptlcs[id] = definition.PtlcInfo{
+		Id:             id,
+		TimeLocked:     owner,
+		TokenStandard:  terms.Token,
+		Amount:         new(big.Int).Set(terms.Amount),
+		ExpirationTime: expirationTime,
+		PointType:      definition.PointTypeED25519,
+		PointLock:      lock,
+	}
+	return id
+}
+
+func (l *ledger) get(id types.Hash) *definition.PtlcInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	info := l.ptlcs[id]
+	return &info
+}
+
+// unlock checks the signature as the contract does and publishes it.
+func (l *ledger) unlock(id types.Hash, destination types.Address, signature []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	message := swap.UnlockMessage(swap.MessageContract, testChainIdentifier, id, destination)
+	if !ed25519.Verify(ed25519.PublicKey(l.ptlcs[id].PointLock), message, signature) {
+		return errors.New("invalid unlock signature")
+	}
+	l.unlocks[id] = signature
+	return nil
+}
+
+func (l *ledger) unlockSignature(id types.Hash) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.unlocks[id]
+}
+
+// scenario selects how the initiator deviates from the protocol.
+type scenario struct {
+	// terms1 and expiration1 describe the PTLC the initiator creates.
+	terms1      swap.Terms
+	expiration1 int64
+	// swapLocks makes the initiator lock its PTLC with the key of the
+	// counterparty's PTLC.
+	swapLocks bool
+	// abort makes the initiator abort instead of continuing once both PTLCs
+	// exist.
+	abort bool
+}
+
+// errAborted is returned by a party whose counterparty aborted the swap.
+var errAborted = errors.New("counterparty aborted")
+
+func newTestKeys(t *testing.T, index uint32) *swap.Keys {
+	t.Helper()
+	keys := &swap.Keys{Index: index}
+	for _, key := range []*ed25519.Scalar{&keys.Key1, &keys.Key2, &keys.Adaptor} {
+		var err error
+		if *key, err = ed25519.RandomScalar(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func newTestAddress(t *testing.T) types.Address {
+	t.Helper()
+	publicKey, err := ed25519.RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return types.PubKeyToAddress(publicKey.ToCurvePoint())
+}
+
+func send(tr transport.Transport, message wire.Message) error {
+	b, err := wire.MarshalBinary(message)
+	if err != nil {
+		return err
+	}
+	return tr.Send(b)
+}
+
+func receive[M wire.Message](tr transport.Transport) (M, error) {
+	var zero M
+	b, err := tr.Receive()
+	if err != nil {
+		return zero, err
+	}
+	message, err := wire.UnmarshalBinary(b)
+	if err != nil {
+		return zero, err
+	}
+	if _, ok := message.(*wire.Abort); ok {
+		return zero, errAborted
+	}
+	m, ok := message.(M)
+	if !ok {
+		return zero, errors.New("unexpected " + message.Type().String() + " message")
+	}
+	return m, nil
+}
+
+// abort aborts the swap of party and tells the counterparty.
+func abort(tr transport.Transport, party interface{ Abort() error }, err error) error {
+	if abortErr := party.Abort(); abortErr != nil {
+		return abortErr
+	}
+	if err != errAborted {
+		send(tr, &wire.Abort{Reason: err.Error()})
+	}
+	return err
+}
+
+func runInitiator(tr transport.Transport, l *ledger, initiator *swap.Initiator, address, counterparty types.Address, s scenario) error {
+	defer tr.Close()
+
+	if err := send(tr, &wire.KeyExchange{KeyExchange: initiator.KeyExchange()}); err != nil {
+		return err
+	}
+	ke, err := receive[*wire.KeyExchange](tr)
+	if err != nil {
+		return abort(tr, initiator, err)
+	}
+	if err := initiator.ReceiveKeyExchange(counterparty, ke.KeyExchange); err != nil {
+		return abort(tr, initiator, err)
+	}
+
+	lock := initiator.LockKey()
+	if s.swapLocks {
+		lock = initiator.CounterpartyLockKey()
+	}
+	ptlc1 := l.create(address, s.terms1, s.expiration1, lock)
+	if err := initiator.Created(ptlc1); err != nil {
+		return err
+	}
+	if err := send(tr, &wire.PtlcFunded{Id: ptlc1}); err != nil {
+		return err
+	}
+	funded, err := receive[*wire.PtlcFunded](tr)
+	if err != nil {
+		return abort(tr, initiator, err)
+	}
+	if s.abort {
+		return abort(tr, initiator, errors.New("initiator gave up"))
+	}
+	if err := initiator.CheckCounterpartyPtlc(l.get(funded.Id), testTerms2, s.expiration1); err != nil {
+		return abort(tr, initiator, err)
+	}
+	if err := initiator.Funded(ptlc1, funded.Id); err != nil {
+		return err
+	}
+
+	sa1, sa2, err := initiator.PartialSignatures()
+	if err != nil {
+		return err
+	}
+	if err := send(tr, &wire.PartialChallenge{Partial1: sa1, Partial2: sa2}); err != nil {
+		return err
+	}
+	adaptorSig, err := receive[*wire.AdaptorSig](tr)
+	if err != nil {
+		return abort(tr, initiator, err)
+	}
+	claim, err := initiator.ReceivePartialSignature(adaptorSig.Partial)
+	if err != nil {
+		return abort(tr, initiator, err)
+	}
+	if err := l.unlock(funded.Id, address, claim); err != nil {
+		return err
+	}
+	if err := initiator.Claimed(); err != nil {
+		return err
+	}
+	return send(tr, &wire.Claimed{Id: funded.Id, Signature: claim})
+}
+
+func runResponder(tr transport.Transport, l *ledger, responder *swap.Responder, address, counterparty types.Address) error {
+	defer tr.Close()
+
+	ke, err := receive[*wire.KeyExchange](tr)
+	if err != nil {
+		return abort(tr, responder, err)
+	}
+	reply, err := responder.ReceiveKeyExchange(counterparty, ke.KeyExchange)
+	if err != nil {
+		return abort(tr, responder, err)
+	}
+	if err := send(tr, &wire.KeyExchange{KeyExchange: reply}); err != nil {
+		return err
+	}
+
+	funded, err := receive[*wire.PtlcFunded](tr)
+	if err != nil {
+		return abort(tr, responder, err)
+	}
+	if err := responder.CheckCounterpartyPtlc(l.get(funded.Id), testTerms1, testExpiration2); err != nil {
+		return abort(tr, responder, err)
+	}
+	ptlc2 := l.create(address, testTerms2, testExpiration2, responder.LockKey())
+	if err := responder.Created(ptlc2); err != nil {
+		return err
+	}
+	if err := responder.Funded(ptlc2, funded.Id); err != nil {
+		return err
+	}
+	if err := send(tr, &wire.PtlcFunded{Id: ptlc2}); err != nil {
+		return err
+	}
+
+	challenge, err := receive[*wire.PartialChallenge](tr)
+	if err != nil {
+		return abort(tr, responder, err)
+	}
+	sb1, err := responder.ReceivePartialSignatures(challenge.Partial1, challenge.Partial2)
+	if err != nil {
+		return abort(tr, responder, err)
+	}
+	if err := send(tr, &wire.AdaptorSig{Partial: sb1}); err != nil {
+		return err
+	}
+
+	// The claim is read from the chain; the message only says when.
+	if _, err := receive[*wire.Claimed](tr); err != nil {
+		return err
+	}
+	claim, err := responder.ReceiveSignature(l.unlockSignature(ptlc2))
+	if err != nil {
+		return err
+	}
+	if err := l.unlock(funded.Id, address, claim); err != nil {
+		return err
+	}
+	return responder.Claimed()
+}
+
+// runSwap runs a swap between an initiator and a responder connected by a
+// pipe and returns the errors of both parties and the parties themselves.
+func runSwap(t *testing.T, s scenario) (initiatorErr, responderErr error, initiator *swap.Initiator, responder *swap.Responder) {
+	t.Helper()
+	addressA, addressB := newTestAddress(t), newTestAddress(t)
+	initiator, err := swap.NewInitiator(nil, newTestKeys(t, 0), addressA, testChainIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder = swap.NewResponder(nil, newTestKeys(t, 0), addressB, testChainIdentifier)
+
+	l := newLedger()
+	trA, trB := transport.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- runResponder(trB, l, responder, addressB, addressA)
+	}()
+	initiatorErr = runInitiator(trA, l, initiator, addressA, addressB, s)
+	responderErr = <-done
+	return initiatorErr, responderErr, initiator, responder
+}
+
+func TestSwap(t *testing.T) {
+	initiatorErr, responderErr, initiator, responder := runSwap(t, scenario{terms1: testTerms1, expiration1: testExpiration1})
+	if initiatorErr != nil || responderErr != nil {
+		t.Fatalf("swap failed: initiator: %v, responder: %v", initiatorErr, responderErr)
+	}
+	if initiator.State() != swap.StateClaimed || responder.State() != swap.StateClaimed {
+		t.Fatalf("swap ended in %v and %v, want %v", initiator.State(), responder.State(), swap.StateClaimed)
+	}
+}
+
+func TestSwapAbort(t *testing.T) {
+	initiatorErr, responderErr, initiator, responder := runSwap(t, scenario{terms1: testTerms1, expiration1: testExpiration1, abort: true})
+	if initiatorErr == nil || responderErr != errAborted {
+		t.Fatalf("got initiator: %v, responder: %v", initiatorErr, responderErr)
+	}
+	if initiator.State() != swap.StateAborted || responder.State() != swap.StateAborted {
+		t.Fatalf("swap ended in %v and %v, want %v", initiator.State(), responder.State(), swap.StateAborted)
+	}
+
+	// Both created their PTLCs, which they still have to reclaim.
+	if own, _ := initiator.Ptlcs(); own.IsZero() {
+		t.Fatal("initiator forgot its PTLC")
+	}
+	if own, _ := responder.Ptlcs(); own.IsZero() {
+		t.Fatal("responder forgot its PTLC")
+	}
+	if swap.Ended(initiator.State(), initiator.Snapshot()) || swap.Ended(responder.State(), responder.Snapshot()) {
+		t.Fatal("swap with PTLCs to reclaim ended")
+	}
+
+	if err := initiator.Refunded(); err != nil {
+		t.Fatal(err)
+	}
+	if !swap.Ended(initiator.State(), initiator.Snapshot()) {
+		t.Fatal("refunded swap did not end")
+	}
+}
+
+func TestSwapWrongPtlc(t *testing.T) {
+	tests := []struct {
+		name string
+		s    scenario
+		err  error
+	}{
+		{
+			name: "token",
+			s:    scenario{terms1: swap.Terms{Token: types.QsrTokenStandard, Amount: testTerms1.Amount}, expiration1: testExpiration1},
+			err:  swap.ErrPtlcMismatch,
+		},
+		{
+			name: "amount",
+			s:    scenario{terms1: swap.Terms{Token: testTerms1.Token, Amount: big.NewInt(1)}, expiration1: testExpiration1},
+			err:  swap.ErrPtlcMismatch,
+		},
+		{
+			name: "lock",
+			s:    scenario{terms1: testTerms1, expiration1: testExpiration1, swapLocks: true},
+			err:  swap.ErrPtlcMismatch,
+		},
+		{
+			name: "expiration",
+			s:    scenario{terms1: testTerms1, expiration1: testExpiration2 + swap.ExpiryMargin - 1},
+			err:  swap.ErrUnsafeExpiration,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			initiatorErr, responderErr, initiator, responder := runSwap(t, test.s)
+			if responderErr != test.err || initiatorErr != errAborted {
+				t.Fatalf("got initiator: %v, responder: %v, want responder: %v", initiatorErr, responderErr, test.err)
+			}
+			if initiator.State() != swap.StateAborted || responder.State() != swap.StateAborted {
+				t.Fatalf("swap ended in %v and %v, want %v", initiator.State(), responder.State(), swap.StateAborted)
+			}
+			if own, _ := responder.Ptlcs(); !own.IsZero() {
+				t.Fatal("responder created its PTLC")
+			}
+			if !swap.Ended(responder.State(), responder.Snapshot()) {
+				t.Fatal("swap without a PTLC to reclaim did not end")
+			}
+			if swap.Ended(initiator.State(), initiator.Snapshot()) {
+				t.Fatal("swap with a PTLC to reclaim ended")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+)
+
+// conn frames messages over a byte stream.
+type conn struct {
+	rw io.ReadWriteCloser
+}
+
+// NewConn returns a transport over a reliable byte stream such as a TCP
+// connection. Every message is prefixed with its length as 4 big-endian
+// bytes.
+func NewConn(rw io.ReadWriteCloser) Transport {
+	return &conn{rw: rw}
+}
+
+// Dial connects to the TCP address of the counterparty.
+func Dial(address string) (Transport, error) {
+	c, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c), nil
+}
+
+// Listener accepts TCP connections from counterparties.
+type Listener struct {
+	listener net.Listener
+}
+
+// Listen listens for counterparties on the TCP address.
+func Listen(address string) (*Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{listener: listener}, nil
+}
+
+// Accept waits for the next counterparty to connect.
+func (l *Listener) Accept() (Transport, error) {
+	c, err := l.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c), nil
+}
+
+// Addr returns the address the listener listens on.
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Close stops listening. Accepted transports are not closed.
+func (l *Listener) Close() error {
+	return l.listener.Close()
+}
+
+func (c *conn) Send(message []byte) error {
+	if len(message) > MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+
+	frame := make([]byte, 4, 4+len(message))
+	binary.BigEndian.PutUint32(frame, uint32(len(message)))
+	frame = append(frame, message...)
+	_, err := c.rw.Write(frame)
+	return closedError(err)
+}
+
+func (c *conn) Receive() ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+		return nil, closedError(err)
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxMessageSize {
+		return nil, ErrMessageTooLarge
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(c.rw, message); err != nil {
+		return nil, closedError(err)
+	}
+	return message, nil
+}
+
+func (c *conn) Close() error {
+	return c.rw.Close()
+}
+
+// closedError maps the errors of a stream that has been closed by either
+// side to ErrClosed.
+func closedError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// tcpPair returns a transport dialed to a loopback listener and the raw
+// connection the listener accepted for it.
+func tcpPair(t *testing.T) (Transport, net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- c
+	}()
+	tr, err := Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := <-accepted
+	if !ok {
+		t.Fatal("listener accepted no connection")
+	}
+	t.Cleanup(func() {
+		tr.Close()
+		c.Close()
+	})
+	return tr, c
+}
+
+func TestConn(t *testing.T) {
+	listener, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan Transport, 1)
+	go func() {
+		tr, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- tr
+	}()
+	trA, err := Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trA.Close()
+	trB, ok := <-accepted
+	if !ok {
+		t.Fatal("listener accepted no transport")
+	}
+	defer trB.Close()
+
+	messages := [][]byte{[]byte("PTLC"), {}, bytes.Repeat([]byte{0xab}, MaxMessageSize)}
+	done := make(chan error, 1)
+	go func() {
+		for _, message := range messages {
+			if err := trA.Send(message); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i, want := range messages {
+		got, err := trB.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("message %d changed in transit", i)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trB.Send([]byte("reply")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := trA.Receive(); err != nil || !bytes.Equal(got, []byte("reply")) {
+		t.Fatalf("Receive returned %q, %v", got, err)
+	}
+}
+
+func TestConnMessageTooLarge(t *testing.T) {
+	tr, c := tcpPair(t)
+	if err := tr.Send(make([]byte, MaxMessageSize+1)); err != ErrMessageTooLarge {
+		t.Fatalf("Send of an oversize message returned %v, want %v", err, ErrMessageTooLarge)
+	}
+
+	// The counterparty announces a frame larger than MaxMessageSize, which
+	// must be rejected before its body is read.
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], MaxMessageSize+1)
+	if _, err := c.Write(header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.Receive(); err != ErrMessageTooLarge {
+		t.Fatalf("Receive of an oversize frame returned %v, want %v", err, ErrMessageTooLarge)
+	}
+}
+
+func TestConnClosed(t *testing.T) {
+	t.Run("truncated frame", func(t *testing.T) {
+		tr, c := tcpPair(t)
+		var header [4]byte
+		binary.BigEndian.PutUint32(header[:], 8)
+		if _, err := c.Write(append(header[:], "PTLC"...)); err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+		if _, err := tr.Receive(); err != ErrClosed {
+			t.Fatalf("Receive of a truncated frame returned %v, want %v", err, ErrClosed)
+		}
+	})
+	t.Run("closed by counterparty", func(t *testing.T) {
+		tr, c := tcpPair(t)
+		c.Close()
+		if _, err := tr.Receive(); err != ErrClosed {
+			t.Fatalf("Receive after the counterparty closed returned %v, want %v", err, ErrClosed)
+		}
+	})
+	t.Run("closed locally", func(t *testing.T) {
+		tr, _ := tcpPair(t)
+		tr.Close()
+		if err := tr.Send([]byte("PTLC")); err != ErrClosed {
+			t.Fatalf("Send after Close returned %v, want %v", err, ErrClosed)
+		}
+		if _, err := tr.Receive(); err != ErrClosed {
+			t.Fatalf("Receive after Close returned %v, want %v", err, ErrClosed)
+		}
+	})
+}
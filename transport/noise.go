@@ -0,0 +1,178 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+)
+
+// This file implements the primitives of the Noise protocol framework
+// (https://noiseprotocol.org/noise.html, revision 34) for the cipher suite
+// 25519_ChaChaPoly_SHA256, as far as the XX pattern needs them.
+
+// noiseProtocolName names the handshake pattern and cipher suite. It is
+// exactly 32 bytes long, so it is used as the initial handshake hash as is.
+const noiseProtocolName = "Noise_XX_25519_ChaChaPoly_SHA256"
+
+// noiseKeySize is the size of X25519 keys, hashes and cipher keys.
+const noiseKeySize = 32
+
+// errNonceExhausted is returned when a cipher state ran out of nonces.
+var errNonceExhausted = errors.New("transport: nonces exhausted")
+
+// cipherState encrypts or decrypts messages with a key and a counter nonce.
+// It encrypts without authentication only before a key is set, which happens
+// in the first handshake message.
+type cipherState struct {
+	key   []byte
+	nonce uint64
+}
+
+func (c *cipherState) encrypt(ad, plaintext []byte) ([]byte, error) {
+	if c.key == nil {
+		return append([]byte(nil), plaintext...), nil
+	}
+	if c.nonce == math.MaxUint64 {
+		return nil, errNonceExhausted
+	}
+
+	aead, err := chacha20poly1305.New(c.key)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := aead.Seal(nil, c.nonceBytes(), plaintext, ad)
+	c.nonce++
+	return ciphertext, nil
+}
+
+func (c *cipherState) decrypt(ad, ciphertext []byte) ([]byte, error) {
+	if c.key == nil {
+		return append([]byte(nil), ciphertext...), nil
+	}
+	if c.nonce == math.MaxUint64 {
+		return nil, errNonceExhausted
+	}
+
+	aead, err := chacha20poly1305.New(c.key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, c.nonceBytes(), ciphertext, ad)
+	if err != nil {
+		return nil, ErrAuthentication
+	}
+	c.nonce++
+	return plaintext, nil
+}
+
+// nonceBytes encodes the counter as 4 zero bytes followed by the counter in
+// little-endian byte order.
+func (c *cipherState) nonceBytes() []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[4:], c.nonce)
+	return nonce
+}
+
+// symmetricState holds the chaining key and the handshake hash, which
+// commits to the whole transcript of the handshake.
+type symmetricState struct {
+	cipherState
+	ck []byte
+	h  []byte
+}
+
+func newSymmetricState(prologue []byte) *symmetricState {
+	s := &symmetricState{h: []byte(noiseProtocolName)}
+	s.ck = s.h
+	s.mixHash(prologue)
+	return s
+}
+
+func (s *symmetricState) mixHash(data []byte) {
+	hash := sha256.New()
+	hash.Write(s.h)
+	hash.Write(data)
+	s.h = hash.Sum(nil)
+}
+
+func (s *symmetricState) mixKey(ikm []byte) {
+	var key []byte
+	s.ck, key = noiseHKDF(s.ck, ikm)
+	s.cipherState = cipherState{key: key}
+}
+
+func (s *symmetricState) encryptAndHash(plaintext []byte) ([]byte, error) {
+	ciphertext, err := s.encrypt(s.h, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+	return ciphertext, nil
+}
+
+func (s *symmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	plaintext, err := s.decrypt(s.h, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	s.mixHash(ciphertext)
+	return plaintext, nil
+}
+
+// split returns the cipher states of the transport messages sent by the
+// initiator and by the responder.
+func (s *symmetricState) split() (initiator, responder *cipherState) {
+	k1, k2 := noiseHKDF(s.ck, nil)
+	return &cipherState{key: k1}, &cipherState{key: k2}
+}
+
+// noiseHKDF derives two keys from the chaining key ck and the input key
+// material ikm.
+func noiseHKDF(ck, ikm []byte) ([]byte, []byte) {
+	prk := noiseHMAC(ck, ikm)
+	out1 := noiseHMAC(prk, []byte{1})
+	out2 := noiseHMAC(prk, out1, []byte{2})
+	return out1, out2
+}
+
+func noiseHMAC(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// dhKey is an X25519 key pair.
+type dhKey struct {
+	private []byte
+	public  []byte
+}
+
+func generateDHKey(rand io.Reader) (*dhKey, error) {
+	private := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand, private); err != nil {
+		return nil, err
+	}
+	public, err := curve25519.X25519(private, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	return &dhKey{private: private, public: public}, nil
+}
+
+// dh returns the X25519 shared secret of the key and the public key of the
+// peer. Public keys of low order are rejected.
+func (k *dhKey) dh(public []byte) ([]byte, error) {
+	secret, err := curve25519.X25519(k.private, public)
+	if err != nil {
+		return nil, ErrHandshake
+	}
+	return secret, nil
+}
@@ -0,0 +1,50 @@
+package transport
+
+import "sync"
+
+// pipe is one end of an in-memory transport.
+type pipe struct {
+	send    chan<- []byte
+	receive <-chan []byte
+	done    chan struct{}
+	once    *sync.Once
+}
+
+// Pipe returns the two ends of an unbuffered in-memory transport. Every
+// Send blocks until the other end receives the message. Closing either end
+// closes both.
+func Pipe() (Transport, Transport) {
+	c1 := make(chan []byte)
+	c2 := make(chan []byte)
+	done := make(chan struct{})
+	once := new(sync.Once)
+	return &pipe{send: c1, receive: c2, done: done, once: once},
+		&pipe{send: c2, receive: c1, done: done, once: once}
+}
+
+func (p *pipe) Send(message []byte) error {
+	if len(message) > MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+
+	select {
+	case p.send <- append([]byte(nil), message...):
+		return nil
+	case <-p.done:
+		return ErrClosed
+	}
+}
+
+func (p *pipe) Receive() ([]byte, error) {
+	select {
+	case message := <-p.receive:
+		return message, nil
+	case <-p.done:
+		return nil, ErrClosed
+	}
+}
+
+func (p *pipe) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
@@ -0,0 +1,271 @@
+package transport
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+
+	"github.com/zenon-network/go-zenon/common/types"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// noisePrologue is mixed into the handshake hash, so that the handshake
+// fails between peers of different protocols.
+const noisePrologue = "PTLC/transport/1"
+
+// identityContext is prepended to the static Noise key signed with the
+// identity key.
+const identityContext = "PTLC/transport/noise/identity"
+
+// identitySize is the size of an identity payload: the ed25519 public key
+// and its signature of the static Noise key.
+const identitySize = ed25519.PublicKeySize + ed25519.SignatureSize
+
+var (
+	// ErrHandshake is returned when the handshake with the counterparty
+	// fails.
+	ErrHandshake = errors.New("transport: handshake failed")
+	// ErrAuthentication is returned for messages that were not sent by the
+	// counterparty of the handshake or were tampered with.
+	ErrAuthentication = errors.New("transport: message authentication failed")
+)
+
+// Secure is a transport that encrypts and authenticates every message with
+// the keys of a Noise XX handshake.
+type Secure struct {
+	tr      Transport
+	send    *cipherState
+	receive *cipherState
+	peer    ed25519.PublicKey
+	// failed is set once a message failed authentication.
+	failed bool
+}
+
+// Handshake runs the Noise_XX_25519_ChaChaPoly_SHA256 handshake over tr and
+// returns the secure transport on top of it. Exactly one of the peers must be
+// the initiator; by convention it is the one that dialed.
+//
+// Each peer is identified by the static ed25519 key passed as key, such as
+// the key of its Zenon wallet. Its static Noise key is generated for the
+// connection and signed with the identity key, and the signature is sent
+// encrypted in the handshake, so that only the counterparty learns the
+// identity. Callers compare PeerKey or PeerAddress against the expected
+// counterparty before trusting it.
+func Handshake(tr Transport, key ed25519.PrivateKey, initiator bool) (*Secure, error) {
+	e, err := generateDHKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	s, err := generateDHKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	identity := signIdentity(key, s.public)
+	state := newSymmetricState([]byte(noisePrologue))
+
+	if initiator {
+		return handshakeInitiator(tr, state, e, s, identity)
+	}
+	return handshakeResponder(tr, state, e, s, identity)
+}
+
+// handshakeInitiator runs the handshake as initiator:
+//
+//	-> e
+//	<- e, ee, s, es
+//	-> s, se
+func handshakeInitiator(tr Transport, state *symmetricState, e, s *dhKey, identity []byte) (*Secure, error) {
+	state.mixHash(e.public)
+	payload, err := state.encryptAndHash(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.Send(append(e.public, payload...)); err != nil {
+		return nil, err
+	}
+
+	message, err := tr.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if len(message) != noiseKeySize+noiseKeySize+chacha20poly1305.Overhead+identitySize+chacha20poly1305.Overhead {
+		return nil, ErrHandshake
+	}
+	re := message[:noiseKeySize]
+	state.mixHash(re)
+	if err := mixDH(state, e, re); err != nil {
+		return nil, err
+	}
+	rs, err := state.decryptAndHash(message[noiseKeySize : 2*noiseKeySize+chacha20poly1305.Overhead])
+	if err != nil {
+		return nil, ErrHandshake
+	}
+	if err := mixDH(state, e, rs); err != nil {
+		return nil, err
+	}
+	payload, err = state.decryptAndHash(message[2*noiseKeySize+chacha20poly1305.Overhead:])
+	if err != nil {
+		return nil, ErrHandshake
+	}
+	peer, err := verifyIdentity(payload, rs)
+	if err != nil {
+		return nil, err
+	}
+
+	static, err := state.encryptAndHash(s.public)
+	if err != nil {
+		return nil, err
+	}
+	if err := mixDH(state, s, re); err != nil {
+		return nil, err
+	}
+	payload, err = state.encryptAndHash(identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := tr.Send(append(static, payload...)); err != nil {
+		return nil, err
+	}
+
+	send, receive := state.split()
+	return &Secure{tr: tr, send: send, receive: receive, peer: peer}, nil
+}
+
+// handshakeResponder runs the handshake as responder.
+func handshakeResponder(tr Transport, state *symmetricState, e, s *dhKey, identity []byte) (*Secure, error) {
+	message, err := tr.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if len(message) != noiseKeySize {
+		return nil, ErrHandshake
+	}
+	re := message
+	state.mixHash(re)
+	if _, err := state.decryptAndHash(nil); err != nil {
+		return nil, ErrHandshake
+	}
+
+	state.mixHash(e.public)
+	if err := mixDH(state, e, re); err != nil {
+		return nil, err
+	}
+	static, err := state.encryptAndHash(s.public)
+	if err != nil {
+		return nil, err
+	}
+	if err := mixDH(state, s, re); err != nil {
+		return nil, err
+	}
+	payload, err := state.encryptAndHash(identity)
+	if err != nil {
+		return nil, err
+	}
+	message = append(append(e.public, static...), payload...)
+	if err := tr.Send(message); err != nil {
+		return nil, err
+	}
+
+	message, err = tr.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if len(message) != noiseKeySize+chacha20poly1305.Overhead+identitySize+chacha20poly1305.Overhead {
+		return nil, ErrHandshake
+	}
+	rs, err := state.decryptAndHash(message[:noiseKeySize+chacha20poly1305.Overhead])
+	if err != nil {
+		return nil, ErrHandshake
+	}
+	if err := mixDH(state, e, rs); err != nil {
+		return nil, err
+	}
+	payload, err = state.decryptAndHash(message[noiseKeySize+chacha20poly1305.Overhead:])
+	if err != nil {
+		return nil, ErrHandshake
+	}
+	peer, err := verifyIdentity(payload, rs)
+	if err != nil {
+		return nil, err
+	}
+
+	receive, send := state.split()
+	return &Secure{tr: tr, send: send, receive: receive, peer: peer}, nil
+}
+
+// mixDH mixes the shared secret of key and the peer's public key into the
+// chaining key.
+func mixDH(state *symmetricState, key *dhKey, public []byte) error {
+	secret, err := key.dh(public)
+	if err != nil {
+		return err
+	}
+	state.mixKey(secret)
+	return nil
+}
+
+// signIdentity returns the identity payload that binds the static Noise key
+// to the ed25519 identity key.
+func signIdentity(key ed25519.PrivateKey, static []byte) []byte {
+	signature := ed25519.Sign(key, append([]byte(identityContext), static...))
+	return append(append([]byte(nil), key.Public().(ed25519.PublicKey)...), signature...)
+}
+
+// verifyIdentity checks the identity payload of the peer against its static
+// Noise key and returns its identity key.
+func verifyIdentity(payload, static []byte) (ed25519.PublicKey, error) {
+	if len(payload) != identitySize {
+		return nil, ErrHandshake
+	}
+	peer := ed25519.PublicKey(payload[:ed25519.PublicKeySize])
+	if !ed25519.Verify(peer, append([]byte(identityContext), static...), payload[ed25519.PublicKeySize:]) {
+		return nil, ErrHandshake
+	}
+	return peer, nil
+}
+
+// PeerKey returns the ed25519 identity key of the counterparty.
+func (s *Secure) PeerKey() ed25519.PublicKey {
+	return s.peer
+}
+
+// PeerAddress returns the Zenon address of the counterparty's identity key.
+func (s *Secure) PeerAddress() types.Address {
+	return types.PubKeyToAddress(s.peer)
+}
+
+// Send encrypts message and delivers it to the counterparty.
+func (s *Secure) Send(message []byte) error {
+	if len(message) > MaxMessageSize-chacha20poly1305.Overhead {
+		return ErrMessageTooLarge
+	}
+	ciphertext, err := s.send.encrypt(nil, message)
+	if err != nil {
+		return err
+	}
+	return s.tr.Send(ciphertext)
+}
+
+// Receive blocks until the next message of the counterparty arrives and
+// decrypts it. A message that fails authentication is rejected with
+// ErrAuthentication, and so are all later ones, because the message stream
+// of the counterparty is no longer intact.
+func (s *Secure) Receive() ([]byte, error) {
+	if s.failed {
+		return nil, ErrAuthentication
+	}
+	ciphertext, err := s.tr.Receive()
+	if err != nil {
+		return nil, err
+	}
+	message, err := s.receive.decrypt(nil, ciphertext)
+	if err == ErrAuthentication {
+		s.failed = true
+	}
+	return message, err
+}
+
+// Close closes the underlying transport.
+func (s *Secure) Close() error {
+	return s.tr.Close()
+}
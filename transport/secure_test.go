@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"testing"
+)
+
+// securePipe returns the two ends of a pipe after the handshake.
+func securePipe(t *testing.T) (initiator, responder *Secure) {
+	t.Helper()
+	_, keyA, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, keyB, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trA, trB := Pipe()
+	done := make(chan error)
+	go func() {
+		var err error
+		responder, err = Handshake(trB, keyB, false)
+		done <- err
+	}()
+	initiator, err = Handshake(trA, keyA, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !initiator.PeerKey().Equal(keyB.Public()) || !responder.PeerKey().Equal(keyA.Public()) {
+		t.Fatal("peers authenticated with the wrong keys")
+	}
+	return initiator, responder
+}
+
+func TestSecure(t *testing.T) {
+	initiator, responder := securePipe(t)
+	defer initiator.Close()
+
+	go initiator.Send([]byte("hello"))
+	message, err := responder.Receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(message, []byte("hello")) {
+		t.Fatalf("received %q", message)
+	}
+}
+
+func TestSecureAuthenticationFailureSticks(t *testing.T) {
+	initiator, responder := securePipe(t)
+	defer initiator.Close()
+
+	go func() {
+		// A tampered message followed by an intact one.
+		initiator.tr.Send(bytes.Repeat([]byte{0xff}, 32))
+		initiator.Send([]byte("hello"))
+	}()
+	for i := 0; i < 2; i++ {
+		if _, err := responder.Receive(); err != ErrAuthentication {
+			t.Fatalf("message %d: got %v, want %v", i, err, ErrAuthentication)
+		}
+	}
+}
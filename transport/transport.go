@@ -0,0 +1,37 @@
+// Package transport carries the messages of a swap between its two parties.
+//
+// A Transport delivers whole messages in order. The in-memory Pipe connects
+// two parties in one process; NewConn frames messages over a stream such as
+// a TCP connection, and the WebSocket transport uses one WebSocket message
+// per swap message, so that the parties can run on different machines.
+//
+// Handshake secures any of them with a Noise XX handshake: the returned
+// transport encrypts and authenticates every message, and identifies the
+// counterparty by its ed25519 key and Zenon address.
+package transport
+
+import "errors"
+
+// MaxMessageSize is the largest message, in bytes, that a transport accepts.
+const MaxMessageSize = 1 << 20
+
+var (
+	// ErrClosed is returned when sending or receiving on a closed transport.
+	ErrClosed = errors.New("transport: closed")
+	// ErrMessageTooLarge is returned for messages larger than
+	// MaxMessageSize.
+	ErrMessageTooLarge = errors.New("transport: message too large")
+)
+
+// Transport is a bidirectional, ordered and reliable message channel to the
+// counterparty of a swap. Send and Receive may be called concurrently with
+// each other, but not with themselves.
+type Transport interface {
+	// Send delivers message to the counterparty.
+	Send(message []byte) error
+	// Receive blocks until the next message of the counterparty arrives.
+	Receive() ([]byte, error)
+	// Close closes the transport. Pending and later calls to Send and
+	// Receive fail.
+	Close() error
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocket sends every message as one binary WebSocket message.
+type webSocket struct {
+	conn *websocket.Conn
+}
+
+// DialWebSocket connects to the WebSocket URL of the counterparty, for
+// example "ws://host:port/swap".
+func DialWebSocket(url string) (Transport, error) {
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newWebSocket(c), nil
+}
+
+// WebSocketHandler returns an HTTP handler that upgrades every request to a
+// WebSocket and passes the resulting transport to accept, which owns it.
+func WebSocketHandler(accept func(Transport)) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		accept(newWebSocket(c))
+	})
+}
+
+func newWebSocket(c *websocket.Conn) Transport {
+	c.SetReadLimit(MaxMessageSize)
+	return &webSocket{conn: c}
+}
+
+func (ws *webSocket) Send(message []byte) error {
+	if len(message) > MaxMessageSize {
+		return ErrMessageTooLarge
+	}
+	return webSocketError(ws.conn.WriteMessage(websocket.BinaryMessage, message))
+}
+
+func (ws *webSocket) Receive() ([]byte, error) {
+	for {
+		messageType, message, err := ws.conn.ReadMessage()
+		if err != nil {
+			return nil, webSocketError(err)
+		}
+		if messageType == websocket.BinaryMessage {
+			return message, nil
+		}
+	}
+}
+
+func (ws *webSocket) Close() error {
+	return ws.conn.Close()
+}
+
+// webSocketError maps the errors of a closed WebSocket to ErrClosed.
+func webSocketError(err error) error {
+	if errors.Is(err, websocket.ErrReadLimit) {
+		return ErrMessageTooLarge
+	}
+	if _, ok := err.(*websocket.CloseError); ok || errors.Is(err, websocket.ErrCloseSent) {
+		return ErrClosed
+	}
+	return closedError(err)
+}
@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketServer serves WebSocketHandler on a loopback address and returns
+// its URL and the transports it accepts.
+func webSocketServer(t *testing.T) (string, <-chan Transport) {
+	t.Helper()
+	accepted := make(chan Transport, 1)
+	server := httptest.NewServer(WebSocketHandler(func(tr Transport) {
+		accepted <- tr
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), accepted
+}
+
+func TestWebSocket(t *testing.T) {
+	url, accepted := webSocketServer(t)
+	trA, err := DialWebSocket(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer trA.Close()
+	trB := <-accepted
+	defer trB.Close()
+
+	messages := [][]byte{[]byte("PTLC"), {}, bytes.Repeat([]byte{0xab}, MaxMessageSize)}
+	done := make(chan error, 1)
+	go func() {
+		for _, message := range messages {
+			if err := trA.Send(message); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for i, want := range messages {
+		got, err := trB.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("message %d changed in transit", i)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := trB.Send([]byte("reply")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := trA.Receive(); err != nil || !bytes.Equal(got, []byte("reply")) {
+		t.Fatalf("Receive returned %q, %v", got, err)
+	}
+
+	trA.Close()
+	if _, err := trB.Receive(); err != ErrClosed {
+		t.Fatalf("Receive after the counterparty closed returned %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestWebSocketSkipsTextMessages(t *testing.T) {
+	url, accepted := webSocketServer(t)
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tr := <-accepted
+	defer tr.Close()
+
+	if err := c.WriteMessage(websocket.TextMessage, []byte("text")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteMessage(websocket.BinaryMessage, []byte("PTLC")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := tr.Receive(); err != nil || !bytes.Equal(got, []byte("PTLC")) {
+		t.Fatalf("Receive returned %q, %v", got, err)
+	}
+}
+
+func TestWebSocketMessageTooLarge(t *testing.T) {
+	url, accepted := webSocketServer(t)
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	tr := <-accepted
+	defer tr.Close()
+
+	if err := tr.Send(make([]byte, MaxMessageSize+1)); err != ErrMessageTooLarge {
+		t.Fatalf("Send of an oversize message returned %v, want %v", err, ErrMessageTooLarge)
+	}
+
+	// The counterparty sends a message larger than MaxMessageSize, which
+	// must hit the read limit.
+	go c.WriteMessage(websocket.BinaryMessage, make([]byte, MaxMessageSize+1))
+	if _, err := tr.Receive(); err != ErrMessageTooLarge {
+		t.Fatalf("Receive of an oversize message returned %v, want %v", err, ErrMessageTooLarge)
+	}
+}
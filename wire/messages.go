@@ -0,0 +1,344 @@
+package wire
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// MaxReasonSize is the largest reason, in bytes, of an Abort message.
+const MaxReasonSize = 256
+
+// Hello opens a swap and announces the Zenon address of its sender, which
+// receives the counterparty's PTLC. Its body is the 20-byte address.
+type Hello struct {
+	Address types.Address
+}
+
+// KeyExchange carries the keys, nonces and proofs of possession of its
+// sender. Its body is the encoding of swap.KeyExchange.
+type KeyExchange struct {
+	*swap.KeyExchange
+}
+
+// PtlcFunded announces the id of the PTLC its sender created. Its body is
+// the 32-byte id.
+type PtlcFunded struct {
+	Id types.Hash
+}
+
+// PartialChallenge carries the initiator's partial signatures sa1 and sa2,
+// its responses to the challenges of both signing sessions. Its body is
+// sa1 || sa2.
+type PartialChallenge struct {
+	Partial1 ed25519.Scalar
+	Partial2 ed25519.Scalar
+}
+
+// AdaptorSig carries the responder's partial signature sb1, which completes
+// the initiator's adaptor signature. Its body is the 32-byte sb1.
+type AdaptorSig struct {
+	Partial ed25519.Scalar
+}
+
+// Claimed announces that its sender unlocked the PTLC with the given id and
+// carries the signature, from which the counterparty extracts the adaptor
+// secret. Its body is id || signature.
+type Claimed struct {
+	Id        types.Hash
+	Signature []byte
+}
+
+// Abort ends the swap. Its body is the UTF-8 reason, at most MaxReasonSize
+// bytes. A longer reason is truncated when the message is encoded, and
+// invalid UTF-8 is replaced with U+FFFD.
+type Abort struct {
+	Reason string
+}
+
+func (*Hello) Type() Type            { return TypeHello }
+func (*KeyExchange) Type() Type      { return TypeKeyExchange }
+func (*PtlcFunded) Type() Type       { return TypePtlcFunded }
+func (*PartialChallenge) Type() Type { return TypePartialChallenge }
+func (*AdaptorSig) Type() Type       { return TypeAdaptorSig }
+func (*Claimed) Type() Type          { return TypeClaimed }
+func (*Abort) Type() Type            { return TypeAbort }
+
+func (m *Hello) appendBody(b []byte) ([]byte, error) {
+	return append(b, m.Address.Bytes()...), nil
+}
+
+func (m *Hello) parseBody(body []byte) error {
+	address, err := types.BytesToAddress(body)
+	if err != nil {
+		return ErrMalformed
+	}
+	m.Address = address
+	return nil
+}
+
+type helloJSON struct {
+	Address types.Address `json:"address"`
+}
+
+func (m *Hello) jsonBody() (any, error) {
+	return helloJSON{Address: m.Address}, nil
+}
+
+func (m *Hello) parseJSONBody(body json.RawMessage) error {
+	var j helloJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	m.Address = j.Address
+	return nil
+}
+
+func (m *KeyExchange) appendBody(b []byte) ([]byte, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return append(b, m.Bytes()...), nil
+}
+
+// check returns ErrMalformed if a field that the encoding requires is
+// missing.
+func (m *KeyExchange) check() error {
+	if m.KeyExchange == nil || m.Proof1 == nil || m.Proof2 == nil || (m.AdaptorPoint != nil && m.AdaptorProof == nil) {
+		return ErrMalformed
+	}
+	return nil
+}
+
+func (m *KeyExchange) parseBody(body []byte) error {
+	ke, err := swap.ParseKeyExchange(body)
+	if err != nil {
+		return err
+	}
+	m.KeyExchange = ke
+	return nil
+}
+
+type keyExchangeJSON struct {
+	PublicKey1   hexBytes `json:"publicKey1"`
+	PublicKey2   hexBytes `json:"publicKey2"`
+	AdaptorPoint hexBytes `json:"adaptorPoint,omitempty"`
+	Nonce1       hexBytes `json:"nonce1"`
+	Nonce2       hexBytes `json:"nonce2"`
+	Proof1       hexBytes `json:"proof1"`
+	Proof2       hexBytes `json:"proof2"`
+	AdaptorProof hexBytes `json:"adaptorProof,omitempty"`
+}
+
+func (m *KeyExchange) jsonBody() (any, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	j := keyExchangeJSON{
+		PublicKey1:   hexBytes(m.PublicKey1),
+		PublicKey2:   hexBytes(m.PublicKey2),
+		AdaptorPoint: hexBytes(m.AdaptorPoint),
+		Nonce1:       hexBytes(m.Nonce1),
+		Nonce2:       hexBytes(m.Nonce2),
+		Proof1:       m.Proof1.Bytes(),
+		Proof2:       m.Proof2.Bytes(),
+	}
+	if m.AdaptorProof != nil {
+		j.AdaptorProof = m.AdaptorProof.Bytes()
+	}
+	return j, nil
+}
+
+func (m *KeyExchange) parseJSONBody(body json.RawMessage) error {
+	var j keyExchangeJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+
+	encoded := []byte{0}
+	if j.AdaptorPoint != nil {
+		encoded[0] = 1
+	}
+	for _, field := range [][]byte{j.PublicKey1, j.PublicKey2, j.AdaptorPoint, j.Nonce1, j.Nonce2, j.Proof1, j.Proof2, j.AdaptorProof} {
+		encoded = append(encoded, field...)
+	}
+	return m.parseBody(encoded)
+}
+
+func (m *PtlcFunded) appendBody(b []byte) ([]byte, error) {
+	return append(b, m.Id.Bytes()...), nil
+}
+
+func (m *PtlcFunded) parseBody(body []byte) error {
+	id, err := types.BytesToHash(body)
+	if err != nil {
+		return ErrMalformed
+	}
+	m.Id = id
+	return nil
+}
+
+type ptlcFundedJSON struct {
+	Id types.Hash `json:"id"`
+}
+
+func (m *PtlcFunded) jsonBody() (any, error) {
+	return ptlcFundedJSON{Id: m.Id}, nil
+}
+
+func (m *PtlcFunded) parseJSONBody(body json.RawMessage) error {
+	var j ptlcFundedJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	m.Id = j.Id
+	return nil
+}
+
+func (m *PartialChallenge) appendBody(b []byte) ([]byte, error) {
+	b = append(b, m.Partial1...)
+	return append(b, m.Partial2...), nil
+}
+
+func (m *PartialChallenge) parseBody(body []byte) error {
+	if len(body) != 2*ed25519.ScalarSize {
+		return ErrMalformed
+	}
+	partial1, err := ed25519.ParseScalar(body[:ed25519.ScalarSize])
+	if err != nil {
+		return err
+	}
+	partial2, err := ed25519.ParseScalar(body[ed25519.ScalarSize:])
+	if err != nil {
+		return err
+	}
+	m.Partial1, m.Partial2 = partial1, partial2
+	return nil
+}
+
+type partialChallengeJSON struct {
+	Partial1 hexBytes `json:"partial1"`
+	Partial2 hexBytes `json:"partial2"`
+}
+
+func (m *PartialChallenge) jsonBody() (any, error) {
+	return partialChallengeJSON{Partial1: hexBytes(m.Partial1), Partial2: hexBytes(m.Partial2)}, nil
+}
+
+func (m *PartialChallenge) parseJSONBody(body json.RawMessage) error {
+	var j partialChallengeJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	return m.parseBody(append(j.Partial1, j.Partial2...))
+}
+
+func (m *AdaptorSig) appendBody(b []byte) ([]byte, error) {
+	return append(b, m.Partial...), nil
+}
+
+func (m *AdaptorSig) parseBody(body []byte) error {
+	partial, err := ed25519.ParseScalar(body)
+	if err != nil {
+		return err
+	}
+	m.Partial = partial
+	return nil
+}
+
+type adaptorSigJSON struct {
+	Partial hexBytes `json:"partial"`
+}
+
+func (m *AdaptorSig) jsonBody() (any, error) {
+	return adaptorSigJSON{Partial: hexBytes(m.Partial)}, nil
+}
+
+func (m *AdaptorSig) parseJSONBody(body json.RawMessage) error {
+	var j adaptorSigJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	return m.parseBody(j.Partial)
+}
+
+func (m *Claimed) appendBody(b []byte) ([]byte, error) {
+	b = append(b, m.Id.Bytes()...)
+	return append(b, m.Signature...), nil
+}
+
+func (m *Claimed) parseBody(body []byte) error {
+	if len(body) != types.HashSize+ed25519.SignatureSize {
+		return ErrMalformed
+	}
+	id, err := types.BytesToHash(body[:types.HashSize])
+	if err != nil {
+		return ErrMalformed
+	}
+	m.Id = id
+	m.Signature = append([]byte(nil), body[types.HashSize:]...)
+	return nil
+}
+
+type claimedJSON struct {
+	Id        types.Hash `json:"id"`
+	Signature hexBytes   `json:"signature"`
+}
+
+func (m *Claimed) jsonBody() (any, error) {
+	return claimedJSON{Id: m.Id, Signature: hexBytes(m.Signature)}, nil
+}
+
+func (m *Claimed) parseJSONBody(body json.RawMessage) error {
+	var j claimedJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	return m.parseBody(append(j.Id.Bytes(), j.Signature...))
+}
+
+func (m *Abort) appendBody(b []byte) ([]byte, error) {
+	return append(b, m.reason()...), nil
+}
+
+// reason returns the reason as valid UTF-8 of at most MaxReasonSize bytes,
+// truncated at a rune boundary.
+func (m *Abort) reason() string {
+	reason := strings.ToValidUTF8(m.Reason, string(utf8.RuneError))
+	if len(reason) <= MaxReasonSize {
+		return reason
+	}
+	n := MaxReasonSize
+	for !utf8.RuneStart(reason[n]) {
+		n--
+	}
+	return reason[:n]
+}
+
+func (m *Abort) parseBody(body []byte) error {
+	if len(body) > MaxReasonSize || !utf8.Valid(body) {
+		return ErrMalformed
+	}
+	m.Reason = string(body)
+	return nil
+}
+
+type abortJSON struct {
+	Reason string `json:"reason"`
+}
+
+func (m *Abort) jsonBody() (any, error) {
+	return abortJSON{Reason: m.reason()}, nil
+}
+
+func (m *Abort) parseJSONBody(body json.RawMessage) error {
+	var j abortJSON
+	if err := strictUnmarshal(body, &j); err != nil {
+		return err
+	}
+	return m.parseBody([]byte(j.Reason))
+}
@@ -0,0 +1,357 @@
+package wire
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kinggorrin/ptlc/crypto/ed25519"
+	"github.com/kinggorrin/ptlc/swap"
+	"github.com/zenon-network/go-zenon/common/types"
+)
+
+// orderHex is the little-endian encoding of the group order l, the smallest
+// unreduced scalar.
+const orderHex = "edd3f55c1a631258d69cf7a2def9de1400000000000000000000000000000010"
+
+// nonCanonicalPointHex encodes a point with y = p + 3.
+const nonCanonicalPointHex = "f0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f"
+
+func newTestScalar(t *testing.T) ed25519.Scalar {
+	t.Helper()
+	s, err := ed25519.RandomScalar(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestKeyExchange(t *testing.T, initiator bool) *KeyExchange {
+	t.Helper()
+	keys := &swap.Keys{Key1: newTestScalar(t), Key2: newTestScalar(t), Adaptor: newTestScalar(t)}
+	_, N1, err := ed25519.GenerateNonce(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, N2, err := ed25519.GenerateNonce(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())
+	ke, err := swap.NewKeyExchange(nil, keys, address, initiator, N1, N2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &KeyExchange{ke}
+}
+
+func marshalBinary(t *testing.T, m Message) []byte {
+	t.Helper()
+	b, err := MarshalBinary(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// testMessages returns a message of every type. The reason of the Abort
+// message has the maximum size, so that no byte can be appended to it.
+func testMessages(t *testing.T) []struct {
+	name string
+	m    Message
+} {
+	t.Helper()
+	id := types.Hash(sha256.Sum256([]byte("PTLC")))
+	signature := make([]byte, ed25519.SignatureSize)
+	for i := range signature {
+		signature[i] = byte(i)
+	}
+	return []struct {
+		name string
+		m    Message
+	}{
+		{"hello", &Hello{Address: types.PubKeyToAddress(newTestScalar(t).ToCurvePoint())}},
+		{"keyExchange initiator", newTestKeyExchange(t, true)},
+		{"keyExchange responder", newTestKeyExchange(t, false)},
+		{"ptlcFunded", &PtlcFunded{Id: id}},
+		{"partialChallenge", &PartialChallenge{Partial1: newTestScalar(t), Partial2: newTestScalar(t)}},
+		{"adaptorSig", &AdaptorSig{Partial: newTestScalar(t)}},
+		{"claimed", &Claimed{Id: id, Signature: signature}},
+		{"abort", &Abort{Reason: strings.Repeat("a", MaxReasonSize)}},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range testMessages(t) {
+		t.Run(test.name, func(t *testing.T) {
+			b := marshalBinary(t, test.m)
+			if b[0] != Version || Type(b[1]) != test.m.Type() {
+				t.Fatalf("binary encoding starts with %x", b[:2])
+			}
+			m, err := UnmarshalBinary(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Type() != test.m.Type() || !bytes.Equal(marshalBinary(t, m), b) {
+				t.Fatal("binary round trip changed the message")
+			}
+
+			j, err := MarshalJSON(test.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m, err = UnmarshalJSON(j); err != nil {
+				t.Fatal(err)
+			}
+			if m.Type() != test.m.Type() || !bytes.Equal(marshalBinary(t, m), b) {
+				t.Fatal("JSON round trip changed the message")
+			}
+			if again, err := MarshalJSON(m); err != nil || !bytes.Equal(again, j) {
+				t.Fatalf("JSON round trip changed the encoding to %s", again)
+			}
+		})
+	}
+}
+
+func TestMarshalRejectsIncompleteKeyExchange(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip func(m *KeyExchange)
+	}{
+		{"key exchange", func(m *KeyExchange) { m.KeyExchange = nil }},
+		{"proof 1", func(m *KeyExchange) { m.Proof1 = nil }},
+		{"proof 2", func(m *KeyExchange) { m.Proof2 = nil }},
+		{"adaptor proof", func(m *KeyExchange) { m.AdaptorProof = nil }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newTestKeyExchange(t, true)
+			test.strip(m)
+			if _, err := MarshalBinary(m); err != ErrMalformed {
+				t.Fatalf("MarshalBinary returned %v, want %v", err, ErrMalformed)
+			}
+			if _, err := MarshalJSON(m); err != ErrMalformed {
+				t.Fatalf("MarshalJSON returned %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinaryRejects(t *testing.T) {
+	for _, input := range [][]byte{nil, {Version}} {
+		if _, err := UnmarshalBinary(input); err != ErrMalformed {
+			t.Fatalf("UnmarshalBinary(%x) returned %v, want %v", input, err, ErrMalformed)
+		}
+	}
+
+	for _, test := range testMessages(t) {
+		t.Run(test.name, func(t *testing.T) {
+			b := marshalBinary(t, test.m)
+			if _, err := UnmarshalBinary(append(b, 0)); err == nil {
+				t.Fatal("UnmarshalBinary accepted trailing bytes")
+			}
+
+			for _, version := range []byte{0, Version + 1} {
+				encoded := append([]byte{version}, b[1:]...)
+				if _, err := UnmarshalBinary(encoded); err != ErrUnsupportedVersion {
+					t.Fatalf("version %d returned %v, want %v", version, err, ErrUnsupportedVersion)
+				}
+			}
+			for _, typ := range []Type{0, TypeAbort + 1, 0xff} {
+				encoded := append([]byte{Version, byte(typ)}, b[2:]...)
+				if _, err := UnmarshalBinary(encoded); err != ErrUnknownType {
+					t.Fatalf("%v returned %v, want %v", typ, err, ErrUnknownType)
+				}
+			}
+		})
+	}
+}
+
+// TestUnmarshalBinaryRejectsNonCanonical covers the types whose bodies have
+// non-canonical encodings. Addresses, ids and signatures are fixed-size
+// byte strings, so every body of Hello, PtlcFunded and Claimed of the right
+// length is canonical.
+func TestUnmarshalBinaryRejectsNonCanonical(t *testing.T) {
+	order, _ := hex.DecodeString(orderHex)
+	nonCanonicalPoint, _ := hex.DecodeString(nonCanonicalPointHex)
+	ke := marshalBinary(t, newTestKeyExchange(t, true))
+	partial := newTestScalar(t)
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		err     error
+	}{
+		{
+			name:    "keyExchange role flag",
+			encoded: append([]byte{Version, byte(TypeKeyExchange), 2}, ke[3:]...),
+		},
+		{
+			name:    "keyExchange public key",
+			encoded: append(append(append([]byte(nil), ke[:3]...), nonCanonicalPoint...), ke[3+ed25519.PublicKeySize:]...),
+			err:     ed25519.ErrNonCanonicalPoint,
+		},
+		{
+			name:    "partialChallenge partial 1",
+			encoded: marshalBinary(t, &PartialChallenge{Partial1: order, Partial2: partial}),
+			err:     ed25519.ErrInvalidScalar,
+		},
+		{
+			name:    "partialChallenge partial 2",
+			encoded: marshalBinary(t, &PartialChallenge{Partial1: partial, Partial2: order}),
+			err:     ed25519.ErrInvalidScalar,
+		},
+		{
+			name:    "adaptorSig partial",
+			encoded: marshalBinary(t, &AdaptorSig{Partial: order}),
+			err:     ed25519.ErrInvalidScalar,
+		},
+		{
+			name:    "abort invalid UTF-8",
+			encoded: append([]byte{Version, byte(TypeAbort)}, "bad\xffreason"...),
+			err:     ErrMalformed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := UnmarshalBinary(test.encoded)
+			if err == nil || (test.err != nil && err != test.err) {
+				t.Fatalf("UnmarshalBinary returned %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONRejects(t *testing.T) {
+	for _, test := range testMessages(t) {
+		t.Run(test.name, func(t *testing.T) {
+			j, err := MarshalJSON(test.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded := string(j)
+			name := `"type":"` + test.m.Type().String() + `"`
+			body := encoded[strings.Index(encoded, `"body":`):]
+
+			tests := []struct {
+				name    string
+				encoded string
+				err     error
+			}{
+				{"whitespace", strings.Replace(encoded, `{"version"`, `{ "version"`, 1), ErrNonCanonical},
+				{"trailing newline", encoded + "\n", ErrNonCanonical},
+				{"trailing value", encoded + "{}", ErrMalformed},
+				{"reordered fields", `{` + name + `,"version":1,` + body, ErrNonCanonical},
+				{"unknown field", strings.Replace(encoded, `{"version"`, `{"extra":0,"version"`, 1), ErrMalformed},
+				{"unknown body field", strings.Replace(encoded, `"body":{`, `"body":{"extra":0,`, 1), ErrMalformed},
+				{"version 0", strings.Replace(encoded, `"version":1`, `"version":0`, 1), ErrUnsupportedVersion},
+				{"version 2", strings.Replace(encoded, `"version":1`, `"version":2`, 1), ErrUnsupportedVersion},
+				{"unknown type", strings.Replace(encoded, name, `"type":"unknown"`, 1), ErrUnknownType},
+				{"numeric type", strings.Replace(encoded, name, `"type":"Type(8)"`, 1), ErrUnknownType},
+			}
+			for _, test := range tests {
+				if _, err := UnmarshalJSON([]byte(test.encoded)); err != test.err {
+					t.Errorf("%s: UnmarshalJSON returned %v, want %v", test.name, err, test.err)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONRejectsNonCanonical(t *testing.T) {
+	partial := hex.EncodeToString(newTestScalar(t))
+	ke, err := MarshalJSON(newTestKeyExchange(t, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey1 := string(ke)[strings.Index(string(ke), `"publicKey1":"`):][:len(`"publicKey1":"`)+2*ed25519.PublicKeySize]
+
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		{
+			name:    "uppercase hex",
+			encoded: `{"version":1,"type":"adaptorSig","body":{"partial":"` + strings.ToUpper(partial) + `"}}`,
+			err:     ErrNonCanonical,
+		},
+		{
+			name:    "escaped string",
+			encoded: `{"version":1,"type":"abort","body":{"reason":"\u0061"}}`,
+			err:     ErrNonCanonical,
+		},
+		{
+			name:    "invalid hex",
+			encoded: `{"version":1,"type":"adaptorSig","body":{"partial":"` + partial[1:] + `"}}`,
+			err:     ErrMalformed,
+		},
+		{
+			name:    "unreduced scalar",
+			encoded: `{"version":1,"type":"adaptorSig","body":{"partial":"` + orderHex + `"}}`,
+			err:     ed25519.ErrInvalidScalar,
+		},
+		{
+			name:    "non-canonical point",
+			encoded: strings.Replace(string(ke), publicKey1, `"publicKey1":"`+nonCanonicalPointHex, 1),
+			err:     ed25519.ErrNonCanonicalPoint,
+		},
+		{
+			name:    "empty adaptor point",
+			encoded: strings.Replace(string(ke), `"nonce1"`, `"adaptorPoint":"","nonce1"`, 1),
+		},
+		{
+			name:    "invalid UTF-8",
+			encoded: `{"version":1,"type":"abort","body":{"reason":"bad` + "\xff" + `reason"}}`,
+			err:     ErrNonCanonical,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := UnmarshalJSON([]byte(test.encoded))
+			if err == nil || (test.err != nil && err != test.err) {
+				t.Fatalf("UnmarshalJSON returned %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestAbortReasonTruncated(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		want   string
+	}{
+		{"short", "expired", "expired"},
+		{"ascii", strings.Repeat("a", MaxReasonSize+1), strings.Repeat("a", MaxReasonSize)},
+		// The 3-byte rune would straddle the limit and is dropped whole.
+		{"rune", strings.Repeat("a", MaxReasonSize-1) + "€", strings.Repeat("a", MaxReasonSize-1)},
+		{"invalid", "bad\xffreason", "bad�reason"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := UnmarshalBinary(marshalBinary(t, &Abort{Reason: test.reason}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.(*Abort).Reason; got != test.want {
+				t.Fatalf("binary reason = %q, want %q", got, test.want)
+			}
+
+			b, err := MarshalJSON(&Abort{Reason: test.reason})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err = UnmarshalJSON(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.(*Abort).Reason; got != test.want || !utf8.ValidString(got) {
+				t.Fatalf("JSON reason = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package wire defines the typed messages that the parties of a swap
+// exchange, and their canonical binary and JSON encodings.
+//
+// Every encoded message starts with the protocol Version and its Type. The
+// binary encoding is version || type || body, where the body is the fixed
+// layout of the message documented on its type. The JSON encoding is the
+// object {"version":1,"type":"hello","body":{...}} with the fields in the
+// order of the message struct and all binary values in lowercase hex.
+//
+// Decoding accepts only canonical encodings: the JSON decoder rejects
+// unknown fields, whitespace and any other deviation from the output of
+// MarshalJSON, and both decoders check every field exactly as the binary
+// parsers of the crypto and swap packages do.
+package wire
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Version is the version of the wire protocol implemented by this package.
+const Version = 1
+
+var (
+	// ErrUnsupportedVersion is returned when a message has a protocol
+	// version other than Version.
+	ErrUnsupportedVersion = errors.New("wire: unsupported protocol version")
+	// ErrUnknownType is returned for messages of an unknown type.
+	ErrUnknownType = errors.New("wire: unknown message type")
+	// ErrMalformed is returned when a message body does not have the layout
+	// of its type.
+	ErrMalformed = errors.New("wire: malformed message")
+	// ErrNonCanonical is returned when a JSON message is not in canonical
+	// form.
+	ErrNonCanonical = errors.New("wire: non-canonical JSON encoding")
+)
+
+// Type identifies the kind of a message.
+type Type uint8
+
+// The message types, in the order in which a swap sends them. Abort may be
+// sent at any time.
+const (
+	TypeHello Type = iota + 1
+	TypeKeyExchange
+	TypePtlcFunded
+	TypePartialChallenge
+	TypeAdaptorSig
+	TypeClaimed
+	TypeAbort
+)
+
+var typeNames = map[Type]string{
+	TypeHello:            "hello",
+	TypeKeyExchange:      "keyExchange",
+	TypePtlcFunded:       "ptlcFunded",
+	TypePartialChallenge: "partialChallenge",
+	TypeAdaptorSig:       "adaptorSig",
+	TypeClaimed:          "claimed",
+	TypeAbort:            "abort",
+}
+
+// String returns the name of the type used in the JSON encoding.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "Type(" + strconv.Itoa(int(t)) + ")"
+}
+
+// Message is a message of the wire protocol.
+type Message interface {
+	// Type returns the type of the message.
+	Type() Type
+
+	// appendBody appends the binary body of the message to b. It fails if
+	// a field that the body requires is missing.
+	appendBody(b []byte) ([]byte, error)
+	// parseBody decodes and checks the binary body of the message.
+	parseBody(body []byte) error
+	// jsonBody returns the JSON representation of the message body. It
+	// fails if a field that the body requires is missing.
+	jsonBody() (any, error)
+	// parseJSONBody decodes and checks the JSON body of the message.
+	parseJSONBody(body json.RawMessage) error
+}
+
+// newMessage returns an empty message of type t.
+func newMessage(t Type) (Message, error) {
+	switch t {
+	case TypeHello:
+		return new(Hello), nil
+	case TypeKeyExchange:
+		return new(KeyExchange), nil
+	case TypePtlcFunded:
+		return new(PtlcFunded), nil
+	case TypePartialChallenge:
+		return new(PartialChallenge), nil
+	case TypeAdaptorSig:
+		return new(AdaptorSig), nil
+	case TypeClaimed:
+		return new(Claimed), nil
+	case TypeAbort:
+		return new(Abort), nil
+	default:
+		return nil, ErrUnknownType
+	}
+}
+
+// MarshalBinary returns the binary encoding version || type || body of m. It
+// returns ErrMalformed if a field that the body requires is missing.
+func MarshalBinary(m Message) ([]byte, error) {
+	return m.appendBody([]byte{Version, byte(m.Type())})
+}
+
+// UnmarshalBinary decodes a binary encoded message.
+func UnmarshalBinary(b []byte) (Message, error) {
+	if len(b) < 2 {
+		return nil, ErrMalformed
+	}
+	if b[0] != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	m, err := newMessage(Type(b[1]))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.parseBody(b[2:]); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// envelope is the JSON encoding of a message.
+type envelope struct {
+	Version int             `json:"version"`
+	Type    string          `json:"type"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// MarshalJSON returns the canonical JSON encoding of m. It returns
+// ErrMalformed if a field that the body requires is missing.
+func MarshalJSON(m Message) ([]byte, error) {
+	j, err := m.jsonBody()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{Version: Version, Type: m.Type().String(), Body: body})
+}
+
+// UnmarshalJSON decodes a JSON encoded message in canonical form.
+func UnmarshalJSON(b []byte) (Message, error) {
+	var e envelope
+	if err := strictUnmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if e.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var m Message
+	for t, name := range typeNames {
+		if name == e.Type {
+			m, _ = newMessage(t)
+		}
+	}
+	if m == nil {
+		return nil, ErrUnknownType
+	}
+	if err := m.parseJSONBody(e.Body); err != nil {
+		return nil, err
+	}
+
+	canonical, err := MarshalJSON(m)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(canonical, b) {
+		return nil, ErrNonCanonical
+	}
+	return m, nil
+}
+
+// strictUnmarshal decodes a single JSON value into v, rejecting unknown
+// fields and trailing data.
+func strictUnmarshal(b []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return ErrMalformed
+	}
+	if decoder.More() {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// hexBytes is a byte string encoded as lowercase hex in JSON.
+type hexBytes []byte
+
+func (h hexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h)), nil
+}
+
+func (h *hexBytes) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return ErrMalformed
+	}
+	*h = b
+	return nil
+}